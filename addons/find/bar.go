@@ -0,0 +1,321 @@
+package find
+
+import (
+	"fmt"
+	"image"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	gvcolor "github.com/oligo/gvcode/color"
+)
+
+// Colors defines the color scheme of the find bar and the match highlights.
+type Colors struct {
+	// Background is the bar background color.
+	Background gvcolor.Color
+	// Border is the bar border color.
+	Border gvcolor.Color
+	// Text is the text color of the bar.
+	Text gvcolor.Color
+	// Error is the text color of the error of the query.
+	Error gvcolor.Color
+	// ButtonBackground is the background of the buttons.
+	ButtonBackground gvcolor.Color
+	// ToggleOn is the background of the toggles that are on.
+	ToggleOn gvcolor.Color
+	// Match is the background of the matches.
+	Match gvcolor.Color
+	// CurrentMatch is the background of the current match.
+	CurrentMatch gvcolor.Color
+	// CurrentMatchBorder is the border of the current match.
+	CurrentMatchBorder gvcolor.Color
+}
+
+// DefaultColors returns the default color scheme of the find bar.
+func DefaultColors() Colors {
+	background, _ := gvcolor.Hex2Color("#252526")
+	border, _ := gvcolor.Hex2Color("#3C3C3C")
+	text, _ := gvcolor.Hex2Color("#CCCCCC")
+	errColor, _ := gvcolor.Hex2Color("#F48771")
+	buttonBg, _ := gvcolor.Hex2Color("#2D2D2D")
+	toggleOn, _ := gvcolor.Hex2Color("#0E639C")
+	match, _ := gvcolor.Hex2Color("#623315B0")
+	currentMatch, _ := gvcolor.Hex2Color("#9E6A03B0")
+	currentBorder, _ := gvcolor.Hex2Color("#F2CC60")
+
+	return Colors{
+		Background:         background,
+		Border:             border,
+		Text:               text,
+		Error:              errColor,
+		ButtonBackground:   buttonBg,
+		ToggleOn:           toggleOn,
+		Match:              match,
+		CurrentMatch:       currentMatch,
+		CurrentMatchBorder: currentBorder,
+	}
+}
+
+// Update processes the events of the bar.
+func (f *FindBar) Update(gtx layout.Context) {
+	if f.focus {
+		f.focus = false
+		if f.visible {
+			gtx.Execute(key.FocusCmd{Tag: &f.query})
+		} else {
+			gtx.Execute(key.FocusCmd{Tag: f.Editor})
+		}
+	}
+	if !f.visible {
+		return
+	}
+
+	for _, tag := range []any{&f.query, &f.replacement} {
+		for {
+			evt, ok := gtx.Event(
+				key.Filter{Focus: tag, Name: key.NameEscape},
+				key.Filter{Focus: tag, Name: key.NameF3, Optional: key.ModShift},
+				key.Filter{Focus: tag, Name: key.NameReturn, Required: key.ModShift},
+				key.Filter{Focus: tag, Name: key.NameEnter, Required: key.ModShift},
+			)
+			if !ok {
+				break
+			}
+			ke, ok := evt.(key.Event)
+			if !ok || ke.State != key.Press {
+				continue
+			}
+			switch ke.Name {
+			case key.NameEscape:
+				f.Close()
+				return
+			case key.NameF3:
+				f.findStep(ke.Modifiers.Contain(key.ModShift))
+			default:
+				f.FindPrevious()
+			}
+		}
+	}
+
+	for {
+		evt, ok := f.query.Update(gtx)
+		if !ok {
+			break
+		}
+		switch evt.(type) {
+		case widget.ChangeEvent:
+			f.search()
+		case widget.SubmitEvent:
+			f.FindNext()
+		}
+	}
+	for {
+		evt, ok := f.replacement.Update(gtx)
+		if !ok {
+			break
+		}
+		if _, ok := evt.(widget.SubmitEvent); ok {
+			f.Replace()
+		}
+	}
+
+	if f.matchCase.Update(gtx) || f.wholeWord.Update(gtx) || f.regex.Update(gtx) {
+		f.search()
+	}
+	if f.inSelection.Update(gtx) {
+		f.setInSelection(f.inSelection.Value)
+	}
+
+	if f.prevBtn.Clicked(gtx) {
+		f.FindPrevious()
+	}
+	if f.nextBtn.Clicked(gtx) {
+		f.FindNext()
+	}
+	if f.replaceBtn.Clicked(gtx) {
+		f.Replace()
+	}
+	if f.replaceAllBtn.Clicked(gtx) {
+		f.ReplaceAll()
+	}
+	if f.closeBtn.Clicked(gtx) {
+		f.Close()
+		return
+	}
+
+	// the matches are updated after the text is edited.
+	if f.searcher != nil && f.searcher.Changed() {
+		f.decorate()
+	}
+}
+
+// Layout processes the events and lays out the bar. It lays out nothing if the
+// bar is closed.
+func (f *FindBar) Layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	f.Update(gtx)
+	if !f.visible {
+		return layout.Dimensions{}
+	}
+
+	gtx.Constraints.Min.Y = 0
+	macro := op.Record(gtx.Ops)
+	dims := layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return f.layoutFindRow(gtx, th)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				if !f.replacing {
+					return layout.Dimensions{}
+				}
+				return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					return f.layoutReplaceRow(gtx, th)
+				})
+			}),
+		)
+	})
+	callOp := macro.Stop()
+
+	defer clip.Rect(image.Rectangle{Max: dims.Size}).Push(gtx.Ops).Pop()
+	paint.ColorOp{Color: f.Colors.Background.NRGBA()}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	callOp.Add(gtx.Ops)
+	f.layoutBorder(gtx, dims.Size)
+
+	return dims
+}
+
+func (f *FindBar) layoutFindRow(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return f.layoutField(gtx, th, &f.query, "Find")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutToggle(gtx, th, &f.matchCase, "Aa")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutToggle(gtx, th, &f.wholeWord, "ab")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutToggle(gtx, th, &f.regex, ".*")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutToggle(gtx, th, &f.inSelection, "≡")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return layout.Inset{Left: unit.Dp(8), Right: unit.Dp(4)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return f.layoutStatus(gtx, th)
+			})
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutButton(gtx, th, &f.prevBtn, "↑")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutButton(gtx, th, &f.nextBtn, "↓")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutButton(gtx, th, &f.closeBtn, "✕")
+		}),
+	)
+}
+
+func (f *FindBar) layoutReplaceRow(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	return layout.Flex{Axis: layout.Horizontal, Alignment: layout.Middle}.Layout(gtx,
+		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+			return f.layoutField(gtx, th, &f.replacement, "Replace")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutButton(gtx, th, &f.replaceBtn, "Replace")
+		}),
+		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return f.layoutButton(gtx, th, &f.replaceAllBtn, "All")
+		}),
+	)
+}
+
+func (f *FindBar) layoutField(gtx layout.Context, th *material.Theme, editor *widget.Editor, hint string) layout.Dimensions {
+	return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		ed := material.Editor(th, editor, hint)
+		ed.TextSize = f.TextSize
+		ed.Color = f.Colors.Text.NRGBA()
+		ed.HintColor = f.Colors.Text.MulAlpha(0x80).NRGBA()
+		return ed.Layout(gtx)
+	})
+}
+
+func (f *FindBar) layoutStatus(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	textColor := f.Colors.Text
+	var status string
+	switch idx, total, capped := f.Matches(); {
+	case f.err != nil:
+		status = "Invalid pattern"
+		textColor = f.Colors.Error
+	case f.query.Len() == 0:
+		status = ""
+	case total == 0:
+		status = "No results"
+	case capped && idx < 0:
+		status = fmt.Sprintf("? of %d+", total)
+	case capped:
+		status = fmt.Sprintf("%d of %d+", idx+1, total)
+	case idx < 0:
+		status = fmt.Sprintf("? of %d", total)
+	default:
+		status = fmt.Sprintf("%d of %d", idx+1, total)
+	}
+
+	label := material.Label(th, f.TextSize, status)
+	label.Color = textColor.NRGBA()
+	return label.Layout(gtx)
+}
+
+func (f *FindBar) layoutToggle(gtx layout.Context, th *material.Theme, toggle *widget.Bool, labelText string) layout.Dimensions {
+	return toggle.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		bg := f.Colors.ButtonBackground
+		if toggle.Value {
+			bg = f.Colors.ToggleOn
+		}
+		return f.layoutLabel(gtx, th, bg, labelText)
+	})
+}
+
+func (f *FindBar) layoutButton(gtx layout.Context, th *material.Theme, btn *widget.Clickable, labelText string) layout.Dimensions {
+	return btn.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return f.layoutLabel(gtx, th, f.Colors.ButtonBackground, labelText)
+	})
+}
+
+func (f *FindBar) layoutLabel(gtx layout.Context, th *material.Theme, bg gvcolor.Color, labelText string) layout.Dimensions {
+	return layout.Inset{Left: unit.Dp(2), Right: unit.Dp(2)}.Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Background{}.Layout(gtx,
+			func(gtx layout.Context) layout.Dimensions {
+				rr := gtx.Dp(unit.Dp(4))
+				rect := clip.UniformRRect(image.Rectangle{Max: gtx.Constraints.Min}, rr)
+				paint.FillShape(gtx.Ops, bg.NRGBA(), rect.Op(gtx.Ops))
+				return layout.Dimensions{Size: gtx.Constraints.Min}
+			},
+			func(gtx layout.Context) layout.Dimensions {
+				return layout.UniformInset(unit.Dp(4)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+					label := material.Label(th, f.TextSize, labelText)
+					label.Color = f.Colors.Text.NRGBA()
+					return label.Layout(gtx)
+				})
+			},
+		)
+	})
+}
+
+func (f *FindBar) layoutBorder(gtx layout.Context, size image.Point) {
+	height := gtx.Dp(unit.Dp(1))
+	rect := image.Rectangle{Min: image.Pt(0, size.Y-height), Max: size}
+	stack := clip.Rect(rect).Push(gtx.Ops)
+	paint.ColorOp{Color: f.Colors.Border.NRGBA()}.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	stack.Pop()
+}
@@ -0,0 +1,361 @@
+// Package find provides a find and replace bar for the gvcode editor.
+package find
+
+import (
+	"math"
+	"strings"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"github.com/oligo/gvcode"
+	"github.com/oligo/gvcode/textstyle/decoration"
+	"github.com/oligo/gvcode/textview"
+)
+
+const (
+	// decorationSource is the source of the decorations of the matches.
+	decorationSource = "find"
+	// defaultMaxMatches is the default number of matches highlighted.
+	defaultMaxMatches = 1000
+)
+
+// FindBar is a find and replace bar for an editor. Ctrl+F opens it to find text,
+// and Ctrl+H opens it to find and replace text. F3 and Shift+F3 go to the next
+// and previous match. The matches are highlighted with decorations, and the
+// current match is selected in the editor.
+type FindBar struct {
+	Editor *gvcode.Editor
+	// Colors defines the color scheme.
+	Colors Colors
+	// TextSize is the size of the text in the bar.
+	TextSize unit.Sp
+	// MaxMatches caps the number of matches that are counted and highlighted.
+	// Replace all is not capped.
+	MaxMatches int
+
+	visible   bool
+	replacing bool
+	// focus requests to focus the query field, or the editor if the bar is closed.
+	focus bool
+
+	query       widget.Editor
+	replacement widget.Editor
+
+	matchCase   widget.Bool
+	wholeWord   widget.Bool
+	regex       widget.Bool
+	inSelection widget.Bool
+
+	prevBtn       widget.Clickable
+	nextBtn       widget.Clickable
+	replaceBtn    widget.Clickable
+	replaceAllBtn widget.Clickable
+	closeBtn      widget.Clickable
+
+	searcher *textview.Searcher
+	// scope is the selection to find in, captured when find in selection is
+	// turned on. The searcher keeps it in place over the edits while it is
+	// open, so the live range is read back from it.
+	scope gvcode.TextRange
+	err   error
+}
+
+// NewFindBar creates a find bar for the editor, and registers its key bindings
+// to the editor.
+func NewFindBar(editor *gvcode.Editor) *FindBar {
+	f := &FindBar{
+		Editor:     editor,
+		Colors:     DefaultColors(),
+		TextSize:   unit.Sp(13),
+		MaxMatches: defaultMaxMatches,
+	}
+	f.query.SingleLine = true
+	f.query.Submit = true
+	f.replacement.SingleLine = true
+	f.replacement.Submit = true
+
+	editor.RegisterCommand(f, key.Filter{Name: "F", Required: key.ModShortcut},
+		func(gtx layout.Context, evt key.Event) gvcode.EditorEvent {
+			f.Open(false)
+			return nil
+		})
+	editor.RegisterCommand(f, key.Filter{Name: "H", Required: key.ModShortcut},
+		func(gtx layout.Context, evt key.Event) gvcode.EditorEvent {
+			f.Open(true)
+			return nil
+		})
+	editor.RegisterCommand(f, key.Filter{Name: key.NameF3, Optional: key.ModShift},
+		func(gtx layout.Context, evt key.Event) gvcode.EditorEvent {
+			f.findStep(evt.Modifiers.Contain(key.ModShift))
+			return nil
+		})
+
+	return f
+}
+
+// Visible reports whether the bar is open.
+func (f *FindBar) Visible() bool {
+	return f.visible
+}
+
+// Open opens the bar and focuses the query field. The selected text becomes the
+// query if it is on a single line. The replace field is shown if replace is set.
+func (f *FindBar) Open(replace bool) {
+	f.visible = true
+	f.replacing = replace
+	f.focus = true
+
+	if sel := f.Editor.SelectedText(); sel != "" && !strings.Contains(sel, "\n") {
+		f.query.SetText(sel)
+	}
+	f.search()
+}
+
+// Close closes the bar, removes the highlights of the matches and focuses the
+// editor.
+func (f *FindBar) Close() {
+	if !f.visible {
+		return
+	}
+
+	f.visible = false
+	f.focus = true
+	f.closeSearcher()
+	f.Editor.ClearDecorations(decorationSource)
+}
+
+// SetQuery sets the text to find.
+func (f *FindBar) SetQuery(query string) {
+	f.query.SetText(query)
+	f.search()
+}
+
+// Matches returns the zero based index of the current match, and the number of
+// matches. The index is -1 if there is no current match. capped is set if the
+// number of matches reaches MaxMatches.
+func (f *FindBar) Matches() (idx, total int, capped bool) {
+	if f.searcher == nil {
+		return -1, 0, false
+	}
+	idx, total = f.searcher.Current()
+	_, capped = f.searcher.Count()
+	return idx, total, capped
+}
+
+// Err returns the error of the query, e.g., an invalid regular expression.
+func (f *FindBar) Err() error {
+	return f.err
+}
+
+func (f *FindBar) searchOptions() textview.SearchOptions {
+	return textview.SearchOptions{
+		Regex:      f.regex.Value,
+		IgnoreCase: !f.matchCase.Value,
+		WholeWord:  f.wholeWord.Value,
+		MaxMatches: f.MaxMatches,
+	}
+}
+
+func (f *FindBar) closeSearcher() {
+	if f.searcher != nil {
+		f.syncScope()
+		f.searcher.Close()
+		f.searcher = nil
+	}
+}
+
+// syncScope updates the scope to the range of the searcher, which is shifted by
+// the edits made since it was set.
+func (f *FindBar) syncScope() {
+	if f.searcher != nil && f.inSelection.Value {
+		f.scope.Start, f.scope.End = f.searcher.Scope()
+	}
+}
+
+// search starts a new search of the query, and selects the first match after
+// the selection start.
+func (f *FindBar) search() {
+	f.closeSearcher()
+	f.err = nil
+
+	query := f.query.Text()
+	if query == "" {
+		f.Editor.ClearDecorations(decorationSource)
+		return
+	}
+
+	s, err := f.Editor.NewSearcher(query, f.searchOptions())
+	if err != nil {
+		f.err = err
+		f.Editor.ClearDecorations(decorationSource)
+		return
+	}
+	if f.inSelection.Value {
+		s.SetScope(f.scope.Start, f.scope.End)
+	}
+	f.searcher = s
+
+	start, end := f.Editor.Selection()
+	if m, ok := s.SelectAt(min(start, end), false); ok {
+		f.reveal(m)
+	}
+	f.decorate()
+}
+
+// FindNext selects the next match.
+func (f *FindBar) FindNext() {
+	f.findStep(false)
+}
+
+// FindPrevious selects the previous match.
+func (f *FindBar) FindPrevious() {
+	f.findStep(true)
+}
+
+func (f *FindBar) findStep(backward bool) {
+	if f.searcher == nil {
+		return
+	}
+
+	var m textview.SearchMatch
+	var ok bool
+	start, end := f.Editor.Selection()
+	if f.isCurrentSelected() {
+		if backward {
+			m, ok = f.searcher.PrevMatch()
+		} else {
+			m, ok = f.searcher.NextMatch()
+		}
+	} else if backward {
+		m, ok = f.searcher.SelectAt(min(start, end), true)
+	} else {
+		m, ok = f.searcher.SelectAt(max(start, end), false)
+	}
+
+	if ok {
+		f.reveal(m)
+		f.decorate()
+	}
+}
+
+// isCurrentSelected reports whether the current match is the selection of the
+// editor.
+func (f *FindBar) isCurrentSelected() bool {
+	m, ok := f.searcher.CurrentMatch()
+	if !ok {
+		return false
+	}
+	start, end := f.Editor.Selection()
+	return m.Start == min(start, end) && m.End == max(start, end)
+}
+
+// reveal selects the match, which scrolls the editor to it.
+func (f *FindBar) reveal(m textview.SearchMatch) {
+	f.Editor.SetCaret(m.End, m.Start)
+}
+
+// Replace replaces the current match if it is selected, and selects the next
+// match. Otherwise it selects the next match.
+func (f *FindBar) Replace() {
+	if f.searcher == nil || f.Editor.ReadOnly() {
+		return
+	}
+	if !f.isCurrentSelected() {
+		f.FindNext()
+		return
+	}
+
+	m, _ := f.searcher.CurrentMatch()
+	repl := f.searcher.Expand(f.replacement.Text(), m)
+	if repl == "" {
+		f.Editor.Delete(1)
+	} else {
+		f.Editor.Insert(repl)
+	}
+
+	// the searcher is updated after the edit.
+	if next, ok := f.searcher.SelectAt(m.Start+len([]rune(repl)), false); ok {
+		f.reveal(next)
+	}
+	f.decorate()
+}
+
+// ReplaceAll replaces all the matches as a single undo step. It returns the
+// number of matches replaced.
+func (f *FindBar) ReplaceAll() int {
+	if f.searcher == nil || f.Editor.ReadOnly() {
+		return 0
+	}
+
+	opts := f.searchOptions()
+	opts.MaxMatches = math.MaxInt
+	s, err := f.Editor.NewSearcher(f.query.Text(), opts)
+	if err != nil {
+		return 0
+	}
+	defer s.Close()
+	if f.inSelection.Value {
+		f.syncScope()
+		s.SetScope(f.scope.Start, f.scope.End)
+	}
+
+	template := f.replacement.Text()
+	var ranges []gvcode.TextRange
+	var texts []string
+	for m := range s.Matches() {
+		ranges = append(ranges, gvcode.TextRange{Start: m.Start, End: m.End})
+		texts = append(texts, s.Expand(template, m))
+	}
+
+	n := f.Editor.ReplaceAllFunc(ranges, func(idx int) string { return texts[idx] })
+	f.syncScope()
+	f.decorate()
+	return n
+}
+
+// decorate highlights the matches, emphasizing the current one.
+func (f *FindBar) decorate() {
+	f.Editor.ClearDecorations(decorationSource)
+	if f.searcher == nil {
+		return
+	}
+	// the matches are decorated now.
+	f.searcher.Changed()
+
+	current, hasCurrent := f.searcher.CurrentMatch()
+	var decos []decoration.Decoration
+	for m := range f.searcher.Matches() {
+		deco := decoration.Decoration{
+			Source:     decorationSource,
+			Priority:   1,
+			Start:      m.Start,
+			End:        m.End,
+			Background: &decoration.Background{Color: f.Colors.Match},
+		}
+		if hasCurrent && m.Start == current.Start {
+			deco.Priority = 2
+			deco.Background = &decoration.Background{Color: f.Colors.CurrentMatch}
+			deco.Border = &decoration.Border{Color: f.Colors.CurrentMatchBorder}
+		}
+		decos = append(decos, deco)
+	}
+	f.Editor.AddDecorations(decos...)
+}
+
+// setInSelection turns find in selection on or off. The selection of the editor
+// becomes the scope when it is turned on.
+func (f *FindBar) setInSelection(on bool) {
+	// the scope of the current searcher is replaced below.
+	f.closeSearcher()
+	f.inSelection.Value = on
+	if on {
+		start, end := f.Editor.Selection()
+		f.scope = gvcode.TextRange{Start: min(start, end), End: max(start, end)}
+		if f.scope.Start == f.scope.End {
+			f.scope = gvcode.TextRange{Start: 0, End: f.Editor.Len()}
+		}
+	}
+	f.search()
+}
@@ -0,0 +1,80 @@
+package find
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"github.com/oligo/gvcode"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+// newTestEditor returns an editor of the text, laid out once.
+func newTestEditor(input string) *gvcode.Editor {
+	editor := &gvcode.Editor{}
+	editor.WithOptions(gvcode.WithColorScheme(syntax.ColorScheme{}))
+	editor.SetText(input)
+
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(500, 500)),
+	}
+	editor.Layout(gtx, text.NewShaper())
+	return editor
+}
+
+func TestReplaceInSelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		selection   gvcode.TextRange
+		replacement string
+		want        string
+	}{
+		{
+			name:        "longer replacement",
+			text:        "foo foo foo\nfoo foo foo\nfoo",
+			selection:   gvcode.TextRange{Start: 12, End: 19},
+			replacement: "quux",
+			want:        "foo foo foo\nquux quux foo\nfoo",
+		},
+		{
+			name:        "shorter replacement",
+			text:        "foo foo foo\nfoo foo foo\nfoo",
+			selection:   gvcode.TextRange{Start: 12, End: 19},
+			replacement: "x",
+			want:        "foo foo foo\nx x foo\nfoo",
+		},
+		{
+			name:        "deleted match",
+			text:        "foofoofoo foo",
+			selection:   gvcode.TextRange{Start: 0, End: 6},
+			replacement: "",
+			want:        "foo foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor := newTestEditor(tt.text)
+			f := NewFindBar(editor)
+
+			editor.SetCaret(tt.selection.Start, tt.selection.End)
+			f.setInSelection(true)
+			f.replacement.SetText(tt.replacement)
+			f.SetQuery("foo")
+
+			// replace the first match, changing the length of the scope.
+			f.Replace()
+			f.ReplaceAll()
+
+			if got := editor.Text(); got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+// Package gotoline provides a go to line prompt for the gvcode editor.
+package gotoline
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+	"github.com/oligo/gvcode"
+	gvcolor "github.com/oligo/gvcode/color"
+)
+
+var errInvalidTarget = errors.New("invalid line number")
+
+// Colors defines the color scheme of the prompt.
+type Colors struct {
+	// Background is the prompt background color.
+	Background gvcolor.Color
+	// Border is the prompt border color.
+	Border gvcolor.Color
+	// Text is the text color of the prompt.
+	Text gvcolor.Color
+	// Error is the text color of the error of the input.
+	Error gvcolor.Color
+}
+
+// DefaultColors returns the default color scheme of the prompt.
+func DefaultColors() Colors {
+	background, _ := gvcolor.Hex2Color("#252526")
+	border, _ := gvcolor.Hex2Color("#3C3C3C")
+	text, _ := gvcolor.Hex2Color("#CCCCCC")
+	errColor, _ := gvcolor.Hex2Color("#F48771")
+
+	return Colors{
+		Background: background,
+		Border:     border,
+		Text:       text,
+		Error:      errColor,
+	}
+}
+
+// GotoLine is a prompt to move the caret of an editor to a line. Ctrl+G opens
+// it. The input can be a line number, a line and column number separated by a
+// colon, like "12:5", or a number of lines to move relative to the caret line,
+// like "+10" or "-3". Lines and columns are counted from 1.
+type GotoLine struct {
+	Editor *gvcode.Editor
+	// Colors defines the color scheme.
+	Colors Colors
+	// TextSize is the size of the text in the prompt.
+	TextSize unit.Sp
+	// Width is the width of the prompt.
+	Width unit.Dp
+
+	visible bool
+	// focus requests to focus the input, or the editor if the prompt is closed.
+	focus bool
+	input widget.Editor
+	err   error
+}
+
+// NewGotoLine creates a go to line prompt for the editor, and registers its key
+// binding to the editor.
+func NewGotoLine(editor *gvcode.Editor) *GotoLine {
+	g := &GotoLine{
+		Editor:   editor,
+		Colors:   DefaultColors(),
+		TextSize: unit.Sp(13),
+		Width:    unit.Dp(320),
+	}
+	g.input.SingleLine = true
+	g.input.Submit = true
+
+	editor.RegisterCommand(g, key.Filter{Name: "G", Required: key.ModShortcut},
+		func(gtx layout.Context, evt key.Event) gvcode.EditorEvent {
+			g.Open()
+			return nil
+		})
+
+	return g
+}
+
+// Visible reports whether the prompt is open.
+func (g *GotoLine) Visible() bool {
+	return g.visible
+}
+
+// Open opens the prompt with an empty input, and focuses it.
+func (g *GotoLine) Open() {
+	g.visible = true
+	g.focus = true
+	g.err = nil
+	g.input.SetText("")
+}
+
+// Close closes the prompt and focuses the editor.
+func (g *GotoLine) Close() {
+	if !g.visible {
+		return
+	}
+	g.visible = false
+	g.focus = true
+}
+
+// Go moves the caret to the target of the input, which is revealed at the
+// center of the editor. The line of the target is flashed.
+func (g *GotoLine) Go(input string) error {
+	caretLine, _ := g.Editor.CaretPos()
+	line, col, err := ParseTarget(input, caretLine)
+	if err != nil {
+		return err
+	}
+
+	line = max(0, min(line, g.Editor.Lines()-1))
+	runeOff, _ := g.Editor.ConvertPos(line, col)
+	g.Editor.SetCaret(runeOff, runeOff)
+	start, end := g.Editor.LineRange(line)
+	g.Editor.RevealRange(start, end, gvcode.RevealCenter|gvcode.RevealFlash)
+	return nil
+}
+
+// ParseTarget parses the input of the prompt, returning the zero based line and
+// column of the target. currentLine is the zero based line the relative input is
+// relative to. The column is zero if the input has no column.
+func ParseTarget(input string, currentLine int) (line, col int, err error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, 0, errInvalidTarget
+	}
+
+	if input[0] == '+' || input[0] == '-' {
+		n, err := strconv.Atoi(input[1:])
+		if err != nil || n < 0 {
+			return 0, 0, errInvalidTarget
+		}
+		if input[0] == '-' {
+			n = -n
+		}
+		return max(0, currentLine+n), 0, nil
+	}
+
+	lineStr, colStr, hasCol := strings.Cut(input, ":")
+	line, err = strconv.Atoi(strings.TrimSpace(lineStr))
+	if err != nil || line < 1 {
+		return 0, 0, errInvalidTarget
+	}
+	if hasCol {
+		col, err = strconv.Atoi(strings.TrimSpace(colStr))
+		if err != nil || col < 1 {
+			return 0, 0, errInvalidTarget
+		}
+		col--
+	}
+	return line - 1, col, nil
+}
+
+// Update processes the events of the prompt.
+func (g *GotoLine) Update(gtx layout.Context) {
+	if g.focus {
+		g.focus = false
+		if g.visible {
+			gtx.Execute(key.FocusCmd{Tag: &g.input})
+		} else {
+			gtx.Execute(key.FocusCmd{Tag: g.Editor})
+		}
+	}
+	if !g.visible {
+		return
+	}
+
+	for {
+		evt, ok := gtx.Event(key.Filter{Focus: &g.input, Name: key.NameEscape})
+		if !ok {
+			break
+		}
+		if ke, ok := evt.(key.Event); ok && ke.State == key.Press {
+			g.Close()
+			return
+		}
+	}
+
+	for {
+		evt, ok := g.input.Update(gtx)
+		if !ok {
+			break
+		}
+		switch evt.(type) {
+		case widget.ChangeEvent:
+			g.err = nil
+		case widget.SubmitEvent:
+			if g.err = g.Go(g.input.Text()); g.err == nil {
+				g.Close()
+				return
+			}
+		}
+	}
+}
+
+// Layout processes the events and lays out the prompt. It lays out nothing if
+// the prompt is closed.
+func (g *GotoLine) Layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	g.Update(gtx)
+	if !g.visible {
+		return layout.Dimensions{}
+	}
+
+	width := min(gtx.Dp(g.Width), gtx.Constraints.Max.X)
+	gtx.Constraints = layout.Exact(image.Pt(width, gtx.Constraints.Max.Y))
+	gtx.Constraints.Min.Y = 0
+
+	macro := op.Record(gtx.Ops)
+	dims := layout.UniformInset(unit.Dp(6)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+		return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				ed := material.Editor(th, &g.input, "line[:column], +lines or -lines")
+				ed.TextSize = g.TextSize
+				ed.Color = g.Colors.Text.NRGBA()
+				ed.HintColor = g.Colors.Text.MulAlpha(0x80).NRGBA()
+				return ed.Layout(gtx)
+			}),
+			layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+				return layout.Inset{Top: unit.Dp(4)}.Layout(gtx, g.layoutStatus(th))
+			}),
+		)
+	})
+	callOp := macro.Stop()
+
+	rect := image.Rectangle{Max: dims.Size}
+	paint.FillShape(gtx.Ops, g.Colors.Background.NRGBA(), clip.Rect(rect).Op())
+	callOp.Add(gtx.Ops)
+	paint.FillShape(gtx.Ops, g.Colors.Border.NRGBA(),
+		clip.Stroke{Path: clip.Rect(rect).Path(), Width: float32(gtx.Dp(unit.Dp(1)))}.Op())
+
+	return dims
+}
+
+func (g *GotoLine) layoutStatus(th *material.Theme) layout.Widget {
+	return func(gtx layout.Context) layout.Dimensions {
+		textColor := g.Colors.Text
+		var status string
+		if g.err != nil {
+			status = g.err.Error()
+			textColor = g.Colors.Error
+		} else {
+			line, col := g.Editor.CaretPos()
+			status = fmt.Sprintf("Current line: %d, column: %d of %d lines", line+1, col+1, g.Editor.Lines())
+		}
+
+		label := material.Label(th, g.TextSize, status)
+		label.Color = textColor.NRGBA()
+		return label.Layout(gtx)
+	}
+}
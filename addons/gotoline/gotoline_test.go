@@ -0,0 +1,49 @@
+package gotoline
+
+import "testing"
+
+func TestParseTarget(t *testing.T) {
+	testcases := []struct {
+		input     string
+		line, col int
+		wantErr   bool
+	}{
+		{input: "12", line: 11},
+		{input: " 12 ", line: 11},
+		{input: "12:5", line: 11, col: 4},
+		{input: "12 : 5", line: 11, col: 4},
+		{input: "1", line: 0},
+		// the relative lines are relative to the line 10.
+		{input: "+3", line: 13},
+		{input: "-3", line: 7},
+		{input: "-30", line: 0},
+		{input: "+0", line: 10},
+		{input: "0", wantErr: true},
+		{input: "0:1", wantErr: true},
+		{input: ":5", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "12:abc", wantErr: true},
+		{input: "12:0", wantErr: true},
+		{input: "12:-1", wantErr: true},
+		{input: "+", wantErr: true},
+		{input: "+-3", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		line, col, err := ParseTarget(tc.input, 10)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: want an error, got line %d, column %d", tc.input, line, col)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.input, err)
+			continue
+		}
+		if line != tc.line || col != tc.col {
+			t.Errorf("%q: want line %d, column %d, got line %d, column %d", tc.input, tc.line, tc.col, line, col)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package gvcode
+
+import (
+	"github.com/oligo/gvcode/language"
+)
+
+// WithUnbalancedBracketsCheck configures whether to mark the brackets without
+// a matching bracket with squiggles of the UnbalancedBracketColor of the color
+// palette. The brackets in strings and comments are skipped if the syntax
+// tokens mark them. The check scans the whole document, so it runs once the
+// text stops changing for a moment rather than on every keystroke. It is
+// disabled by default, as the brackets of prose, like the smiley ":)", are
+// often unbalanced.
+func WithUnbalancedBracketsCheck(enabled bool) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.bracketChecker.enabled = enabled
+		e.bracketChecker.MarkDirty()
+	}
+}
+
+// JumpToMatchingBracket moves the caret to the matching bracket of the bracket
+// next to the caret, or to the closing bracket enclosing the caret. It reports
+// whether the caret is moved.
+func (e *Editor) JumpToMatchingBracket() bool {
+	e.initBuffer()
+	start, end := e.text.Selection()
+	left, right := e.text.NearestMatchingBracketsAt(start)
+	if left < 0 || right < 0 {
+		return false
+	}
+
+	caret := right
+	if start == right || start == right+1 {
+		caret = left
+	}
+	e.SetCaret(caret, caret)
+	return caret != start || start != end
+}
+
+// SelectToBracket selects the text between the brackets enclosing the
+// selection. If the text is already selected, the brackets are selected too.
+// It is bound to Ctrl+Alt+B, or Cmd+Alt+B on macOS. It reports whether the
+// selection is changed.
+func (e *Editor) SelectToBracket() bool {
+	e.initBuffer()
+	start, end := e.text.Selection()
+	lo, hi := min(start, end), max(start, end)
+
+	left, right := e.text.EnclosingBrackets(lo, hi)
+	if left < 0 {
+		return false
+	}
+	if lo == left+1 && hi == right {
+		e.SetCaret(right+1, left)
+	} else {
+		e.SetCaret(right, left+1)
+	}
+	return true
+}
+
+// RemoveBrackets removes the pair of brackets enclosing the selection. It is
+// bound to Ctrl+Alt+Shift+B, or Cmd+Alt+Shift+B on macOS. It reports whether
+// the text is changed.
+func (e *Editor) RemoveBrackets() bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	start, end := e.text.Selection()
+	left, right := e.text.EnclosingBrackets(min(start, end), max(start, end))
+	if left < 0 {
+		return false
+	}
+	return e.replaceSurround(left, right, language.Pair{})
+}
@@ -0,0 +1,92 @@
+package gvcode
+
+import (
+	"testing"
+	"time"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/oligo/gvcode/language"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+func TestBracketCheckerDue(t *testing.T) {
+	e := newTestEditor(t, "f(a", WithColorScheme(syntax.ColorScheme{}))
+	bc := &e.bracketChecker
+
+	// the check is off by default, and the squiggles are cleared at once.
+	bc.MarkDirty()
+	if bc.enabled || !bc.Due(layout.Context{Ops: new(op.Ops)}) {
+		t.Fatalf("disabled checker should be due at once")
+	}
+
+	e.WithOptions(WithUnbalancedBracketsCheck(true))
+	start := time.Unix(1000, 0)
+	due := func(after time.Duration) bool {
+		return bc.Due(layout.Context{Ops: new(op.Ops), Now: start.Add(after)})
+	}
+
+	if due(0) || due(bracketCheckDelay/2) {
+		t.Errorf("check should wait for the delay")
+	}
+	// an edit postpones the check.
+	bc.MarkDirty()
+	if due(bracketCheckDelay) {
+		t.Errorf("check should be postponed by the edit")
+	}
+	if !due(2 * bracketCheckDelay) {
+		t.Errorf("check should be due after the delay")
+	}
+	bc.Check(e.colorPalette.UnbalancedBracketColor)
+	if due(3 * bracketCheckDelay) {
+		t.Errorf("check should not be due after checking")
+	}
+}
+
+func TestBracketCommands(t *testing.T) {
+	w := newTestWindow(t, "f(a, b)")
+	w.editor.SetCaret(3, 3)
+
+	w.press("B", key.ModShortcut|key.ModAlt)
+	if start, end := w.editor.Selection(); start != 6 || end != 2 {
+		t.Errorf("Ctrl+Alt+B should select inside the brackets: %d, %d", start, end)
+	}
+	w.press("B", key.ModShortcut|key.ModAlt)
+	if start, end := w.editor.Selection(); start != 7 || end != 1 {
+		t.Errorf("Ctrl+Alt+B should select the brackets: %d, %d", start, end)
+	}
+
+	w.editor.SetCaret(3, 3)
+	w.press("B", key.ModShortcut|key.ModAlt|key.ModShift)
+	if got := w.editor.Text(); got != "fa, b" {
+		t.Errorf("Ctrl+Alt+Shift+B should remove the brackets: %q", got)
+	}
+}
+
+func TestSetLanguageRestoresPairs(t *testing.T) {
+	e := newTestEditor(t, "", WithBracketPairs(map[rune]rune{'<': '>'}), WithQuotePairs(map[rune]rune{'`': '`'}))
+	bq := e.text.BracketsQuotes
+
+	e.SetLanguage(&language.Config{
+		Brackets:         []language.Pair{{Open: "(", Close: ")"}},
+		AutoClosingPairs: []language.AutoClosingPair{{Pair: language.Pair{Open: "'", Close: "'"}}},
+	})
+	if _, ok := bq.GetClosingBracket('('); !ok {
+		t.Error("want the brackets of the language")
+	}
+	if _, ok := bq.GetClosingBracket('<'); ok {
+		t.Error("want the brackets of the option replaced")
+	}
+
+	e.SetLanguage(nil)
+	if _, ok := bq.GetClosingBracket('<'); !ok {
+		t.Error("want the brackets of the option restored")
+	}
+	if _, ok := bq.GetClosingBracket('('); ok {
+		t.Error("want the brackets of the language removed")
+	}
+	if _, ok := bq.GetClosingQuote('`'); !ok {
+		t.Error("want the quotes of the option restored")
+	}
+}
@@ -0,0 +1,380 @@
+package gvcode
+
+import (
+	"slices"
+	"strings"
+
+	"gioui.org/io/key"
+	"github.com/oligo/gvcode/internal/buffer"
+)
+
+// caretMarker is a secondary caret. The caret and the other end of its selection
+// are tracked with buffer markers, so they stay in place when the text before
+// them is edited.
+type caretMarker struct {
+	caret  *buffer.Marker
+	anchor *buffer.Marker
+	// virtual is the number of columns of a column selection beyond the end
+	// of the line before the caret.
+	virtual int
+}
+
+func (c *caretMarker) selection() (start, end int) {
+	return c.caret.Offset(), c.anchor.Offset()
+}
+
+// multiCaret manages the secondary carets of the editor. The primary caret is
+// the caret of the text view.
+type multiCaret struct {
+	carets []*caretMarker
+	// virtual is the number of virtual columns before the caret being edited,
+	// or the primary caret otherwise.
+	virtual int
+}
+
+func (m *multiCaret) active() bool {
+	return len(m.carets) > 0
+}
+
+func (m *multiCaret) add(src buffer.TextSource, start, end int) *caretMarker {
+	c := &caretMarker{}
+	m.set(src, c, start, end)
+	m.carets = append(m.carets, c)
+	return c
+}
+
+// set moves the caret, replacing its markers.
+func (m *multiCaret) set(src buffer.TextSource, c *caretMarker, start, end int) {
+	m.release(src, c)
+	c.caret, _ = src.CreateMarker(start, buffer.BiasForward)
+	c.anchor, _ = src.CreateMarker(end, buffer.BiasForward)
+}
+
+func (m *multiCaret) release(src buffer.TextSource, c *caretMarker) {
+	if c.caret != nil {
+		src.RemoveMarker(c.caret)
+	}
+	if c.anchor != nil {
+		src.RemoveMarker(c.anchor)
+	}
+	c.caret, c.anchor = nil, nil
+}
+
+func (m *multiCaret) remove(src buffer.TextSource, c *caretMarker) {
+	m.release(src, c)
+	m.carets = slices.DeleteFunc(m.carets, func(e *caretMarker) bool { return e == c })
+}
+
+func (m *multiCaret) clear(src buffer.TextSource) {
+	for _, c := range m.carets {
+		m.release(src, c)
+	}
+	m.carets = m.carets[:0]
+}
+
+// sorted returns the carets in document order.
+func (m *multiCaret) sorted() []*caretMarker {
+	carets := slices.Clone(m.carets)
+	slices.SortFunc(carets, func(a, b *caretMarker) int {
+		as, ae := a.selection()
+		bs, be := b.selection()
+		return min(as, ae) - min(bs, be)
+	})
+	return carets
+}
+
+// normalize removes the secondary carets that overlap the primary selection
+// [start, end] or an earlier caret in the document.
+func (m *multiCaret) normalize(src buffer.TextSource, start, end int) {
+	primStart, primEnd := min(start, end), max(start, end)
+	overlaps := func(s1, e1, s2, e2 int) bool {
+		if s1 == e1 || s2 == e2 {
+			return s1 <= e2 && s2 <= e1
+		}
+		return s1 < e2 && s2 < e1
+	}
+
+	lastEnd := -1
+	for _, c := range m.sorted() {
+		s, e := c.selection()
+		s, e = min(s, e), max(s, e)
+		if overlaps(s, e, primStart, primEnd) || s < lastEnd || (s == lastEnd && s == e) {
+			m.remove(src, c)
+			continue
+		}
+		lastEnd = e
+	}
+}
+
+// AddCaret adds a secondary caret at start, with the other end of its selection
+// at end. start and end are in runes, and represent offsets into the editor text.
+func (e *Editor) AddCaret(start, end int) {
+	e.initBuffer()
+	e.clearColumnSelection()
+	length := e.text.Len()
+	start = max(0, min(start, length))
+	end = max(0, min(end, length))
+	e.carets.add(e.buffer, start, end)
+	start, end = e.text.Selection()
+	e.carets.normalize(e.buffer, start, end)
+}
+
+// ClearCarets removes all the secondary carets, keeping the primary caret. It
+// also removes the column selection.
+func (e *Editor) ClearCarets() {
+	e.initBuffer()
+	e.clearColumnSelection()
+	e.carets.clear(e.buffer)
+}
+
+// Carets returns the selections of all the carets in document order, including
+// the primary caret. Start is the caret position and End is the other end of the
+// selection, like the values returned by Selection.
+func (e *Editor) Carets() []TextRange {
+	e.initBuffer()
+	start, end := e.text.Selection()
+	ranges := []TextRange{{Start: start, End: end}}
+	for _, c := range e.carets.carets {
+		s, end := c.selection()
+		ranges = append(ranges, TextRange{Start: s, End: end})
+	}
+
+	slices.SortFunc(ranges, func(a, b TextRange) int {
+		return min(a.Start, a.End) - min(b.Start, b.End)
+	})
+	return ranges
+}
+
+// caretPositions returns the selections of the secondary carets, followed by
+// the selection of the primary caret. The primary caret is the caret of the text
+// view if primary is nil.
+func (e *Editor) caretPositions(primary *caretMarker) []buffer.CursorPos {
+	positions := make([]buffer.CursorPos, 0, len(e.carets.carets)+1)
+	for _, c := range e.carets.carets {
+		if c == primary {
+			continue
+		}
+		start, end := c.selection()
+		positions = append(positions, buffer.CursorPos{Start: start, End: end})
+	}
+
+	start, end := e.text.Selection()
+	if primary != nil {
+		start, end = primary.selection()
+	}
+	return append(positions, buffer.CursorPos{Start: start, End: end})
+}
+
+// restoreCarets sets the carets to the selections returned by caretPositions.
+func (e *Editor) restoreCarets(positions []buffer.CursorPos) {
+	e.carets.clear(e.buffer)
+	length := e.text.Len()
+	for _, pos := range positions[:len(positions)-1] {
+		e.carets.add(e.buffer, min(pos.Start, length), min(pos.End, length))
+	}
+	primary := positions[len(positions)-1]
+	e.SetCaret(primary.Start, primary.End)
+	start, end := e.text.Selection()
+	e.carets.normalize(e.buffer, start, end)
+}
+
+// forEachCaret calls fn with each caret set as the caret of the text view, in
+// document order. The edits made by fn are batched as a single undo group, and
+// the carets are moved to the selections left by fn. The primary caret is
+// restored afterwards. It returns the last non-nil event returned by fn.
+//
+// The column selection is turned into regular carets, and the virtual columns
+// of a caret are available to fn while it is called.
+func (e *Editor) forEachCaret(fn func(idx int) EditorEvent) EditorEvent {
+	defer e.clearColumnSelection()
+	if !e.carets.active() || e.mode == ModeSnippet {
+		return fn(0)
+	}
+
+	// track the primary caret along with the others.
+	before := e.caretPositions(nil)
+	start, end := e.text.Selection()
+	primary := e.carets.add(e.buffer, start, end)
+	primary.virtual = e.carets.virtual
+
+	e.buffer.GroupOp()
+	var evt EditorEvent
+	for idx, c := range e.carets.sorted() {
+		e.text.SetCaret(c.selection())
+		e.carets.virtual = c.virtual
+		if ev := fn(idx); ev != nil {
+			evt = ev
+		}
+		start, end := e.text.Selection()
+		e.carets.set(e.buffer, c, start, end)
+	}
+	// undo and redo restore the carets.
+	e.buffer.SetCarets(before, e.caretPositions(primary))
+	e.buffer.UnGroupOp()
+
+	start, end = primary.selection()
+	e.carets.remove(e.buffer, primary)
+	e.text.SetCaret(start, end)
+	e.carets.normalize(e.buffer, start, end)
+	return evt
+}
+
+// onCaretsTextInput applies the text input to every caret. The input range of a
+// secondary caret is derived from the input range relative to the primary
+// selection, which may cover the text composed by an input method.
+func (e *Editor) onCaretsTextInput(ke key.EditEvent) {
+	if !e.carets.active() && !e.text.ColumnSelectionActive() {
+		e.onTextInput(ke)
+		return
+	}
+
+	start, end := e.text.Selection()
+	before := min(start, end) - min(ke.Range.Start, ke.Range.End)
+	after := max(ke.Range.Start, ke.Range.End) - max(start, end)
+	e.forEachCaret(func(int) EditorEvent {
+		e.fillVirtualSpace()
+		start, end := e.text.Selection()
+		caretKe := ke
+		caretKe.Range.Start = max(0, min(start, end)-before)
+		caretKe.Range.End = max(caretKe.Range.Start, min(e.text.Len(), max(start, end)+after))
+		e.onTextInput(caretKe)
+		// the input replaces the selection, so collapse it after the input. Gio
+		// only does that for the primary caret.
+		if start, end := e.text.Selection(); start != end {
+			e.text.SetCaret(max(start, end), max(start, end))
+		}
+		return nil
+	})
+	e.lastInput = &ke
+}
+
+// pasteAtCarets pastes the text at every caret. If the text has as many lines
+// as there are carets, each caret gets one line.
+func (e *Editor) pasteAtCarets(text string) EditorEvent {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) != len(e.carets.carets)+1 {
+		lines = nil
+	}
+
+	return e.forEachCaret(func(idx int) EditorEvent {
+		s := text
+		if lines != nil {
+			s = lines[idx]
+		}
+		e.fillVirtualSpace()
+		if e.Insert(s) != 0 {
+			return ChangeEvent{}
+		}
+		return nil
+	})
+}
+
+// AddNextOccurrence selects the word at the caret if there is no selection. Otherwise
+// it adds a caret selecting the next occurrence of the selected text, wrapping around
+// at the end of the document. The new caret becomes the primary caret.
+func (e *Editor) AddNextOccurrence() {
+	e.initBuffer()
+	e.clearColumnSelection()
+	start, end := e.text.Selection()
+	if start == end {
+		wordStart, wordEnd := e.text.WordBoundariesAt(start, false)
+		if wordStart != wordEnd {
+			e.SetCaret(wordEnd, wordStart)
+		}
+		return
+	}
+
+	occurrences := e.text.FindAllTextOccurrences(start, end)
+	if len(occurrences) == 0 {
+		return
+	}
+
+	selected := func(occ [2]int) bool {
+		for _, r := range e.Carets() {
+			if min(r.Start, r.End) == occ[0] && max(r.Start, r.End) == occ[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	// search after the primary selection first, then wrap around.
+	from := max(start, end)
+	idx := slices.IndexFunc(occurrences, func(occ [2]int) bool { return occ[0] >= from })
+	if idx < 0 {
+		idx = 0
+	}
+	for i := range occurrences {
+		occ := occurrences[(idx+i)%len(occurrences)]
+		if selected(occ) {
+			continue
+		}
+
+		e.carets.add(e.buffer, start, end)
+		e.SetCaret(occ[1], occ[0])
+		return
+	}
+}
+
+// SelectAllOccurrences adds a caret selecting each occurrence of the selected text,
+// or of the word at the caret if there is no selection.
+func (e *Editor) SelectAllOccurrences() {
+	e.initBuffer()
+	e.clearColumnSelection()
+	start, end := e.text.Selection()
+	if start == end {
+		start, end = e.text.WordBoundariesAt(start, false)
+		if start == end {
+			return
+		}
+		start, end = end, start
+	}
+
+	occurrences := e.text.FindAllTextOccurrences(start, end)
+	if len(occurrences) == 0 {
+		return
+	}
+
+	e.carets.clear(e.buffer)
+	for _, occ := range occurrences {
+		if occ[0] == min(start, end) {
+			continue
+		}
+		e.carets.add(e.buffer, occ[1], occ[0])
+	}
+	e.SetCaret(start, end)
+	e.carets.normalize(e.buffer, start, end)
+}
+
+// AddCaretAbove adds a caret on the line above the topmost caret, at the same column.
+func (e *Editor) AddCaretAbove() {
+	e.addCaretVertically(-1)
+}
+
+// AddCaretBelow adds a caret on the line below the bottommost caret, at the same column.
+func (e *Editor) AddCaretBelow() {
+	e.addCaretVertically(1)
+}
+
+func (e *Editor) addCaretVertically(direction int) {
+	e.initBuffer()
+	carets := e.Carets()
+	edge := carets[0].Start
+	if direction > 0 {
+		edge = carets[len(carets)-1].Start
+	}
+
+	line, p := e.text.FindParagraph(edge)
+	target := line + direction
+	if target < 0 || target >= e.text.Paragraphs() {
+		return
+	}
+
+	// keep the column, but stay before the line break.
+	lineStart, lineEnd := e.text.RangeOfLines(target, 1, false)
+	if r, err := e.text.ReadRuneAt(lineEnd - 1); err == nil && r == '\n' && lineEnd > lineStart {
+		lineEnd--
+	}
+	runeOff := min(lineStart+edge-p.RuneOff, lineEnd)
+	e.AddCaret(runeOff, runeOff)
+}
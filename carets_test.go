@@ -0,0 +1,144 @@
+package gvcode
+
+import (
+	"slices"
+	"testing"
+
+	"gioui.org/io/key"
+)
+
+func TestEditAtCarets(t *testing.T) {
+	testcases := []struct {
+		name    string
+		input   string
+		primary TextRange
+		carets  []TextRange
+		edit    func(w *testWindow)
+		want    string
+		// wantCarets are the carets after the edit, in document order.
+		wantCarets []TextRange
+	}{
+		{
+			name:       "type",
+			input:      "ab\nab\nab",
+			primary:    TextRange{Start: 1, End: 1},
+			carets:     []TextRange{{Start: 4, End: 4}, {Start: 7, End: 7}},
+			edit:       func(w *testWindow) { typeText(w.editor, "xy") },
+			want:       "axyb\naxyb\naxyb",
+			wantCarets: []TextRange{{Start: 3, End: 3}, {Start: 8, End: 8}, {Start: 13, End: 13}},
+		},
+		{
+			name:       "type over selections",
+			input:      "foo bar foo",
+			primary:    TextRange{Start: 3, End: 0},
+			carets:     []TextRange{{Start: 11, End: 8}},
+			edit:       func(w *testWindow) { typeText(w.editor, "x") },
+			want:       "x bar x",
+			wantCarets: []TextRange{{Start: 1, End: 1}, {Start: 7, End: 7}},
+		},
+		{
+			name:       "delete backward",
+			input:      "ab\nab\nab",
+			primary:    TextRange{Start: 2, End: 2},
+			carets:     []TextRange{{Start: 5, End: 5}, {Start: 8, End: 8}},
+			edit:       func(w *testWindow) { w.press(key.NameDeleteBackward, 0) },
+			want:       "a\na\na",
+			wantCarets: []TextRange{{Start: 1, End: 1}, {Start: 3, End: 3}, {Start: 5, End: 5}},
+		},
+		{
+			name:       "delete forward",
+			input:      "ab\nab\nab",
+			primary:    TextRange{Start: 0, End: 0},
+			carets:     []TextRange{{Start: 3, End: 3}, {Start: 6, End: 6}},
+			edit:       func(w *testWindow) { w.press(key.NameDeleteForward, 0) },
+			want:       "b\nb\nb",
+			wantCarets: []TextRange{{Start: 0, End: 0}, {Start: 2, End: 2}, {Start: 4, End: 4}},
+		},
+		{
+			name:       "paste a line per caret",
+			input:      "a\nb\nc",
+			primary:    TextRange{Start: 1, End: 1},
+			carets:     []TextRange{{Start: 3, End: 3}, {Start: 5, End: 5}},
+			edit:       func(w *testWindow) { w.editor.pasteAtCarets("1\n2\n3\n") },
+			want:       "a1\nb2\nc3",
+			wantCarets: []TextRange{{Start: 2, End: 2}, {Start: 5, End: 5}, {Start: 8, End: 8}},
+		},
+		{
+			name:       "paste the text at each caret",
+			input:      "a\nb\nc",
+			primary:    TextRange{Start: 1, End: 1},
+			carets:     []TextRange{{Start: 5, End: 5}},
+			edit:       func(w *testWindow) { w.editor.pasteAtCarets("12") },
+			want:       "a12\nb\nc12",
+			wantCarets: []TextRange{{Start: 3, End: 3}, {Start: 9, End: 9}},
+		},
+		{
+			name:       "carets merged by deleting",
+			input:      "abcd",
+			primary:    TextRange{Start: 3, End: 3},
+			carets:     []TextRange{{Start: 2, End: 2}},
+			edit:       func(w *testWindow) { w.press(key.NameDeleteBackward, 0); w.press(key.NameDeleteBackward, 0) },
+			want:       "d",
+			wantCarets: []TextRange{{Start: 0, End: 0}},
+		},
+		{
+			name:       "overlapping carets are removed",
+			input:      "abcdef",
+			primary:    TextRange{Start: 3, End: 1},
+			carets:     []TextRange{{Start: 2, End: 2}, {Start: 4, End: 6}, {Start: 5, End: 5}},
+			edit:       func(w *testWindow) {},
+			want:       "abcdef",
+			wantCarets: []TextRange{{Start: 3, End: 1}, {Start: 4, End: 6}},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWindow(t, tc.input)
+			w.editor.SetCaret(tc.primary.Start, tc.primary.End)
+			for _, c := range tc.carets {
+				w.editor.AddCaret(c.Start, c.End)
+			}
+
+			tc.edit(w)
+			if got := w.editor.Text(); got != tc.want {
+				t.Errorf("want text %q, got %q", tc.want, got)
+			}
+			if got := w.editor.Carets(); !slices.Equal(got, tc.wantCarets) {
+				t.Errorf("want carets %v, got %v", tc.wantCarets, got)
+			}
+		})
+	}
+}
+
+func TestUndoRedoCarets(t *testing.T) {
+	w := newTestWindow(t, "ab\nab\nab")
+	w.editor.SetCaret(1, 1)
+	w.editor.AddCaret(4, 4)
+	w.editor.AddCaret(7, 7)
+	before := w.editor.Carets()
+
+	typeText(w.editor, "x")
+	after := w.editor.Carets()
+
+	w.press("Z", key.ModShortcut)
+	if got := w.editor.Text(); got != "ab\nab\nab" {
+		t.Fatalf("undo: want text %q, got %q", "ab\nab\nab", got)
+	}
+	if got := w.editor.Carets(); !slices.Equal(got, before) {
+		t.Errorf("undo: want carets %v, got %v", before, got)
+	}
+
+	w.press("Z", key.ModShortcut|key.ModShift)
+	if got := w.editor.Text(); got != "axb\naxb\naxb" {
+		t.Fatalf("redo: want text %q, got %q", "axb\naxb\naxb", got)
+	}
+	if got := w.editor.Carets(); !slices.Equal(got, after) {
+		t.Errorf("redo: want carets %v, got %v", after, got)
+	}
+
+	// the primary caret is kept.
+	if start, end := w.editor.Selection(); start != 2 || end != 2 {
+		t.Errorf("redo: want primary caret at 2, got %d, %d", start, end)
+	}
+}
@@ -0,0 +1,314 @@
+package gvcode
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+)
+
+// TextCase is a letter case or an identifier naming style applied by
+// TransformCase.
+type TextCase uint8
+
+const (
+	// UpperCase converts all the letters to upper case.
+	UpperCase TextCase = iota
+	// LowerCase converts all the letters to lower case.
+	LowerCase
+	// TitleCase capitalizes the first letter of each word, and converts the
+	// other letters to lower case.
+	TitleCase
+	// CamelCase joins the words as camelCase.
+	CamelCase
+	// PascalCase joins the words as PascalCase.
+	PascalCase
+	// SnakeCase joins the words as snake_case.
+	SnakeCase
+	// KebabCase joins the words as kebab-case.
+	KebabCase
+	// ScreamingSnakeCase joins the words as SCREAMING_SNAKE_CASE.
+	ScreamingSnakeCase
+)
+
+// TransformCase converts the text of each selection, or the word at each caret
+// if there is no selection, to the case c. The identifier styles rewrite the
+// runs of words joined by spaces, underscores or hyphens, splitting the words
+// at the case changes too, so "parseHTTPRequest" becomes "parse_http_request"
+// in SnakeCase. The selections keep covering the transformed text. It reports
+// whether the text is changed.
+func (e *Editor) TransformCase(c TextCase) bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	changed := false
+	e.forEachCaret(func(int) EditorEvent {
+		if e.transformCaseAtCaret(c) {
+			changed = true
+		}
+		return nil
+	})
+	return changed
+}
+
+// CycleIdentifierCase converts the identifier at each caret, or the text of
+// each selection, to the identifier style after its current one, in the order
+// CamelCase, PascalCase, SnakeCase, KebabCase and ScreamingSnakeCase. It reports
+// whether the text is changed.
+func (e *Editor) CycleIdentifierCase() bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	changed := false
+	e.forEachCaret(func(int) EditorEvent {
+		start, end := e.text.Selection()
+		lo, hi := min(start, end), max(start, end)
+		if lo == hi {
+			lo, hi = e.caseWordAt(start, true)
+		}
+		if e.transformCaseAtCaret(nextIdentifierCase(e.textRange(lo, hi))) {
+			changed = true
+		}
+		return nil
+	})
+	return changed
+}
+
+// nextIdentifierCase returns the identifier style following the style of text
+// in the cycle of CycleIdentifierCase.
+func nextIdentifierCase(text string) TextCase {
+	first, _ := utf8.DecodeRuneInString(text)
+	switch {
+	case strings.Contains(text, "_") && strings.ToUpper(text) == text:
+		return CamelCase
+	case strings.Contains(text, "_"):
+		return KebabCase
+	case strings.Contains(text, "-"):
+		return ScreamingSnakeCase
+	case unicode.IsUpper(first):
+		return SnakeCase
+	default:
+		return PascalCase
+	}
+}
+
+func (e *Editor) transformCaseAtCaret(c TextCase) bool {
+	start, end := e.text.Selection()
+	lo, hi := min(start, end), max(start, end)
+	if lo == hi {
+		lo, hi = e.caseWordAt(start, c >= CamelCase)
+		if lo == hi {
+			return false
+		}
+	}
+
+	text := e.textRange(lo, hi)
+	converted := e.convertCase(text, c)
+	if converted == text {
+		return false
+	}
+	e.replace(lo, hi, converted)
+
+	n := utf8.RuneCountInString(converted)
+	switch {
+	case start < end:
+		e.SetCaret(lo, lo+n)
+	case start > end:
+		e.SetCaret(lo+n, lo)
+	case start == hi:
+		e.SetCaret(lo+n, lo+n)
+	default:
+		caret := lo + min(start-lo, n)
+		e.SetCaret(caret, caret)
+	}
+	return true
+}
+
+// caseWordAt returns the range of the word at caret. If joined is true, the
+// words joined by hyphens or underscores are included, as they make up a
+// single identifier.
+func (e *Editor) caseWordAt(caret int, joined bool) (start, end int) {
+	start, end = e.text.WordBoundariesAt(caret, false)
+	if start == end || !joined {
+		return start, end
+	}
+
+	wordAt := func(off int) bool {
+		r, err := e.text.ReadRuneAt(off)
+		return err == nil && e.isCaseWordRune(r)
+	}
+	for {
+		r, err := e.text.ReadRuneAt(end)
+		if err != nil || !isCaseJoiner(r) || r == ' ' || !wordAt(end+1) {
+			break
+		}
+		_, end = e.text.WordBoundariesAt(end+1, false)
+	}
+	for start > 1 {
+		r, err := e.text.ReadRuneAt(start - 1)
+		if err != nil || !isCaseJoiner(r) || r == ' ' || !wordAt(start-2) {
+			break
+		}
+		start, _ = e.text.WordBoundariesAt(start-1, false)
+	}
+	return start, end
+}
+
+// isCaseJoiner reports whether r joins the words of an identifier.
+func isCaseJoiner(r rune) bool {
+	return r == '_' || r == '-' || r == ' '
+}
+
+// isCaseWordRune reports whether r belongs to a word of an identifier.
+func (e *Editor) isCaseWordRune(r rune) bool {
+	return !isCaseJoiner(r) && !e.text.IsWordSeperator(r)
+}
+
+// convertCase converts text to the case c.
+func (e *Editor) convertCase(text string, c TextCase) string {
+	switch c {
+	case UpperCase:
+		return strings.ToUpper(text)
+	case LowerCase:
+		return strings.ToLower(text)
+	case TitleCase:
+		var b strings.Builder
+		wordStart := true
+		for _, r := range text {
+			if wordStart {
+				b.WriteRune(unicode.ToTitle(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			wordStart = e.text.IsWordSeperator(r)
+		}
+		return b.String()
+	}
+
+	// rewrite each run of words joined by joiners, keeping the other runes.
+	runes := []rune(text)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		if !e.isCaseWordRune(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+
+		end := i
+		for j := i; j < len(runes) && (isCaseJoiner(runes[j]) || e.isCaseWordRune(runes[j])); j++ {
+			if !isCaseJoiner(runes[j]) {
+				end = j + 1
+			}
+		}
+		b.WriteString(joinWords(splitWords(runes[i:end]), c))
+		i = end
+	}
+	return b.String()
+}
+
+// splitWords splits an identifier into words at the joiners and at the case
+// changes, like "parseHTTPRequest" into "parse", "HTTP" and "Request". Digits
+// stay in the word before them.
+func splitWords(runes []rune) []string {
+	var words []string
+	start := 0
+	flush := func(end int) {
+		if end > start {
+			words = append(words, string(runes[start:end]))
+		}
+		start = end
+	}
+
+	for i, r := range runes {
+		switch {
+		case isCaseJoiner(r):
+			flush(i)
+			start = i + 1
+		case i > start && unicode.IsUpper(r):
+			prev := runes[i-1]
+			// an upper case letter after a lower case or uncased rune, or the
+			// last upper case letter of an acronym followed by a lower case
+			// letter, like the "R" of "HTTPRequest".
+			if !unicode.IsUpper(prev) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				flush(i)
+			}
+		}
+	}
+	flush(len(runes))
+	return words
+}
+
+// joinWords joins the words in the identifier style c.
+func joinWords(words []string, c TextCase) string {
+	capitalize := func(w string) string {
+		r, size := utf8.DecodeRuneInString(w)
+		return string(unicode.ToTitle(r)) + strings.ToLower(w[size:])
+	}
+
+	var b strings.Builder
+	for i, w := range words {
+		switch c {
+		case CamelCase:
+			if i == 0 {
+				b.WriteString(strings.ToLower(w))
+			} else {
+				b.WriteString(capitalize(w))
+			}
+		case PascalCase:
+			b.WriteString(capitalize(w))
+		case SnakeCase:
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteString(strings.ToLower(w))
+		case KebabCase:
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteString(strings.ToLower(w))
+		case ScreamingSnakeCase:
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteString(strings.ToUpper(w))
+		}
+	}
+	return b.String()
+}
+
+// metaModifier is the modifier of the Emacs style Meta shortcuts: Alt, or Ctrl
+// on macOS, where Option+letter types accented letters and symbols.
+var metaModifier = func() key.Modifiers {
+	if key.ModShortcut == key.ModCommand {
+		return key.ModCtrl
+	}
+	return key.ModAlt
+}()
+
+// caseCommand handles the case shortcuts, like those of Emacs: Alt+U, Alt+L and
+// Alt+C convert the text to UpperCase, LowerCase and TitleCase, and Alt+Shift+C
+// cycles the identifier styles. Alt is replaced with Ctrl on macOS.
+func (e *Editor) caseCommand(gtx layout.Context, evt key.Event) EditorEvent {
+	var changed bool
+	switch {
+	case evt.Modifiers.Contain(key.ModShift):
+		changed = e.CycleIdentifierCase()
+	case evt.Name == "U":
+		changed = e.TransformCase(UpperCase)
+	case evt.Name == "L":
+		changed = e.TransformCase(LowerCase)
+	case evt.Name == "C":
+		changed = e.TransformCase(TitleCase)
+	}
+	if changed {
+		return ChangeEvent{}
+	}
+	return nil
+}
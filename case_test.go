@@ -0,0 +1,105 @@
+package gvcode
+
+import (
+	"slices"
+	"testing"
+
+	"gioui.org/io/key"
+)
+
+func TestSplitWords(t *testing.T) {
+	testcases := []struct {
+		input string
+		want  []string
+	}{
+		{input: "parseHTTPRequest", want: []string{"parse", "HTTP", "Request"}},
+		{input: "ParseHTTP", want: []string{"Parse", "HTTP"}},
+		{input: "snake_case_name", want: []string{"snake", "case", "name"}},
+		{input: "kebab-case", want: []string{"kebab", "case"}},
+		{input: "SCREAMING_SNAKE", want: []string{"SCREAMING", "SNAKE"}},
+		{input: "utf8Decoder", want: []string{"utf8", "Decoder"}},
+		{input: "base64URL", want: []string{"base64", "URL"}},
+		{input: "v2_api", want: []string{"v2", "api"}},
+		{input: "__private", want: []string{"private"}},
+		{input: "étéChaud", want: []string{"été", "Chaud"}},
+		{input: "ΑλφαΒήτα", want: []string{"Αλφα", "Βήτα"}},
+		{input: "变量名", want: []string{"变量名"}},
+	}
+
+	for _, tc := range testcases {
+		if got := splitWords([]rune(tc.input)); !slices.Equal(got, tc.want) {
+			t.Errorf("%q: want %q, got %q", tc.input, tc.want, got)
+		}
+	}
+}
+
+func TestJoinWords(t *testing.T) {
+	words := []string{"parse", "HTTP", "Request"}
+	testcases := []struct {
+		c    TextCase
+		want string
+	}{
+		{c: CamelCase, want: "parseHttpRequest"},
+		{c: PascalCase, want: "ParseHttpRequest"},
+		{c: SnakeCase, want: "parse_http_request"},
+		{c: KebabCase, want: "parse-http-request"},
+		{c: ScreamingSnakeCase, want: "PARSE_HTTP_REQUEST"},
+	}
+
+	for _, tc := range testcases {
+		if got := joinWords(words, tc.c); got != tc.want {
+			t.Errorf("case %d: want %q, got %q", tc.c, tc.want, got)
+		}
+	}
+}
+
+func TestConvertCase(t *testing.T) {
+	testcases := []struct {
+		input string
+		c     TextCase
+		want  string
+	}{
+		{input: "parseHTTPRequest", c: SnakeCase, want: "parse_http_request"},
+		{input: "parse_http_request", c: CamelCase, want: "parseHttpRequest"},
+		{input: "utf8Decoder", c: KebabCase, want: "utf8-decoder"},
+		{input: "max_retries_2", c: PascalCase, want: "MaxRetries2"},
+		{input: "étéChaud", c: ScreamingSnakeCase, want: "ÉTÉ_CHAUD"},
+		{input: "ÉTÉ_CHAUD", c: CamelCase, want: "étéChaud"},
+		// the runs of words are converted, and the other runes are kept.
+		{input: "foo_bar(baz_qux)", c: CamelCase, want: "fooBar(bazQux)"},
+		{input: "hello world", c: SnakeCase, want: "hello_world"},
+		{input: "hello wORLD", c: TitleCase, want: "Hello World"},
+		{input: "naïve café", c: UpperCase, want: "NAÏVE CAFÉ"},
+		{input: "ÀÉÎ", c: LowerCase, want: "àéî"},
+	}
+
+	e := newTestEditor(t, "")
+	for _, tc := range testcases {
+		if got := e.convertCase(tc.input, tc.c); got != tc.want {
+			t.Errorf("%q in case %d: want %q, got %q", tc.input, tc.c, tc.want, got)
+		}
+	}
+}
+
+func TestCaseCommands(t *testing.T) {
+	w := newTestWindow(t, "parse_http_request")
+	w.editor.SetCaret(3, 3)
+
+	w.press("U", metaModifier)
+	if got := w.editor.Text(); got != "PARSE_HTTP_REQUEST" {
+		t.Errorf("Alt+U: want upper case, got %q", got)
+	}
+	w.press("L", metaModifier)
+	if got := w.editor.Text(); got != "parse_http_request" {
+		t.Errorf("Alt+L: want lower case, got %q", got)
+	}
+
+	// Alt+Shift+C cycles the identifier styles.
+	want := []string{"parse-http-request", "PARSE_HTTP_REQUEST", "parseHttpRequest", "ParseHttpRequest", "parse_http_request"}
+	for _, text := range want {
+		w.press("C", metaModifier|key.ModShift)
+		if got := w.editor.Text(); got != text {
+			t.Errorf("Alt+Shift+C: want %q, got %q", text, got)
+		}
+	}
+}
@@ -118,6 +118,16 @@ type ColorPalette struct {
 	LineColor Color
 	// Color used to paint the line number
 	LineNumberColor Color
+	// Color used to paint the vertical rulers.
+	RulerColor Color
+	// Color used to paint the whitespace markers and control characters.
+	WhitespaceColor Color
+	// Color used to paint the indent guides.
+	IndentGuideColor Color
+	// Color used to paint the indent guide of the block containing the caret.
+	ActiveIndentGuideColor Color
+	// Color used to mark the brackets without a matching bracket.
+	UnbalancedBracketColor Color
 	// Other colors.
 	colors []Color
 }
@@ -0,0 +1,182 @@
+package gvcode
+
+import (
+	"image"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"gioui.org/io/clipboard"
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"github.com/oligo/gvcode/textview"
+)
+
+// columnSelectionChord are the modifiers moving the head of the column
+// selection with the arrow keys in any direction: Ctrl+Alt+Shift, or
+// Cmd+Alt+Shift on macOS. Alt+Shift+Up and Alt+Shift+Down also start a column
+// selection, while Alt+Shift+Left and Alt+Shift+Right only move the head of an
+// active one.
+const columnSelectionChord = key.ModShortcut | key.ModAlt | key.ModShift
+
+// columnState tracks the column selection of the editor, which is kept by the
+// text view. While there is a column selection, each of its lines has a caret,
+// with the head line as the primary caret.
+type columnState struct {
+	// dragging is set while a column selection is made with the pointer.
+	dragging bool
+}
+
+// columnClipboard is the text of the last column block copied by any editor, so
+// that a block copied in one editor is pasted as a block in the others too.
+var columnClipboard struct {
+	mu   sync.Mutex
+	text string
+}
+
+func setColumnClipboard(text string) {
+	columnClipboard.mu.Lock()
+	defer columnClipboard.mu.Unlock()
+	columnClipboard.text = text
+}
+
+// isColumnBlock reports whether the pasted text is the last copied column block.
+func isColumnBlock(text string) bool {
+	columnClipboard.mu.Lock()
+	defer columnClipboard.mu.Unlock()
+	return text == columnClipboard.text && strings.Contains(text, "\n")
+}
+
+// syncColumnCarets replaces the carets with a caret for each line of the column
+// selection. The carets on the lines shorter than the column record the virtual
+// columns before them.
+func (e *Editor) syncColumnCarets() {
+	ranges := e.text.ColumnRanges()
+	if len(ranges) == 0 {
+		return
+	}
+
+	e.carets.clear(e.buffer)
+	for i, r := range ranges {
+		virtual := 0
+		if r.Start == r.End {
+			virtual = min(r.StartVirtual, r.EndVirtual)
+		}
+		if i == 0 {
+			e.text.SetCaret(r.Start, r.End)
+			e.carets.virtual = virtual
+			continue
+		}
+		c := e.carets.add(e.buffer, r.Start, r.End)
+		c.virtual = virtual
+	}
+}
+
+// clearColumnSelection removes the column selection, keeping the carets of its
+// lines as regular carets.
+func (e *Editor) clearColumnSelection() {
+	e.text.ClearColumnSelection()
+	e.column.dragging = false
+	e.carets.virtual = 0
+	for _, c := range e.carets.carets {
+		c.virtual = 0
+	}
+}
+
+// startColumnSelectionAt starts a column selection at the pointer position.
+func (e *Editor) startColumnSelectionAt(pt image.Point) {
+	e.carets.clear(e.buffer)
+	e.text.StartColumnSelectionAt(pt)
+	e.syncColumnCarets()
+	e.column.dragging = true
+}
+
+// extendColumnSelectionTo moves the head of the column selection to the pointer
+// position.
+func (e *Editor) extendColumnSelectionTo(pt image.Point) {
+	e.text.ExtendColumnSelectionTo(pt)
+	e.syncColumnCarets()
+}
+
+// extendColumnSelection moves the head of the column selection by lines and
+// columns. If there is no column selection, it is started at the caret.
+func (e *Editor) extendColumnSelection(lines, cols int) {
+	if !e.text.ColumnSelectionActive() {
+		caret, _ := e.text.Selection()
+		e.carets.clear(e.buffer)
+		e.text.StartColumnSelection(caret)
+	}
+	e.text.MoveColumnSelection(lines, cols)
+	e.syncColumnCarets()
+}
+
+// fillVirtualSpace inserts spaces before the caret being edited up to its column,
+// if the caret is beyond the end of a line shorter than the column selection.
+func (e *Editor) fillVirtualSpace() {
+	virtual := e.carets.virtual
+	e.carets.virtual = 0
+	if virtual <= 0 || e.text.SelectionLen() != 0 {
+		return
+	}
+	e.Insert(strings.Repeat(" ", virtual))
+}
+
+// columnText returns the text of the column selection, with a line for each line
+// of the selection. The virtual columns are not included.
+func (e *Editor) columnText() string {
+	ranges := slices.Clone(e.text.ColumnRanges())
+	slices.SortFunc(ranges, func(a, b textview.ColumnRange) int { return a.Line - b.Line })
+
+	lines := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		startOff := e.text.ByteOffset(min(r.Start, r.End))
+		endOff := e.text.ByteOffset(max(r.Start, r.End))
+		buf := make([]byte, endOff-startOff)
+		n, _ := e.buffer.ReadAt(buf, startOff)
+		lines = append(lines, string(buf[:n]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// onColumnCopyCut copies the column selection as a block of lines, and deletes
+// the selected text from each line when cutting.
+func (e *Editor) onColumnCopyCut(gtx layout.Context, k key.Event) EditorEvent {
+	text := e.columnText()
+	setColumnClipboard(text)
+	gtx.Execute(clipboard.WriteCmd{Type: "application/text", Data: io.NopCloser(strings.NewReader(text))})
+	if k.Name != "X" || e.mode == ModeReadOnly {
+		return nil
+	}
+
+	return e.forEachCaret(func(int) EditorEvent {
+		if e.text.SelectionLen() != 0 && e.Delete(1) != 0 {
+			return ChangeEvent{}
+		}
+		return nil
+	})
+}
+
+// pasteColumnBlock pastes each line of the text at the column of the caret on
+// successive lines, filling the lines shorter than the column with spaces and
+// appending lines at the end of the document as needed.
+func (e *Editor) pasteColumnBlock(text string) EditorEvent {
+	e.buffer.GroupOp()
+	defer e.buffer.UnGroupOp()
+
+	if e.text.SelectionLen() != 0 {
+		e.Delete(1)
+	}
+	caret, _ := e.text.Selection()
+	line, col := e.text.ColumnAt(caret)
+	for i, s := range strings.Split(text, "\n") {
+		if line+i >= e.text.Paragraphs() {
+			e.text.SetCaret(e.text.Len(), e.text.Len())
+			e.Insert("\n")
+		}
+		runeOff, virtual := e.text.OffsetAtColumn(line+i, col)
+		e.text.SetCaret(runeOff, runeOff)
+		e.Insert(strings.Repeat(" ", virtual) + s)
+	}
+	return ChangeEvent{}
+}
@@ -0,0 +1,42 @@
+package gvcode
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"gioui.org/io/key"
+	"gioui.org/io/transfer"
+)
+
+func TestPasteColumnBlockAcrossEditors(t *testing.T) {
+	w := newTestWindow(t, "abcd\nefgh")
+	w.editor.text.StartColumnSelection(0)
+	w.editor.text.MoveColumnSelection(1, 4)
+	w.press("C", key.ModShortcut)
+
+	block := w.editor.columnText()
+	lines := strings.Split(block, "\n")
+	if len(lines) != 2 || lines[0] == "" {
+		t.Fatalf("want a column block of two lines, got %q", block)
+	}
+
+	// the block copied in the first editor is pasted as a block in another one.
+	other := newTestEditor(t, "xy\nzw")
+	other.SetCaret(0, 0)
+	other.onPasteEvent(transfer.DataEvent{
+		Type: "application/text",
+		Open: func() io.ReadCloser { return io.NopCloser(strings.NewReader(block)) },
+	})
+	if want := lines[0] + "xy\n" + lines[1] + "zw"; other.Text() != want {
+		t.Errorf("want %q, got %q", want, other.Text())
+	}
+
+	// a plain copy replaces the block.
+	w.editor.text.ClearColumnSelection()
+	w.editor.SetCaret(0, 2)
+	w.press("C", key.ModShortcut)
+	if isColumnBlock(block) {
+		t.Error("want the column block replaced by the plain copy")
+	}
+}
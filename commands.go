@@ -21,9 +21,12 @@ type keyCommand struct {
 }
 
 // RegisterCommand register an extra command handler responding to key events.
-// If there is an existing handler, it appends to the existing ones. Only the
-// last key filter is checked during event handling. This method is expected to
-// be invoked dynamically during layout.
+// If there is an existing handler, it appends to the existing ones. Of the
+// handlers of a key whose filters can match the same key event, only the last
+// one is checked during event handling, so a handler registered later overrides
+// the built-in one of the same key and modifiers. The handlers of the same key
+// with disjoint modifiers, like Ctrl+A and Shift+Alt+A, are all checked. This
+// method is expected to be invoked dynamically during layout.
 func (e *Editor) RegisterCommand(srcTag any, filter key.Filter, handler CommandHandler) {
 	if e.commands == nil {
 		e.commands = make(map[key.Name][]keyCommand)
@@ -76,13 +79,54 @@ func (e *Editor) buildBuiltinCommands() {
 		e.commands[filter.Name] = append(e.commands[filter.Name], keyCommand{filter: filter, handler: handler})
 	}
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameEnter, Optional: key.ModShift},
+	// registerCaretsCommand registers a command applied to every caret.
+	registerCaretsCommand := func(filter key.Filter, handler CommandHandler) {
+		registerCommand(filter, func(gtx layout.Context, evt key.Event) EditorEvent {
+			return e.forEachCaret(func(int) EditorEvent {
+				return handler(gtx, evt)
+			})
+		})
+	}
+
+	// registerColumnCommand registers a command applied to every caret, which
+	// moves the head of the column selection by cols on the column selection
+	// chord, starting one if needed. The expand selection chord expands the
+	// selection if cols is positive, or shrinks it, unless there is a column
+	// selection, whose head it moves like Alt+Shift does.
+	registerColumnCommand := func(filter key.Filter, cols int, handler CommandHandler) {
+		filter.Optional |= key.ModShortcut | expandSelectionChord
+		registerCommand(filter, func(gtx layout.Context, evt key.Event) EditorEvent {
+			switch {
+			case evt.Modifiers == columnSelectionChord:
+				e.extendColumnSelection(0, cols)
+				return nil
+			case e.text.ColumnSelectionActive() && (evt.Modifiers == expandSelectionChord || evt.Modifiers == key.ModAlt|key.ModShift):
+				e.extendColumnSelection(0, cols)
+				return nil
+			case evt.Modifiers == expandSelectionChord:
+				if cols > 0 {
+					e.ExpandSelection()
+				} else {
+					e.ShrinkSelection()
+				}
+				return nil
+			case key.ModShortcut == key.ModCommand && evt.Modifiers&(key.ModCommand|key.ModCtrl) != 0:
+				// Cmd and Ctrl are only parts of the chords on macOS.
+				return nil
+			}
+			return e.forEachCaret(func(int) EditorEvent {
+				return handler(gtx, evt)
+			})
+		})
+	}
+
+	registerCaretsCommand(key.Filter{Focus: e, Name: key.NameEnter, Optional: key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			return e.onInsertLineBreak(evt)
 		},
 	)
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameReturn, Optional: key.ModShift},
+	registerCaretsCommand(key.Filter{Focus: e, Name: key.NameReturn, Optional: key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			return e.onInsertLineBreak(evt)
 		},
@@ -127,11 +171,183 @@ func (e *Editor) buildBuiltinCommands() {
 
 	registerCommand(key.Filter{Focus: e, Name: "A", Required: key.ModShortcut},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
+			e.ClearCarets()
 			e.text.SetCaret(0, e.text.Len())
 			return nil
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameHome, Optional: key.ModShortcut | key.ModShift},
+	registerCommand(key.Filter{Focus: e, Name: "A", Required: key.ModShift | key.ModAlt},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if e.ToggleBlockComment() {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	registerCommand(key.Filter{Focus: e, Name: "/", Required: key.ModShortcut},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if e.ToggleLineComment() {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	// Ctrl+D adds the next occurrence, and Ctrl+Shift+D duplicates the lines.
+	registerCommand(key.Filter{Focus: e, Name: "D", Required: key.ModShortcut, Optional: key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if !evt.Modifiers.Contain(key.ModShift) {
+				e.AddNextOccurrence()
+				return nil
+			}
+			if e.DuplicateLines(false) {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	registerCommand(key.Filter{Focus: e, Name: "L", Required: key.ModShortcut | key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			e.SelectAllOccurrences()
+			return nil
+		})
+
+	registerCommand(key.Filter{Focus: e, Name: key.NameEscape},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			e.changeSurround = false
+			e.ClearCarets()
+			return nil
+		})
+
+	// Ctrl+Shift+I toggles the overwrite mode, as Gio reports no Insert key. The
+	// ModeChangedEvent is queued by the mode change.
+	registerCommand(key.Filter{Focus: e, Name: "I", Required: key.ModShortcut | key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			e.ToggleOverwrite()
+			return nil
+		})
+
+	registerCommand(key.Filter{Focus: e, Name: "K", Required: key.ModShortcut | key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if e.mode != ModeReadOnly && e.DeleteLine() != 0 {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	registerCommand(key.Filter{Focus: e, Name: "\\", Required: key.ModShortcut | key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			e.JumpToMatchingBracket()
+			return nil
+		})
+
+	// Ctrl+Alt+B selects to the enclosing brackets, and Ctrl+Alt+Shift+B removes
+	// them.
+	registerCommand(key.Filter{Focus: e, Name: "B", Required: key.ModShortcut | key.ModAlt, Optional: key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if !evt.Modifiers.Contain(key.ModShift) {
+				e.SelectToBracket()
+				return nil
+			}
+			if e.RemoveBrackets() {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	// Ctrl+Alt+D deletes the brackets or quotes around the selection, and
+	// Ctrl+Alt+R replaces them with the pair opened by the next typed text.
+	registerCommand(key.Filter{Focus: e, Name: "D", Required: key.ModShortcut | key.ModAlt},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if e.DeleteSurround() {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	registerCommand(key.Filter{Focus: e, Name: "R", Required: key.ModShortcut | key.ModAlt},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			e.changeSurround = e.mode != ModeReadOnly
+			return nil
+		})
+
+	// Ctrl+Alt+I reindents the selected lines, and Ctrl+Alt+Shift+I converts
+	// the indentation between tabs and spaces.
+	registerCommand(key.Filter{Focus: e, Name: "I", Required: key.ModShortcut | key.ModAlt, Optional: key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			changed := false
+			if !evt.Modifiers.Contain(key.ModShift) {
+				changed = e.ReindentLines()
+			} else if e.text.SoftTab {
+				changed = e.ConvertIndentation(Tabs)
+			} else {
+				changed = e.ConvertIndentation(Spaces)
+			}
+			if changed {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	registerCommand(key.Filter{Focus: e, Name: "J", Required: key.ModShortcut},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if e.JoinLines() {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	for _, name := range []key.Name{"U", "L", "C"} {
+		registerCommand(key.Filter{Focus: e, Name: name, Required: metaModifier}, e.caseCommand)
+	}
+	registerCommand(key.Filter{Focus: e, Name: "C", Required: metaModifier | key.ModShift}, e.caseCommand)
+
+	// Alt+Q, or Ctrl+Q on macOS, reflows the paragraphs like in Emacs.
+	registerCommand(key.Filter{Focus: e, Name: "Q", Required: metaModifier},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			if e.ReflowParagraphs() {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	// F9 sorts the selected lines. The modifiers combine: Shift sorts them in
+	// descending order, Ctrl compares them case-insensitively and Alt compares
+	// the numbers in them by value.
+	registerCommand(key.Filter{Focus: e, Name: key.NameF9, Optional: key.ModShortcut | key.ModAlt | key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			opts := SortLinesOptions{
+				Descending: evt.Modifiers.Contain(key.ModShift),
+				IgnoreCase: evt.Modifiers.Contain(key.ModShortcut),
+				Natural:    evt.Modifiers.Contain(key.ModAlt),
+			}
+			if e.SortLines(opts) {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	// F10 removes the duplicates of the selected lines, and Shift+F10 reverses
+	// them.
+	registerCommand(key.Filter{Focus: e, Name: key.NameF10, Optional: key.ModShift},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			changed := false
+			if evt.Modifiers.Contain(key.ModShift) {
+				changed = e.ReverseLines()
+			} else {
+				changed = e.UniqueLines()
+			}
+			if changed {
+				return ChangeEvent{}
+			}
+			return nil
+		})
+
+	for _, name := range []key.Name{"=", "+", "-", "0"} {
+		registerCommand(key.Filter{Focus: e, Name: name, Required: key.ModShortcut, Optional: key.ModShift},
+			e.zoomCommand)
+	}
+
+	registerCaretsCommand(key.Filter{Focus: e, Name: key.NameHome, Optional: key.ModShortcut | key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			selAct := textview.SelectionClear
 			if evt.Modifiers.Contain(key.ModShift) {
@@ -146,7 +362,7 @@ func (e *Editor) buildBuiltinCommands() {
 			return nil
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameEnd, Optional: key.ModShortcut | key.ModShift},
+	registerCaretsCommand(key.Filter{Focus: e, Name: key.NameEnd, Optional: key.ModShortcut | key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			selAct := textview.SelectionClear
 			if evt.Modifiers.Contain(key.ModShift) {
@@ -160,15 +376,19 @@ func (e *Editor) buildBuiltinCommands() {
 			return nil
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameTab, Optional: key.ModShift},
+	registerCaretsCommand(key.Filter{Focus: e, Name: key.NameTab, Optional: key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			return e.onTab(evt)
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameDeleteBackward, Optional: key.ModShortcutAlt | key.ModShift},
+	registerCaretsCommand(key.Filter{Focus: e, Name: key.NameDeleteBackward, Optional: key.ModShortcutAlt | key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			if e.mode != ModeReadOnly {
 				moveByWord := evt.Modifiers.Contain(key.ModShortcutAlt)
+				if e.carets.virtual > 0 && e.text.SelectionLen() == 0 {
+					// nothing to delete beyond the end of the line.
+					return nil
+				}
 
 				if moveByWord {
 					if e.deleteWord(-1) != 0 {
@@ -183,10 +403,13 @@ func (e *Editor) buildBuiltinCommands() {
 			return nil
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameDeleteForward, Optional: key.ModShortcutAlt | key.ModShift},
+	registerCaretsCommand(key.Filter{Focus: e, Name: key.NameDeleteForward, Optional: key.ModShortcutAlt | key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			if e.mode != ModeReadOnly {
 				moveByWord := evt.Modifiers.Contain(key.ModShortcutAlt)
+				if e.carets.virtual > 0 && e.text.SelectionLen() == 0 {
+					return nil
+				}
 				if moveByWord {
 					if e.deleteWord(1) != 0 {
 						return ChangeEvent{}
@@ -230,7 +453,7 @@ func (e *Editor) buildBuiltinCommands() {
 		return atBeginning, atEnd
 	}
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameLeftArrow, Optional: key.ModShortcutAlt | key.ModShift},
+	registerColumnCommand(key.Filter{Focus: e, Name: key.NameLeftArrow, Optional: key.ModShortcutAlt | key.ModAlt | key.ModShift}, -1,
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			atBeginning, _ := checkPos(gtx)
 			if atBeginning {
@@ -258,22 +481,39 @@ func (e *Editor) buildBuiltinCommands() {
 			return nil
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameUpArrow, Optional: key.ModShortcutAlt | key.ModShift},
+	registerCommand(key.Filter{Focus: e, Name: key.NameUpArrow, Optional: key.ModShortcut | key.ModShortcutAlt | key.ModAlt | key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
-			atBeginning, _ := checkPos(gtx)
-			if atBeginning {
+			if evt.Modifiers == key.ModAlt|key.ModShift || evt.Modifiers == columnSelectionChord {
+				e.extendColumnSelection(-1, 0)
 				return nil
 			}
-
-			selAct := textview.SelectionClear
-			if evt.Modifiers.Contain(key.ModShift) {
-				selAct = textview.SelectionExtend
+			if evt.Modifiers.Contain(key.ModShortcut | key.ModAlt) {
+				e.AddCaretAbove()
+				return nil
 			}
-			e.text.MoveLines(-1, selAct)
-			return nil
+			if evt.Modifiers == key.ModAlt {
+				if e.MoveLines(-1) {
+					return ChangeEvent{}
+				}
+				return nil
+			}
+
+			return e.forEachCaret(func(int) EditorEvent {
+				atBeginning, _ := checkPos(gtx)
+				if atBeginning {
+					return nil
+				}
+
+				selAct := textview.SelectionClear
+				if evt.Modifiers.Contain(key.ModShift) {
+					selAct = textview.SelectionExtend
+				}
+				e.text.MoveLines(-1, selAct)
+				return nil
+			})
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameRightArrow, Optional: key.ModShortcutAlt | key.ModShift},
+	registerColumnCommand(key.Filter{Focus: e, Name: key.NameRightArrow, Optional: key.ModShortcutAlt | key.ModAlt | key.ModShift}, +1,
 		func(gtx layout.Context, evt key.Event) EditorEvent {
 			_, atEnd := checkPos(gtx)
 			if atEnd {
@@ -301,19 +541,36 @@ func (e *Editor) buildBuiltinCommands() {
 			return nil
 		})
 
-	registerCommand(key.Filter{Focus: e, Name: key.NameDownArrow, Optional: key.ModShortcutAlt | key.ModShift},
+	registerCommand(key.Filter{Focus: e, Name: key.NameDownArrow, Optional: key.ModShortcut | key.ModShortcutAlt | key.ModAlt | key.ModShift},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
-			_, atEnd := checkPos(gtx)
-			if atEnd {
+			if evt.Modifiers == key.ModAlt|key.ModShift || evt.Modifiers == columnSelectionChord {
+				e.extendColumnSelection(+1, 0)
 				return nil
 			}
-
-			selAct := textview.SelectionClear
-			if evt.Modifiers.Contain(key.ModShift) {
-				selAct = textview.SelectionExtend
+			if evt.Modifiers.Contain(key.ModShortcut | key.ModAlt) {
+				e.AddCaretBelow()
+				return nil
 			}
-			e.text.MoveLines(+1, selAct)
-			return nil
+			if evt.Modifiers == key.ModAlt {
+				if e.MoveLines(+1) {
+					return ChangeEvent{}
+				}
+				return nil
+			}
+
+			return e.forEachCaret(func(int) EditorEvent {
+				_, atEnd := checkPos(gtx)
+				if atEnd {
+					return nil
+				}
+
+				selAct := textview.SelectionClear
+				if evt.Modifiers.Contain(key.ModShift) {
+					selAct = textview.SelectionExtend
+				}
+				e.text.MoveLines(+1, selAct)
+				return nil
+			})
 		})
 
 }
@@ -324,31 +581,59 @@ func (e *Editor) processCommands(gtx layout.Context) EditorEvent {
 	}
 
 	for _, cmds := range e.commands {
-		cmd := cmds[len(cmds)-1]
-		for {
-			ke, ok := gtx.Event(cmd.filter)
-			if !ok {
-				break
+		for i, cmd := range cmds {
+			if shadowed(cmd, cmds[i+1:]) {
+				continue
+			}
+			if evt := e.processCommand(gtx, cmd); evt != nil {
+				return evt
 			}
+		}
+	}
 
-			e.blinkStart = gtx.Now
-			if ke, ok := ke.(key.Event); ok {
-				if !gtx.Focused(e) || ke.State != key.Press {
-					break
-				}
-				e.scrollCaret = true
-				e.scroller.Stop()
-				if cmd.tag == nil || cmd.tag == e {
-					e.cancelCompletor()
-				}
+	return nil
+}
 
-				if !ke.Modifiers.Contain(cmd.filter.Required) {
-					break
-				}
+// shadowed reports whether the filter of cmd overlaps with the filter of any of
+// the commands registered after it, which take precedence.
+func shadowed(cmd keyCommand, later []keyCommand) bool {
+	return slices.ContainsFunc(later, func(c keyCommand) bool {
+		return filtersOverlap(cmd.filter, c.filter)
+	})
+}
 
-				if evt := cmd.handler(gtx, ke); evt != nil {
-					return evt
-				}
+// filtersOverlap reports whether a key event can match both of the filters of
+// the same key name.
+func filtersOverlap(a, b key.Filter) bool {
+	required := a.Required | b.Required
+	allowed := (a.Required | a.Optional) & (b.Required | b.Optional)
+	return required&^allowed == 0
+}
+
+func (e *Editor) processCommand(gtx layout.Context, cmd keyCommand) EditorEvent {
+	for {
+		ke, ok := gtx.Event(cmd.filter)
+		if !ok {
+			break
+		}
+
+		e.blinkStart = gtx.Now
+		if ke, ok := ke.(key.Event); ok {
+			if !gtx.Focused(e) || ke.State != key.Press {
+				break
+			}
+			e.scrollCaret = true
+			e.scroller.Stop()
+			if cmd.tag == nil || cmd.tag == e {
+				e.cancelCompletor()
+			}
+
+			if !ke.Modifiers.Contain(cmd.filter.Required) {
+				break
+			}
+
+			if evt := cmd.handler(gtx, ke); evt != nil {
+				return evt
 			}
 		}
 	}
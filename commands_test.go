@@ -0,0 +1,112 @@
+package gvcode
+
+import (
+	"testing"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"github.com/oligo/gvcode/language"
+)
+
+func TestFiltersOverlap(t *testing.T) {
+	testcases := []struct {
+		a, b key.Filter
+		want bool
+	}{
+		{a: key.Filter{Name: "A"}, b: key.Filter{Name: "A"}, want: true},
+		{a: key.Filter{Name: "A", Required: key.ModShortcut}, b: key.Filter{Name: "A", Required: key.ModShortcut}, want: true},
+		{a: key.Filter{Name: "A", Required: key.ModShortcut}, b: key.Filter{Name: "A", Required: key.ModShift | key.ModAlt}, want: false},
+		{a: key.Filter{Name: "Z", Required: key.ModShortcut, Optional: key.ModShift}, b: key.Filter{Name: "Z", Required: key.ModShortcut | key.ModShift}, want: true},
+		{a: key.Filter{Name: "D", Required: key.ModShortcut}, b: key.Filter{Name: "D", Required: key.ModShortcut | key.ModShift}, want: false},
+	}
+
+	for i, tc := range testcases {
+		if got := filtersOverlap(tc.a, tc.b); got != tc.want {
+			t.Errorf("case %d: want %v, got %v", i, tc.want, got)
+		}
+		if got := filtersOverlap(tc.b, tc.a); got != tc.want {
+			t.Errorf("case %d reversed: want %v, got %v", i, tc.want, got)
+		}
+	}
+}
+
+func TestCommandDispatch(t *testing.T) {
+	cfg := &language.Config{}
+	cfg.Comments.BlockComment = language.Pair{Open: "/*", Close: "*/"}
+	w := newTestWindow(t, "abc")
+	w.editor.SetLanguage(cfg)
+
+	// the handlers of the same key with disjoint modifiers are all checked.
+	w.press("A", key.ModShortcut)
+	if start, end := w.editor.Selection(); start != 0 || end != 3 {
+		t.Fatalf("Ctrl+A did not select all: %d, %d", start, end)
+	}
+	w.press("A", key.ModShift|key.ModAlt)
+	if got := w.editor.Text(); got != "/* abc */" {
+		t.Fatalf("Shift+Alt+A did not toggle the block comment: %q", got)
+	}
+
+	// a handler registered later overrides the built-in one of the same key
+	// and modifiers, but not the ones with other modifiers.
+	overridden := 0
+	w.editor.RegisterCommand(w, key.Filter{Name: "A", Required: key.ModShortcut},
+		func(gtx layout.Context, evt key.Event) EditorEvent {
+			overridden++
+			return nil
+		})
+	w.frame()
+	w.editor.SetCaret(0, 0)
+	w.press("A", key.ModShortcut)
+	if start, end := w.editor.Selection(); overridden != 1 || start != 0 || end != 0 {
+		t.Errorf("Ctrl+A not overridden: %d calls, selection %d, %d", overridden, start, end)
+	}
+	w.editor.SetCaret(0, w.editor.Len())
+	w.press("A", key.ModShift|key.ModAlt)
+	if got := w.editor.Text(); got != "abc" {
+		t.Errorf("Shift+Alt+A should still toggle the block comment: %q", got)
+	}
+}
+
+func TestSelectionArrowChords(t *testing.T) {
+	w := newTestWindow(t, "foo bar\nfoo bar")
+	w.editor.SetCaret(1, 1)
+
+	// the expand selection chord with the right and left arrows grows and
+	// shrinks the selection.
+	w.press(key.NameRightArrow, expandSelectionChord)
+	if start, end := w.editor.Selection(); start != 3 || end != 0 {
+		t.Fatalf("expand: want the word selected, got %d, %d", start, end)
+	}
+	w.press(key.NameLeftArrow, expandSelectionChord)
+	if start, end := w.editor.Selection(); start != 1 || end != 1 {
+		t.Fatalf("shrink: want the caret restored, got %d, %d", start, end)
+	}
+	if w.editor.text.ColumnSelectionActive() {
+		t.Fatal("the expand selection chord should not start a column selection")
+	}
+
+	// the column selection chord starts a column selection horizontally.
+	w.press(key.NameRightArrow, columnSelectionChord)
+	w.press(key.NameRightArrow, columnSelectionChord)
+	if !w.editor.text.ColumnSelectionActive() {
+		t.Fatal("Ctrl+Alt+Shift+Right did not start a column selection")
+	}
+	w.press(key.NameDownArrow, columnSelectionChord)
+	carets := w.editor.Carets()
+	if len(carets) != 2 || carets[0].Start <= carets[0].End {
+		t.Fatalf("want a column selection on two lines, got %v", carets)
+	}
+
+	// Alt+Shift+Right moves the head of an active column selection.
+	w.press(key.NameRightArrow, key.ModAlt|key.ModShift)
+	if got := w.editor.Carets(); len(got) != 2 || got[0].Start <= carets[0].Start {
+		t.Errorf("Alt+Shift+Right did not extend the column selection: %v", got)
+	}
+
+	// Alt+Shift+Down starts a column selection vertically.
+	w.editor.SetCaret(0, 0)
+	w.press(key.NameDownArrow, key.ModAlt|key.ModShift)
+	if !w.editor.text.ColumnSelectionActive() {
+		t.Error("Alt+Shift+Down did not start a column selection")
+	}
+}
@@ -0,0 +1,217 @@
+package gvcode
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// offsetEdit is an edit at rune offset pos, deleting and inserting runes. It is
+// used to map the offsets before a series of edits to the offsets after them.
+type offsetEdit struct {
+	pos      int
+	deleted  int
+	inserted int
+}
+
+// mapOffset maps the offset p before the edits to the offset after them. The
+// edits must be sorted by pos and not overlap. If stick is set, an offset at
+// the position of an insertion stays before the inserted text.
+func mapOffset(edits []offsetEdit, p int, stick bool) int {
+	delta := 0
+	for _, ed := range edits {
+		if p < ed.pos || (p == ed.pos && stick && ed.deleted == 0) {
+			break
+		}
+		if p < ed.pos+ed.deleted {
+			// p is inside the deleted text.
+			return ed.pos + delta
+		}
+		delta += ed.inserted - ed.deleted
+	}
+	return p + delta
+}
+
+// mapSelection moves the selection over the edits so it still covers the same
+// text, keeping its direction.
+func (e *Editor) mapSelection(start, end int, edits []offsetEdit) {
+	empty := start == end
+	if start <= end {
+		e.SetCaret(mapOffset(edits, start, !empty), mapOffset(edits, end, false))
+	} else {
+		e.SetCaret(mapOffset(edits, start, false), mapOffset(edits, end, true))
+	}
+}
+
+// blanksAround returns the start of the spaces and tabs before lo, and the end
+// of the ones after hi.
+func (e *Editor) blanksAround(lo, hi int) (start, end int) {
+	isBlank := func(off int) bool {
+		r, err := e.text.ReadRuneAt(off)
+		return err == nil && (r == ' ' || r == '\t')
+	}
+	start, end = lo, hi
+	for start > 0 && isBlank(start-1) {
+		start--
+	}
+	for end < e.text.Len() && isBlank(end) {
+		end++
+	}
+	return start, end
+}
+
+func leadingSpaces(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+// ToggleLineComment comments the lines covered by the selection with the line
+// comment token of the language, or uncomments them if all of them are
+// commented. The comment tokens are aligned at the minimum indentation of the
+// lines, and blank lines are left alone. If the language has no line comment
+// token, the selection is toggled with block comment tokens instead. It reports
+// whether the text is changed.
+func (e *Editor) ToggleLineComment() bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly || e.lang == nil {
+		return false
+	}
+
+	token := e.lang.Comments.LineComment
+	if token == "" {
+		return e.ToggleBlockComment()
+	}
+
+	selStart, selEnd := e.text.Selection()
+	b := e.selectedLineBlock()
+
+	commented := true
+	minIndent := -1
+	for _, line := range b.lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := leadingSpaces(line)
+		if !strings.HasPrefix(line[len(indent):], token) {
+			commented = false
+		}
+		if n := utf8.RuneCountInString(indent); minIndent < 0 || n < minIndent {
+			minIndent = n
+		}
+	}
+	if minIndent < 0 {
+		// all the lines are blank.
+		return false
+	}
+
+	var edits []offsetEdit
+	lineOff := b.start
+	for i, line := range b.lines {
+		lineLen := utf8.RuneCountInString(line)
+		if strings.TrimSpace(line) != "" {
+			if commented {
+				indent := leadingSpaces(line)
+				removed := token
+				if strings.HasPrefix(line[len(indent)+len(token):], " ") {
+					removed += " "
+				}
+				b.lines[i] = indent + line[len(indent)+len(removed):]
+				edits = append(edits, offsetEdit{pos: lineOff + utf8.RuneCountInString(indent), deleted: utf8.RuneCountInString(removed)})
+			} else {
+				indent := string([]rune(line)[:minIndent])
+				b.lines[i] = indent + token + " " + line[len(indent):]
+				edits = append(edits, offsetEdit{pos: lineOff + minIndent, inserted: utf8.RuneCountInString(token) + 1})
+			}
+		}
+		lineOff += lineLen + 1
+	}
+
+	e.replaceLineBlock(b)
+	e.mapSelection(selStart, selEnd, edits)
+	return true
+}
+
+// ToggleBlockComment encloses the selection with the block comment tokens of
+// the language, or removes the tokens if the selection is enclosed by them. The
+// whitespace around the selection is ignored, and the spaces and tabs between the
+// tokens and the code are removed with the tokens. If there is no selection, the
+// trimmed line of the caret is used. It reports whether the text is changed.
+func (e *Editor) ToggleBlockComment() bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly || e.lang == nil {
+		return false
+	}
+
+	open, close := e.lang.Comments.BlockComment.Open, e.lang.Comments.BlockComment.Close
+	if open == "" || close == "" {
+		return false
+	}
+
+	selStart, selEnd := e.text.Selection()
+	lo, hi := min(selStart, selEnd), max(selStart, selEnd)
+	if lo == hi {
+		lo, hi = e.text.SelectedLineRange()
+	}
+
+	// trim the whitespace around the code.
+	text := e.textRange(lo, hi)
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		if selStart != selEnd {
+			return false
+		}
+		// insert an empty comment, with the caret inside it.
+		e.replace(selStart, selStart, open+"  "+close)
+		caret := selStart + utf8.RuneCountInString(open) + 1
+		e.SetCaret(caret, caret)
+		return true
+	}
+	lo += utf8.RuneCountInString(leadingSpaces(text))
+	hi = lo + utf8.RuneCountInString(trimmed)
+
+	openLen, closeLen := utf8.RuneCountInString(open), utf8.RuneCountInString(close)
+	// the blanks between the code and the tokens enclosing it.
+	blankStart, blankEnd := e.blanksAround(lo, hi)
+	var openEdit, closeEdit offsetEdit
+	switch {
+	case strings.HasPrefix(trimmed, open) && strings.HasSuffix(trimmed, close) && len(trimmed) >= len(open)+len(close):
+		// the selection includes the tokens.
+		inner := trimmed[len(open) : len(trimmed)-len(close)]
+		leading, trailing := leadingSpaces(inner), ""
+		if len(leading) < len(inner) {
+			trailing = inner[len(strings.TrimRight(inner, " \t")):]
+		}
+		openEdit = offsetEdit{pos: lo, deleted: openLen + utf8.RuneCountInString(leading)}
+		closeEdit = offsetEdit{pos: hi - closeLen - utf8.RuneCountInString(trailing), deleted: closeLen + utf8.RuneCountInString(trailing)}
+	case blankStart >= openLen && e.textRange(blankStart-openLen, blankStart) == open &&
+		e.textRange(blankEnd, min(e.text.Len(), blankEnd+closeLen)) == close:
+		// the tokens enclose the selection.
+		openEdit = offsetEdit{pos: blankStart - openLen, deleted: lo - blankStart + openLen}
+		closeEdit = offsetEdit{pos: hi, deleted: blankEnd - hi + closeLen}
+	default:
+		openEdit = offsetEdit{pos: lo, inserted: openLen + 1}
+		closeEdit = offsetEdit{pos: hi, inserted: closeLen + 1}
+	}
+
+	e.buffer.GroupOp()
+	if closeEdit.inserted > 0 {
+		e.replace(hi, hi, " "+close)
+		e.replace(lo, lo, open+" ")
+	} else {
+		e.replace(closeEdit.pos, closeEdit.pos+closeEdit.deleted, "")
+		e.replace(openEdit.pos, openEdit.pos+openEdit.deleted, "")
+	}
+	e.buffer.UnGroupOp()
+
+	edits := []offsetEdit{openEdit, closeEdit}
+	if selStart == selEnd {
+		e.mapSelection(selStart, selEnd, edits)
+		return true
+	}
+	// select the code.
+	newLo, newHi := mapOffset(edits, lo, false), mapOffset(edits, hi, true)
+	if selStart < selEnd {
+		e.SetCaret(newLo, newHi)
+	} else {
+		e.SetCaret(newHi, newLo)
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+package gvcode
+
+import (
+	"testing"
+
+	"github.com/oligo/gvcode/language"
+)
+
+// commentLanguage returns a language with C style comment tokens.
+func commentLanguage() *language.Config {
+	cfg := &language.Config{ID: "c"}
+	cfg.Comments.LineComment = "//"
+	cfg.Comments.BlockComment = language.Pair{Open: "/*", Close: "*/"}
+	return cfg
+}
+
+func TestToggleComment(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		// sel is the selection before the toggle, with the caret at Start.
+		sel     TextRange
+		block   bool
+		want    string
+		wantSel TextRange
+	}{
+		{
+			name:    "aligned at the minimum indentation",
+			input:   "    a\n  b\n      c",
+			sel:     TextRange{Start: 18, End: 0},
+			want:    "  //   a\n  // b\n  //     c",
+			wantSel: TextRange{Start: 26, End: 0},
+		},
+		{
+			name:    "blank lines skipped",
+			input:   "a\n\n  \nb",
+			sel:     TextRange{Start: 7, End: 0},
+			want:    "// a\n\n  \n// b",
+			wantSel: TextRange{Start: 13, End: 0},
+		},
+		{
+			name:    "caret kept on the code",
+			input:   "  abc",
+			sel:     TextRange{Start: 3, End: 3},
+			want:    "  // abc",
+			wantSel: TextRange{Start: 6, End: 6},
+		},
+		{
+			name:    "uncomment without a space",
+			input:   "//x",
+			sel:     TextRange{Start: 3, End: 3},
+			want:    "x",
+			wantSel: TextRange{Start: 1, End: 1},
+		},
+		{
+			name:    "uncomment surrounded by whitespace",
+			input:   "  // a\n  //  x",
+			sel:     TextRange{Start: 0, End: 14},
+			want:    "  a\n   x",
+			wantSel: TextRange{Start: 0, End: 8},
+		},
+		{
+			name:    "partially commented lines are commented",
+			input:   "// a\nb",
+			sel:     TextRange{Start: 6, End: 0},
+			want:    "// // a\n// b",
+			wantSel: TextRange{Start: 12, End: 0},
+		},
+		{
+			name:    "forward selection mapped",
+			input:   "ab\ncd",
+			sel:     TextRange{Start: 4, End: 1},
+			want:    "// ab\n// cd",
+			wantSel: TextRange{Start: 10, End: 4},
+		},
+		{
+			name:    "backward selection mapped",
+			input:   "ab\ncd",
+			sel:     TextRange{Start: 1, End: 4},
+			want:    "// ab\n// cd",
+			wantSel: TextRange{Start: 4, End: 10},
+		},
+		{
+			name:    "block comment",
+			input:   "x = foo()",
+			sel:     TextRange{Start: 9, End: 4},
+			block:   true,
+			want:    "x = /* foo() */",
+			wantSel: TextRange{Start: 12, End: 7},
+		},
+		{
+			name:    "block comment reversed",
+			input:   "x = foo()",
+			sel:     TextRange{Start: 4, End: 9},
+			block:   true,
+			want:    "x = /* foo() */",
+			wantSel: TextRange{Start: 7, End: 12},
+		},
+		{
+			name:    "block uncomment with whitespace inside the selection",
+			input:   "x = /*   foo()  */",
+			sel:     TextRange{Start: 18, End: 4},
+			block:   true,
+			want:    "x = foo()",
+			wantSel: TextRange{Start: 9, End: 4},
+		},
+		{
+			name:    "block uncomment around the selection",
+			input:   "x = /*\tfoo()   */;",
+			sel:     TextRange{Start: 12, End: 7},
+			block:   true,
+			want:    "x = foo();",
+			wantSel: TextRange{Start: 9, End: 4},
+		},
+		{
+			name:    "block comment of the caret line",
+			input:   "  foo()",
+			sel:     TextRange{Start: 4, End: 4},
+			block:   true,
+			want:    "  /* foo() */",
+			wantSel: TextRange{Start: 7, End: 7},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEditor(t, tc.input)
+			e.SetLanguage(commentLanguage())
+			e.SetCaret(tc.sel.Start, tc.sel.End)
+
+			toggle := e.ToggleLineComment
+			if tc.block {
+				toggle = e.ToggleBlockComment
+			}
+			if !toggle() {
+				t.Fatal("the text is not changed")
+			}
+			if got := e.Text(); got != tc.want {
+				t.Fatalf("want text %q, got %q", tc.want, got)
+			}
+			if start, end := e.Selection(); (TextRange{Start: start, End: end}) != tc.wantSel {
+				t.Errorf("want selection %v, got %d, %d", tc.wantSel, start, end)
+			}
+
+			// the toggle is a single undo step.
+			if _, ok := e.undo(); !ok {
+				t.Fatal("nothing to undo")
+			}
+			if got := e.Text(); got != tc.input {
+				t.Errorf("undo: want text %q, got %q", tc.input, got)
+			}
+		})
+	}
+}
+
+func TestToggleCommentRoundTrip(t *testing.T) {
+	input := "func a() {\n\tif b {\n\n\t\t  c()\n\t}\n}"
+	e := newTestEditor(t, input)
+	e.SetLanguage(commentLanguage())
+	e.SetCaret(0, e.Len())
+
+	e.ToggleLineComment()
+	e.ToggleLineComment()
+	if got := e.Text(); got != input {
+		t.Errorf("line comment: want %q, got %q", input, got)
+	}
+
+	e.ToggleBlockComment()
+	e.ToggleBlockComment()
+	if got := e.Text(); got != input {
+		t.Errorf("block comment: want %q, got %q", input, got)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/oligo/gvcode/gutter"
 	"github.com/oligo/gvcode/internal/buffer"
 	gestureExt "github.com/oligo/gvcode/internal/gesture"
+	"github.com/oligo/gvcode/language"
 	"github.com/oligo/gvcode/textview"
 )
 
@@ -29,11 +30,17 @@ import (
 type Editor struct {
 	// mode sets the mode of the editor to work in.
 	mode EditorMode
+	// editingMode is the normal or the overwrite mode, which the editor returns
+	// to from the read-only and snippet modes.
+	editingMode EditorMode
 
 	// text manages the text buffer and provides shaping and cursor positioning
 	// services.
-	text       *textview.TextView
-	buffer     buffer.TextSource
+	text   *textview.TextView
+	buffer buffer.TextSource
+	// carets are the secondary carets for multi-cursor editing.
+	carets     multiCaret
+	column     columnState
 	snippetCtx *snippetContext
 	// colorPalette configures the color scheme used for syntax highlighting.
 	colorPalette *color.ColorPalette
@@ -42,6 +49,28 @@ type Editor struct {
 	gutterGap unit.Dp
 	// gutterManager manages multiple gutter providers (line numbers, breakpoints, etc.)
 	gutterManager *gutter.Manager
+	// rulers are vertical lines drawn behind the text at the configured columns.
+	rulers []textview.Ruler
+	// indentGuides controls whether to paint the indent guides.
+	indentGuides bool
+	// sticky manages the pinned scope header lines.
+	sticky stickyScroll
+	// zoom scales the editor content.
+	zoom zoomState
+	// reveal tracks the range to scroll into view and its flash.
+	reveal revealState
+	// expansion tracks the selections of ExpandSelection.
+	expansion selectionExpansion
+	// smartPaste reindents the pasted lines to the caret context.
+	smartPaste bool
+	// surroundPairs are the extra pairs surrounding the selection, keyed by
+	// the typed text.
+	surroundPairs map[string]language.Pair
+	// changeSurround is set by the change surround command, until the text
+	// opening the new pair is typed.
+	changeSurround bool
+	// hardWrap configures the reflow and the hard wrapping on typing.
+	hardWrap hardWrap
 	// hooks
 	onPaste   BeforePasteHook
 	completor Completion
@@ -69,6 +98,11 @@ type Editor struct {
 	pending     []EditorEvent
 	// commands is a registry of key commands.
 	commands map[key.Name][]keyCommand
+	// lang is the configuration of the language of the editor.
+	lang *language.Config
+	// bracketPairs and quotePairs are set by WithBracketPairs and
+	// WithQuotePairs, and are used when the language has no pairs.
+	bracketPairs, quotePairs map[rune]rune
 	// autoInsertions tracks recently inserted closing brackets or quotes.
 	autoInsertions map[int]rune
 	// gutterWidth can be used to guide to set the horizontal offset when
@@ -78,6 +112,8 @@ type Editor struct {
 	wordHighlighter wordHighlighter
 	// selection highlighting state
 	selectionHighlighter selectionHighlighter
+	// bracketChecker marks the unbalanced brackets.
+	bracketChecker bracketChecker
 }
 
 type imeState struct {
@@ -87,6 +123,11 @@ type imeState struct {
 	}
 	snippet    key.Snippet
 	start, end int
+	// overwritten are the grapheme clusters removed by the inputs of the
+	// composition in the overwrite mode, and overwriteAt is the offset after
+	// the composed text, where they were.
+	overwritten []string
+	overwriteAt int
 }
 
 type EditorEvent interface {
@@ -129,6 +170,7 @@ func (e *Editor) initBuffer() {
 	e.text.CaretWidth = unit.Dp(1)
 	e.wordHighlighter.editor = e
 	e.selectionHighlighter.editor = e
+	e.bracketChecker.editor = e
 }
 
 // Update the state of the editor in response to input events. Update consumes editor
@@ -168,6 +210,9 @@ func (e *Editor) Layout(gtx layout.Context, lt *text.Shaper) layout.Dimensions {
 		}
 	}
 
+	// Lay out the editor with the metric scaled by the zoom level.
+	gtx.Metric = e.zoom.scale(gtx.Metric)
+
 	// Adjust scrolling for new viewport and layout.
 	e.text.ScrollRel(0, 0)
 
@@ -175,6 +220,8 @@ func (e *Editor) Layout(gtx layout.Context, lt *text.Shaper) layout.Dimensions {
 		e.scrollCaret = false
 		e.text.ScrollToCaret()
 	}
+	e.applyReveal(gtx)
+	e.text.TickScroll(gtx)
 
 	defer clip.Rect(image.Rectangle{Max: gtx.Constraints.Max}).Push(gtx.Ops).Pop()
 	e.scroller.Add(gtx.Ops)
@@ -216,6 +263,7 @@ func (e *Editor) Layout(gtx layout.Context, lt *text.Shaper) layout.Dimensions {
 		}),
 		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 			e.text.Layout(gtx, lt)
+			e.restoreZoomAnchor()
 			dims := e.layout(gtx)
 			if e.completor != nil {
 				e.text.PaintOverlay(gtx, e.completor.Offset(), e.completor.Layout)
@@ -265,6 +313,8 @@ func (e *Editor) layout(gtx layout.Context) layout.Dimensions {
 		selectColor = textColor.MulAlpha(0x60)
 	}
 
+	e.paintRulers(gtx, textColor)
+	e.paintIndentGuides(gtx, textColor)
 	if e.Len() > 0 {
 		e.paintSelection(gtx, selectColor)
 		e.text.HighlightMatchingBrackets(gtx, selectColor.Op(gtx.Ops))
@@ -274,12 +324,16 @@ func (e *Editor) layout(gtx layout.Context) layout.Dimensions {
 		if e.selectionHighlighter.IsDirty() {
 			e.selectionHighlighter.HighlightSelection(e.colorPalette.SelectColor)
 		}
+		if e.bracketChecker.Due(gtx) {
+			e.bracketChecker.Check(e.colorPalette.UnbalancedBracketColor)
+		}
 
 		e.paintText(gtx, textColor)
 	}
 	if gtx.Enabled() {
 		e.paintCaret(gtx, textColor)
 	}
+	e.paintStickyLines(gtx, textColor)
 	return layout.Dimensions{Size: gtx.Constraints.Max}
 }
 
@@ -289,18 +343,74 @@ func (e *Editor) PaintOverlay(gtx layout.Context, position image.Point, w layout
 	e.text.PaintOverlay(gtx, offset, w)
 }
 
+// paintRulers paints the vertical rulers behind the text. The ruler color from
+// the color palette is used for rulers with no color set.
+func (e *Editor) paintRulers(gtx layout.Context, textColor color.Color) {
+	if len(e.rulers) == 0 {
+		return
+	}
+
+	rulerColor := e.colorPalette.RulerColor
+	if !rulerColor.IsSet() {
+		rulerColor = textColor.MulAlpha(0x30)
+	}
+	e.text.PaintRulers(gtx, e.rulers, rulerColor)
+}
+
+// paintIndentGuides paints the indent guides behind the text and selection.
+func (e *Editor) paintIndentGuides(gtx layout.Context, textColor color.Color) {
+	if !e.indentGuides {
+		return
+	}
+
+	guideColor := e.colorPalette.IndentGuideColor
+	if !guideColor.IsSet() {
+		guideColor = textColor.MulAlpha(0x30)
+	}
+	activeColor := e.colorPalette.ActiveIndentGuideColor
+	if !activeColor.IsSet() {
+		activeColor = textColor.MulAlpha(0x90)
+	}
+	// the guides are skipped under the selections of the secondary carets too.
+	var selections [][2]int
+	for _, c := range e.carets.carets {
+		if start, end := c.selection(); start != end {
+			selections = append(selections, [2]int{start, end})
+		}
+	}
+	e.text.PaintIndentGuidesSkipping(gtx, guideColor.Op(gtx.Ops), activeColor.Op(gtx.Ops), selections)
+}
+
 // paintSelection paints the contrasting background for selected text using the provided
 // material to set the painting material for the selection.
 func (e *Editor) paintSelection(gtx layout.Context, material color.Color) {
 	e.initBuffer()
 	e.text.PaintSelection(gtx, material.Op(gtx.Ops))
+	if e.text.ColumnSelectionActive() {
+		// the column selection covers the selections of its carets.
+		return
+	}
+	for _, c := range e.carets.carets {
+		if start, end := c.selection(); start != end {
+			e.text.PaintSelectionRange(gtx, start, end, material.Op(gtx.Ops))
+		}
+	}
 }
 
 // paintText paints the text glyphs using the provided material to set the fill of the
-// glyphs.
+// glyphs. The whitespace markers are painted in the same pass using the whitespace
+// color from the color palette, or a faded text color if it is not set.
 func (e *Editor) paintText(gtx layout.Context, material color.Color) {
 	e.initBuffer()
-	e.text.PaintText(gtx, material.Op(gtx.Ops))
+	var wsMaterial op.CallOp
+	if e.text.WhitespaceMode != textview.WhitespaceNone {
+		wsColor := e.colorPalette.WhitespaceColor
+		if !wsColor.IsSet() {
+			wsColor = material.MulAlpha(0x50)
+		}
+		wsMaterial = wsColor.Op(gtx.Ops)
+	}
+	e.text.PaintTextWithWhitespaces(gtx, material.Op(gtx.Ops), wsMaterial)
 }
 
 // paintCaret paints the text glyphs using the provided material to set the fill material
@@ -310,7 +420,21 @@ func (e *Editor) paintCaret(gtx layout.Context, material color.Color) {
 	if !e.showCaret || e.mode == ModeReadOnly {
 		return
 	}
-	e.text.PaintCaret(gtx, material.Op(gtx.Ops))
+	if e.text.ColumnSelectionActive() {
+		e.text.PaintColumnCarets(gtx, material.Op(gtx.Ops))
+		return
+	}
+	paintAt := e.text.PaintCaretAt
+	if e.mode == ModeOverwrite {
+		// the block caret is translucent to keep the glyph under it visible.
+		paintAt = e.text.PaintBlockCaretAt
+		material = material.MulAlpha(0x80)
+	}
+	start, _ := e.text.Selection()
+	paintAt(gtx, start, material.Op(gtx.Ops))
+	for _, c := range e.carets.carets {
+		paintAt(gtx, c.caret.Offset(), material.Op(gtx.Ops))
+	}
 }
 
 // Len is the length of the editor contents, in runes.
@@ -344,7 +468,11 @@ func (e *Editor) SetText(s string) {
 	e.text.SoftTab = indent == Spaces
 	e.text.TabWidth = size
 
+	// markers are reset with the buffer.
+	e.carets.carets = e.carets.carets[:0]
+	e.clearColumnSelection()
 	e.text.SetText(s)
+	e.bracketChecker.MarkDirty()
 	e.ime.start = 0
 	e.ime.end = 0
 	// Reset xoff and move the caret to the beginning.
@@ -379,6 +507,28 @@ func (e *Editor) Lines() int {
 	return e.text.Paragraphs()
 }
 
+// LineRange returns the rune range of the logical line, including the trailing
+// line break if there is one.
+func (e *Editor) LineRange(line int) (start, end int) {
+	e.initBuffer()
+	return e.text.RangeOfLines(line, 1, false)
+}
+
+// ReadRange reads the text between the rune offsets start and end into buf,
+// which is grown if it is too small, and returns the text read.
+func (e *Editor) ReadRange(buf []byte, start, end int) []byte {
+	e.initBuffer()
+	startOff := e.text.ByteOffset(min(start, end))
+	endOff := e.text.ByteOffset(max(start, end))
+	size := int(endOff - startOff)
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	n, _ := e.buffer.ReadAt(buf, startOff)
+	return buf[:n]
+}
+
 // ReadUntil reads in the specified direction from the current caret position until the
 // seperator returns false. It returns the read text.
 func (e *Editor) ReadUntil(direction int, seperator func(r rune) bool) string {
@@ -495,6 +645,14 @@ func (e *Editor) undo() (EditorEvent, bool) {
 	if !ok {
 		return nil, false
 	}
+	// the markers of the carets are not restored by undo and redo, but the
+	// carets of the edits made at several carets are recorded with them.
+	e.clearColumnSelection()
+	e.carets.clear(e.buffer)
+	if carets, ok := e.buffer.RestoredCarets(); ok {
+		e.restoreCarets(carets)
+		return ChangeEvent{}, true
+	}
 
 	var start, end int
 	for _, pos := range positions {
@@ -514,6 +672,14 @@ func (e *Editor) redo() (EditorEvent, bool) {
 	if !ok {
 		return nil, false
 	}
+	// the markers of the carets are not restored by undo and redo, but the
+	// carets of the edits made at several carets are recorded with them.
+	e.clearColumnSelection()
+	e.carets.clear(e.buffer)
+	if carets, ok := e.buffer.RestoredCarets(); ok {
+		e.restoreCarets(carets)
+		return ChangeEvent{}, true
+	}
 
 	var start, end int
 	for _, pos := range positions {
@@ -550,12 +716,22 @@ func (e *Editor) replace(start, end int, s string) int {
 	e.ime.start = adjust(e.ime.start)
 	e.ime.end = adjust(e.ime.end)
 	e.text.UpdateSyntaxTokensOffset(start, end, newEnd)
+	e.bracketChecker.MarkDirty()
 	return sc
 }
 
 // ReplaceAll replaces all texts specifed in TextRange with newStr.
 // It returns the number of occurrences replaced.
 func (e *Editor) ReplaceAll(texts []TextRange, newStr string) int {
+	return e.ReplaceAllFunc(texts, func(int) string { return newStr })
+}
+
+// ReplaceAllFunc replaces all texts specifed in TextRange with the text returned
+// by repl for the index of the range. The ranges must be sorted and must not
+// overlap. The replacements are a single undo step. It returns the number of
+// occurrences replaced.
+func (e *Editor) ReplaceAllFunc(texts []TextRange, repl func(idx int) string) int {
+	e.initBuffer()
 	if len(texts) <= 0 {
 		return 0
 	}
@@ -566,7 +742,7 @@ func (e *Editor) ReplaceAll(texts []TextRange, newStr string) int {
 	finalPos := 0
 	for idx := len(texts) - 1; idx >= 0; idx-- {
 		start, end := texts[idx].Start, texts[idx].End
-		e.replace(start, end, newStr)
+		e.replace(start, end, repl(idx))
 		finalPos = start
 	}
 	e.buffer.UnGroupOp()
@@ -575,6 +751,13 @@ func (e *Editor) ReplaceAll(texts []TextRange, newStr string) int {
 	return len(texts)
 }
 
+// NewSearcher returns a searcher of the query in the editor text. The searcher
+// is kept up to date as the text is edited, until it is closed.
+func (e *Editor) NewSearcher(query string, opts textview.SearchOptions) (*textview.Searcher, error) {
+	e.initBuffer()
+	return e.text.NewSearcher(query, opts)
+}
+
 // MoveCaret moves the caret (aka selection start) and the selection end
 // relative to their current positions. Positive distances moves forward,
 // negative distances moves backward. Distances are in grapheme clusters,
@@ -725,6 +908,51 @@ func (e *Editor) Scroll(gtx layout.Context, xRatio, yRatio float32) {
 	e.text.ScrollRel(int(float32(textDims.X)*xRatio), int(float32(textDims.Y)*yRatio))
 }
 
+// VisibleLines returns the top and the bottom of the viewport in logical lines.
+// The integer part is the logical line, and the fraction is the position in the
+// screen lines it is wrapped into, so the values do not depend on line wrapping.
+func (e *Editor) VisibleLines() (top, bottom float32) {
+	e.initBuffer()
+	return e.lineAtY(0), e.lineAtY(e.text.Dimensions().Size.Y)
+}
+
+// LineRatio returns the vertical position of the logical line relative to the
+// document height, in the ratio used by ScrollRatio and Scroll. The fraction of
+// line is the position in the screen lines it is wrapped into.
+func (e *Editor) LineRatio(line float32) float32 {
+	e.initBuffer()
+	height := e.text.FullDimensions().Size.Y
+	if height <= 0 {
+		return 0
+	}
+	idx := max(0, min(int(line), e.text.Paragraphs()-1))
+	top, bottom := e.lineBounds(idx)
+	frac := max(0, min(1, line-float32(idx)))
+	y := e.text.ScrollOff().Y + top + int(float32(bottom-top)*frac)
+	return float32(y) / float32(height)
+}
+
+// lineBounds returns the top and the bottom y coordinate of the logical line
+// relative to the viewport.
+func (e *Editor) lineBounds(line int) (top, bottom int) {
+	top = e.text.LineTop(line)
+	if line+1 < e.text.Paragraphs() {
+		return top, e.text.LineTop(line + 1)
+	}
+	return top, e.text.FullDimensions().Size.Y - e.text.ScrollOff().Y
+}
+
+// lineAtY returns the logical line at the y coordinate relative to the viewport,
+// with the fraction of its height above y.
+func (e *Editor) lineAtY(y int) float32 {
+	line := e.text.LineAt(y)
+	top, bottom := e.lineBounds(line)
+	if bottom <= top {
+		return float32(line)
+	}
+	return float32(line) + max(0, min(1, float32(y-top)/float32(bottom-top)))
+}
+
 // GutterWidth returns the width of the gutter in pixel, which can be used to
 // guide to set the horizontal offset when laying out a horizontal scrollbar.
 func (e *Editor) GutterWidth() int {
@@ -0,0 +1,120 @@
+package gvcode
+
+import (
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"gioui.org/io/input"
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+// newTestEditor returns an editor holding input, laid out in a 500x500 viewport.
+func newTestEditor(t *testing.T, input string, opts ...EditorOption) *Editor {
+	t.Helper()
+	e := &Editor{}
+	e.WithOptions(append([]EditorOption{WithTextSize(14), WithLineHeight(0, 1.2), WithTabWidth(4)}, opts...)...)
+	e.SetText(input)
+	gtx := layout.Context{
+		Ops:         new(op.Ops),
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(500, 500)),
+	}
+	e.text.Layout(gtx, text.NewShaper())
+	return e
+}
+
+// typeText types s at the caret one rune at a time, like the key presses of a
+// user.
+func typeText(e *Editor, s string) {
+	for _, r := range s {
+		start, end := e.Selection()
+		e.onCaretsTextInput(key.EditEvent{Range: key.Range{Start: start, End: end}, Text: string(r)})
+	}
+}
+
+// testWindow delivers input events to an editor through a router, laying it out
+// in a 500x500 viewport on each frame.
+type testWindow struct {
+	router input.Router
+	ops    op.Ops
+	shaper *text.Shaper
+	editor *Editor
+	events []EditorEvent
+}
+
+func newTestWindow(t *testing.T, input string, opts ...EditorOption) *testWindow {
+	t.Helper()
+	opts = append([]EditorOption{WithColorScheme(syntax.ColorScheme{})}, opts...)
+	w := &testWindow{shaper: text.NewShaper(), editor: newTestEditor(t, input, opts...)}
+	w.frame()
+	w.router.Source().Execute(key.FocusCmd{Tag: w.editor})
+	w.frame()
+	return w
+}
+
+// frame lays out the editor, collecting the events it generates.
+func (w *testWindow) frame() {
+	w.ops.Reset()
+	gtx := layout.Context{
+		Ops:         &w.ops,
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(500, 500)),
+		Source:      w.router.Source(),
+	}
+	for {
+		evt, ok := w.editor.Update(gtx)
+		if !ok {
+			break
+		}
+		w.events = append(w.events, evt)
+	}
+	w.editor.Layout(gtx, w.shaper)
+	w.router.Frame(&w.ops)
+}
+
+// press delivers a key press to the editor.
+func (w *testWindow) press(name key.Name, mods key.Modifiers) {
+	w.router.Queue(key.Event{Name: name, Modifiers: mods, State: key.Press})
+	w.frame()
+}
+
+func TestVisibleLinesWrapped(t *testing.T) {
+	// the first line is wrapped into many screen lines.
+	input := strings.Repeat("word ", 300) + strings.Repeat("\nline", 100)
+	w := newTestWindow(t, input, WrapLine(true))
+	e := w.editor
+
+	if top, _ := e.VisibleLines(); top != 0 {
+		t.Fatalf("want the top at line 0, got %v", top)
+	}
+
+	scrollToLine := func(line float32) {
+		_, _, minY, _ := e.ScrollRatio()
+		e.Scroll(layout.Context{}, 0, e.LineRatio(line)-minY)
+	}
+	for _, line := range []float32{0.5, 1, 10.25} {
+		scrollToLine(line)
+		top, bottom := e.VisibleLines()
+		if math.Abs(float64(top-line)) > 0.05 {
+			t.Errorf("scroll to %v: want the top at the line, got %v", line, top)
+		}
+		if bottom <= top {
+			t.Errorf("scroll to %v: want the bottom below the top, got %v, %v", line, top, bottom)
+		}
+	}
+
+	// below the wrapped line, the viewport spans a logical line per screen line.
+	scrollToLine(10)
+	top, bottom := e.VisibleLines()
+	lineHeight := float32(e.text.GetLineHeight().Round())
+	if want := float32(e.text.Dimensions().Size.Y) / lineHeight; math.Abs(float64(bottom-top-want)) > 1 {
+		t.Errorf("want the viewport to span %v lines, got %v", want, bottom-top)
+	}
+}
@@ -40,6 +40,12 @@ func (e *Editor) processEvents(gtx layout.Context) (ev EditorEvent, ok bool) {
 			}
 		}
 
+		// deliver the events queued while processing, like ModeChangedEvent.
+		if !ok && len(e.pending) > 0 {
+			ev, ok = e.pending[0], true
+			e.pending = e.pending[:copy(e.pending, e.pending[1:])]
+		}
+
 		switch ev.(type) {
 		case ChangeEvent:
 			e.wordHighlighter.MarkActive(false)
@@ -69,10 +75,21 @@ func (e *Editor) processPointer(gtx layout.Context) (EditorEvent, bool) {
 	scrollOffY := e.text.ScrollOff().Y
 	scrollY.Min = -scrollOffY
 	scrollY.Max = max(0, textDims.Size.Y-(scrollOffY+visibleDims.Size.Y))
+	// Keep a pixel of vertical range at the boundaries while the shortcut
+	// modifier is held, so that Ctrl+wheel scrolling is still delivered for
+	// zooming. Otherwise the wheel scrolling past the boundaries is left to the
+	// scrollable parents.
+	if e.updateZoomModifier(gtx) {
+		scrollY.Min = min(scrollY.Min, -1)
+		scrollY.Max = max(scrollY.Max, 1)
+	}
 	sbounds := e.text.ScrollBounds()
 
 	var soff, smin, smax int
 	sdist := e.scroller.Update(gtx.Metric, gtx.Source, gtx.Now, scrollX, scrollY)
+	if mods, ok := e.scroller.Modifiers(); ok {
+		e.zoom.modifierHeld = mods.Contain(key.ModShortcut)
+	}
 	if e.scroller.Direction() == gestureExt.Horizontal {
 		e.text.ScrollRel(sdist, 0)
 		soff = e.text.ScrollOff().X
@@ -83,6 +100,10 @@ func (e *Editor) processPointer(gtx layout.Context) (EditorEvent, bool) {
 		smin, smax = sbounds.Min.Y, sbounds.Max.Y
 	}
 
+	if ev, ok := e.processZoom(); ok {
+		return ev, ok
+	}
+
 	for {
 		evt, ok := e.clicker.Update(gtx.Source)
 		if !ok {
@@ -131,12 +152,37 @@ func (e *Editor) processPointerEvent(gtx layout.Context, ev event.Event) (Editor
 		switch {
 		case evt.Kind == gesture.KindPress && evt.Source == pointer.Mouse,
 			evt.Kind == gesture.KindClick && evt.Source != pointer.Mouse:
-			prevCaretPos, _ := e.text.Selection()
-			e.blinkStart = gtx.Now
-			e.text.MoveCoord(image.Point{
+			if evt.Modifiers == 0 && e.jumpToStickyLine(int(evt.Position.Y)) {
+				e.blinkStart = gtx.Now
+				gtx.Execute(key.FocusCmd{Tag: e})
+				e.dragging = false
+				break
+			}
+
+			pos := image.Point{
 				X: int(math.Round(float64(evt.Position.X))),
 				Y: int(math.Round(float64(evt.Position.Y))),
-			})
+			}
+			if evt.Modifiers == key.ModAlt|key.ModShift && evt.Source == pointer.Mouse {
+				// start a column selection, extended by dragging.
+				e.blinkStart = gtx.Now
+				e.startColumnSelectionAt(pos)
+				gtx.Execute(key.FocusCmd{Tag: e})
+				e.dragging = false
+				break
+			}
+			e.clearColumnSelection()
+
+			prevCaretPos, prevEnd := e.text.Selection()
+			if evt.Modifiers == key.ModAlt {
+				// keep the current caret, and add a new primary caret.
+				e.carets.add(e.buffer, prevCaretPos, prevEnd)
+			} else if evt.Modifiers != key.ModShift {
+				e.carets.clear(e.buffer)
+			}
+
+			e.blinkStart = gtx.Now
+			e.text.MoveCoord(pos)
 			gtx.Execute(key.FocusCmd{Tag: e})
 			if e.mode != ModeReadOnly {
 				gtx.Execute(key.SoftKeyboardCmd{Show: true})
@@ -169,12 +215,17 @@ func (e *Editor) processPointerEvent(gtx layout.Context, ev event.Event) (Editor
 				e.dragging = false
 			}
 
+			if e.carets.active() {
+				start, end := e.text.Selection()
+				e.carets.normalize(e.buffer, start, end)
+			}
+
 			if e.completor != nil {
 				e.completor.Cancel()
 			}
-			// switch to normal mode when clicked.
+			// leave the snippet mode when clicked.
 			if e.mode == ModeSnippet {
-				e.setMode(ModeNormal)
+				e.restoreEditingMode()
 			}
 		}
 	case pointer.Event:
@@ -184,7 +235,15 @@ func (e *Editor) processPointerEvent(gtx layout.Context, ev event.Event) (Editor
 			release = true
 			fallthrough
 		case evt.Kind == pointer.Drag && evt.Source == pointer.Mouse:
-			if e.dragging {
+			if e.column.dragging {
+				e.blinkStart = gtx.Now
+				e.extendColumnSelectionTo(image.Point{
+					X: int(math.Round(float64(evt.Position.X))),
+					Y: int(math.Round(float64(evt.Position.Y))),
+				})
+				e.scrollCaret = true
+				e.column.dragging = !release
+			} else if e.dragging {
 				e.blinkStart = gtx.Now
 				e.text.MoveCoord(image.Point{
 					X: int(math.Round(float64(evt.Position.X))),
@@ -245,7 +304,7 @@ func (e *Editor) processEditEvents(gtx layout.Context) EditorEvent {
 		case key.SnippetEvent:
 			e.updateSnippet(gtx, ke.Start, ke.End)
 		case key.EditEvent:
-			e.onTextInput(ke)
+			e.onCaretsTextInput(ke)
 		case key.SelectionEvent:
 			e.scrollCaret = true
 			e.scroller.Stop()
@@ -310,29 +369,50 @@ func (e *Editor) updateSnippet(gtx layout.Context, start, end int) {
 }
 
 func (e *Editor) onCopyCut(gtx layout.Context, k key.Event) EditorEvent {
-	lineOp := false
-	if e.text.SelectionLen() == 0 {
-		lineOp = true
-		e.scratch, _, _ = e.text.SelectedLineText(e.scratch)
-		if len(e.scratch) > 0 && e.scratch[len(e.scratch)-1] != '\n' {
-			e.scratch = append(e.scratch, '\n')
+	if e.text.ColumnSelectionActive() {
+		return e.onColumnCopyCut(gtx, k)
+	}
+	setColumnClipboard("")
+
+	// lineOps records, for each caret, whether its whole line is copied.
+	var lineOps []bool
+	var text strings.Builder
+	e.forEachCaret(func(int) EditorEvent {
+		lineOp := false
+		if e.text.SelectionLen() == 0 {
+			lineOp = true
+			e.scratch, _, _ = e.text.SelectedLineText(e.scratch)
+			if len(e.scratch) > 0 && e.scratch[len(e.scratch)-1] != '\n' {
+				e.scratch = append(e.scratch, '\n')
+			}
+		} else {
+			e.scratch = e.text.SelectedText(e.scratch)
 		}
-	} else {
-		e.scratch = e.text.SelectedText(e.scratch)
-	}
 
-	if text := string(e.scratch); text != "" {
+		// the text of multiple carets is separated by line breaks.
+		if s := text.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			text.WriteByte('\n')
+		}
+		text.Write(e.scratch)
+		lineOps = append(lineOps, lineOp)
+		return nil
+	})
+
+	if text := text.String(); text != "" {
 		gtx.Execute(clipboard.WriteCmd{Type: "application/text", Data: io.NopCloser(strings.NewReader(text))})
 		if k.Name == "X" && e.mode != ModeReadOnly {
-			if !lineOp {
-				if e.Delete(1) != 0 {
-					return ChangeEvent{}
-				}
-			} else {
-				if e.DeleteLine() != 0 {
-					return ChangeEvent{}
+			return e.forEachCaret(func(idx int) EditorEvent {
+				if !lineOps[idx] {
+					if e.Delete(1) != 0 {
+						return ChangeEvent{}
+					}
+				} else {
+					if e.DeleteLine() != 0 {
+						return ChangeEvent{}
+					}
 				}
-			}
+				return nil
+			})
 		}
 	}
 
@@ -378,13 +458,39 @@ func (e *Editor) onTextInput(ke key.EditEvent) {
 		e.autoInsertions = make(map[int]rune)
 	}
 
+	if e.changeSurround {
+		// the typed text opens the pair replacing the one around the selection.
+		e.changeSurround = false
+		if pair, ok := e.surroundingPair(ke.Text); ok {
+			e.ChangeSurround(pair)
+		}
+		return
+	}
+
+	if e.surroundSelection(ke) {
+		e.scrollCaret = true
+		e.scroller.Stop()
+		e.lastInput = &ke
+		return
+	}
+
+	// In the overwrite mode, the grapheme clusters after the caret are removed
+	// before the typed text is inserted, as a single undo step.
+	overwritten := false
+	if e.mode == ModeOverwrite {
+		e.buffer.GroupOp()
+		defer e.buffer.UnGroupOp()
+		overwritten = e.overwriteInput(ke)
+	}
+
 	// check if the input character is a bracket or a quote.
 	r := []rune(ke.Text)[0]
 	counterpart, isOpening := e.text.BracketsQuotes.GetCounterpart(r)
 
 	if counterpart > 0 && isOpening {
-		// Assume we will auto-insert by default.
-		shouldAutoInsert := true
+		// Assume we will auto-insert by default, unless the text is
+		// overwritten.
+		shouldAutoInsert := !overwritten
 
 		if counterpart != r {
 			// only check the next char.
@@ -398,6 +504,10 @@ func (e *Editor) onTextInput(ke key.EditEvent) {
 			}
 		}
 
+		if shouldAutoInsert {
+			shouldAutoInsert = e.canAutoClose(ke.Range.Start, r)
+		}
+
 		replaced := ke.Text
 		if shouldAutoInsert {
 			replaced += string(counterpart)
@@ -424,11 +534,11 @@ func (e *Editor) onTextInput(ke key.EditEvent) {
 			e.text.MoveCaret(1, 1)
 			delete(e.autoInsertions, ke.Range.Start)
 		} else {
-			e.replace(ke.Range.Start, ke.Range.End, ke.Text)
+			e.replaceAndIndent(ke.Range.Start, ke.Range.End, ke.Text)
 		}
 	} else {
 		delete(e.autoInsertions, ke.Range.Start)
-		e.replace(ke.Range.Start, ke.Range.End, ke.Text)
+		e.replaceAndIndent(ke.Range.Start, ke.Range.End, ke.Text)
 	}
 
 	e.scrollCaret = true
@@ -445,6 +555,20 @@ func (e *Editor) onTextInput(ke key.EditEvent) {
 
 }
 
+// replaceAndIndent replaces the range with the typed text s, and re-indents the
+// line if the text decides a new indentation for it. The line is then broken if
+// it goes past the hard wrap column. The edits are a single undo step.
+func (e *Editor) replaceAndIndent(start, end int, s string) {
+	e.buffer.GroupOp()
+	defer e.buffer.UnGroupOp()
+
+	e.replace(start, end, s)
+	if lineStart, lineEnd, indent, ok := e.text.IndentOnType(s); ok {
+		e.replace(lineStart, lineEnd, indent)
+	}
+	e.hardWrapOnType(s)
+}
+
 func (e *Editor) isNearWordChar(runeOff int, backward bool) bool {
 	pos := runeOff
 	if backward {
@@ -523,9 +647,18 @@ func (e *Editor) onPasteEvent(ke transfer.DataEvent) EditorEvent {
 		text = e.onPaste(text)
 	}
 
+	if e.carets.active() || e.text.ColumnSelectionActive() {
+		return e.pasteAtCarets(text)
+	}
+	if isColumnBlock(text) {
+		return e.pasteColumnBlock(text)
+	}
+
 	runes := 0
 	if isSingleLine(text) {
 		runes = e.InsertLine(text)
+	} else if e.smartPaste && strings.Contains(text, "\n") {
+		runes = e.pasteReindented(text)
 	} else {
 		runes = e.Insert(text)
 	}
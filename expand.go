@@ -0,0 +1,210 @@
+package gvcode
+
+import (
+	"strings"
+
+	"gioui.org/io/key"
+)
+
+// expandSelectionChord are the modifiers expanding and shrinking the selection
+// with the right and left arrows: Alt+Shift, or Ctrl+Cmd+Shift on macOS, where
+// Option+Shift+Left and Option+Shift+Right select by word.
+var expandSelectionChord = func() key.Modifiers {
+	if key.ModShortcut == key.ModCommand {
+		return key.ModCtrl | key.ModCommand | key.ModShift
+	}
+	return key.ModAlt | key.ModShift
+}()
+
+// SelectionRangeProvider returns the ranges enclosing the rune range [start,
+// end), like the ranges computed from a syntax tree or returned by the LSP
+// selectionRange request. The ranges are not required to be sorted, and the
+// ones not containing [start, end) are ignored.
+type SelectionRangeProvider func(start, end int) []TextRange
+
+// WithSelectionRangeProvider sets an application supplied provider of the
+// ranges used to expand the selection, in place of the built-in ranges of words,
+// strings, brackets, lines and indented blocks.
+func WithSelectionRangeProvider(provider SelectionRangeProvider) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.expansion.provider = provider
+	}
+}
+
+// selectionExpansion tracks the selections expanded by ExpandSelection, so
+// that ShrinkSelection can restore them.
+type selectionExpansion struct {
+	provider SelectionRangeProvider
+	// stack holds the selections before each expansion.
+	stack []TextRange
+	// last is the selection after the last expansion. The stack is dropped if
+	// the selection is changed otherwise.
+	last TextRange
+}
+
+// ExpandSelection grows the selection to the smallest syntactic unit
+// containing it, through the word, the quoted string, the bracket contents, the
+// brackets including the delimiters, the lines, the indented block and the
+// whole document. It reports whether the selection is changed.
+func (e *Editor) ExpandSelection() bool {
+	e.initBuffer()
+	start, end := e.text.Selection()
+	lo, hi := min(start, end), max(start, end)
+	if e.expansion.last != (TextRange{Start: start, End: end}) {
+		e.expansion.stack = e.expansion.stack[:0]
+	}
+
+	var ranges []TextRange
+	if e.expansion.provider != nil {
+		ranges = e.expansion.provider(lo, hi)
+	}
+	if len(ranges) == 0 {
+		ranges = e.selectionRanges(lo, hi)
+	}
+	ranges = append(ranges, TextRange{Start: 0, End: e.text.Len()})
+
+	// find the smallest range containing the selection.
+	next := TextRange{Start: -1}
+	for _, r := range ranges {
+		if r.Start > lo || r.End < hi || r.End-r.Start <= hi-lo {
+			continue
+		}
+		if next.Start < 0 || r.End-r.Start < next.End-next.Start {
+			next = r
+		}
+	}
+	if next.Start < 0 {
+		return false
+	}
+
+	e.expansion.stack = append(e.expansion.stack, TextRange{Start: start, End: end})
+	// the caret is placed at the end of the range.
+	e.SetCaret(next.End, next.Start)
+	e.expansion.last = TextRange{Start: next.End, End: next.Start}
+	return true
+}
+
+// ShrinkSelection restores the selection before the last ExpandSelection. It
+// reports whether the selection is changed.
+func (e *Editor) ShrinkSelection() bool {
+	e.initBuffer()
+	start, end := e.text.Selection()
+	stack := e.expansion.stack
+	if e.expansion.last != (TextRange{Start: start, End: end}) || len(stack) == 0 {
+		e.expansion.stack = stack[:0]
+		return false
+	}
+
+	prev := stack[len(stack)-1]
+	e.expansion.stack = stack[:len(stack)-1]
+	e.SetCaret(prev.Start, prev.End)
+	e.expansion.last = prev
+	return true
+}
+
+// selectionRanges returns the built-in syntactic ranges around the rune range
+// [start, end).
+func (e *Editor) selectionRanges(start, end int) []TextRange {
+	var ranges []TextRange
+	add := func(start, end int) {
+		if start >= 0 && end >= start {
+			ranges = append(ranges, TextRange{Start: start, End: end})
+		}
+	}
+
+	// the word.
+	add(e.text.WordBoundariesAt(start, false))
+
+	// the quoted string, with and without the quotes.
+	if left, right := e.enclosingQuotes(start); left >= 0 {
+		add(left+1, right)
+		add(left, right+1)
+	}
+
+	// the brackets, with and without the delimiters.
+	left, right := start, end
+	for {
+		left, right = e.text.EnclosingBrackets(left, right)
+		if left < 0 {
+			break
+		}
+		add(left+1, right)
+		add(left, right+1)
+		right++
+	}
+
+	// the lines, without and with the line break.
+	firstLine, _ := e.text.FindParagraph(start)
+	lastLine, _ := e.text.FindParagraph(max(start, end-1))
+	linesStart, linesEnd := e.linesRange(firstLine, lastLine)
+	add(linesStart, linesEnd)
+	add(e.text.RangeOfLines(firstLine, lastLine-firstLine+1, false))
+
+	// the indented blocks, without and with the line break.
+	level := -1
+	for i := firstLine; i <= lastLine; i++ {
+		if line := e.lineText(i); strings.TrimSpace(line) != "" {
+			l := indentWidth(line, e.text.TabWidth)
+			if level < 0 || l < level {
+				level = l
+			}
+		}
+	}
+	addLines := func(first, last int) {
+		add(e.linesRange(first, last))
+		add(e.text.RangeOfLines(first, last-first+1, false))
+	}
+	first, last := firstLine, lastLine
+	for level > 0 {
+		first, last = e.indentedBlock(first, last, level)
+		addLines(first, last)
+		if first == 0 {
+			break
+		}
+		// the block with its header line, which starts the outer block.
+		first--
+		addLines(first, last)
+		level = indentWidth(e.lineText(first), e.text.TabWidth)
+	}
+
+	return ranges
+}
+
+// linesRange returns the rune range of the lines from first to last, without
+// the line break of the last line.
+func (e *Editor) linesRange(first, last int) (start, end int) {
+	start, _ = e.text.RangeOfLines(first, 1, false)
+	_, end = e.text.RangeOfLines(last, 1, false)
+	if r, err := e.text.ReadRuneAt(end - 1); end > start && err == nil && r == '\n' {
+		end--
+	}
+	return start, end
+}
+
+// indentedBlock extends the lines from first to last over the surrounding
+// lines indented by at least level columns, and the blank lines among them.
+// The blank lines around the block are excluded.
+func (e *Editor) indentedBlock(first, last, level int) (int, int) {
+	blank := func(i int) bool {
+		return strings.TrimSpace(e.lineText(i)) == ""
+	}
+	inBlock := func(i int) bool {
+		return blank(i) || indentWidth(e.lineText(i), e.text.TabWidth) >= level
+	}
+
+	start, end := first, last
+	for start > 0 && inBlock(start-1) {
+		start--
+	}
+	for end+1 < e.text.Paragraphs() && inBlock(end+1) {
+		end++
+	}
+	for start < first && blank(start) {
+		start++
+	}
+	for end > last && blank(end) {
+		end--
+	}
+	return start, end
+}
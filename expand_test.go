@@ -0,0 +1,133 @@
+package gvcode
+
+import (
+	"strings"
+	"testing"
+
+	"gioui.org/io/key"
+)
+
+func TestExpandSelection(t *testing.T) {
+	input := "def a():\n    x = 1\n    call(1, \"foo bar\")\n    y = 2\nz = 3"
+	want := []string{
+		// the word.
+		"foo",
+		// the quoted string, without and with the quotes.
+		"foo bar",
+		"\"foo bar\"",
+		// the bracket contents, and the brackets.
+		"1, \"foo bar\"",
+		"(1, \"foo bar\")",
+		// the line, without and with the line break.
+		"    call(1, \"foo bar\")",
+		"    call(1, \"foo bar\")\n",
+		// the indented block, without and with the line break, and the block with
+		// its header line.
+		"    x = 1\n    call(1, \"foo bar\")\n    y = 2",
+		"    x = 1\n    call(1, \"foo bar\")\n    y = 2\n",
+		"def a():\n    x = 1\n    call(1, \"foo bar\")\n    y = 2\n",
+		// the document.
+		input,
+	}
+
+	e := newTestEditor(t, input)
+	e.text.SoftTab, e.text.TabWidth = true, 4
+	caret := strings.Index(input, "foo") + 1
+	e.SetCaret(caret, caret)
+
+	for i, text := range want {
+		if !e.ExpandSelection() {
+			t.Fatalf("level %d: the selection is not expanded", i)
+		}
+		if got := e.SelectedText(); got != text {
+			t.Fatalf("level %d: want %q, got %q", i, text, got)
+		}
+	}
+	if e.ExpandSelection() {
+		t.Error("the document should not be expanded")
+	}
+
+	// shrink back through the levels to the caret.
+	for i := len(want) - 2; i >= 0; i-- {
+		if !e.ShrinkSelection() {
+			t.Fatalf("level %d: the selection is not shrunk", i)
+		}
+		if got := e.SelectedText(); got != want[i] {
+			t.Fatalf("level %d: want %q, got %q", i, want[i], got)
+		}
+	}
+	if !e.ShrinkSelection() {
+		t.Fatal("the caret is not restored")
+	}
+	if start, end := e.Selection(); start != caret || end != caret {
+		t.Errorf("want the caret at %d, got %d, %d", caret, start, end)
+	}
+	if e.ShrinkSelection() {
+		t.Error("the caret should not be shrunk")
+	}
+}
+
+func TestExpandSelectionReset(t *testing.T) {
+	e := newTestEditor(t, "foo bar")
+	e.SetCaret(1, 1)
+	e.ExpandSelection()
+
+	// a selection changed otherwise drops the expansions.
+	e.SetCaret(5, 5)
+	if e.ShrinkSelection() {
+		t.Error("the selection should not be shrunk after it is changed")
+	}
+}
+
+func TestSelectionRangeProvider(t *testing.T) {
+	var queries []TextRange
+	provider := func(start, end int) []TextRange {
+		queries = append(queries, TextRange{Start: start, End: end})
+		return []TextRange{
+			// not containing the selection.
+			{Start: 0, End: 2},
+			{Start: 2, End: 9},
+			{Start: 3, End: 6},
+		}
+	}
+
+	e := newTestEditor(t, "0123456789ab", WithSelectionRangeProvider(provider))
+	e.SetCaret(4, 4)
+
+	want := []TextRange{{Start: 6, End: 3}, {Start: 9, End: 2}, {Start: 12, End: 0}}
+	for i, rng := range want {
+		e.ExpandSelection()
+		if start, end := e.Selection(); (TextRange{Start: start, End: end}) != rng {
+			t.Errorf("level %d: want %v, got %d, %d", i, rng, start, end)
+		}
+	}
+	wantQueries := []TextRange{{Start: 4, End: 4}, {Start: 3, End: 6}, {Start: 2, End: 9}}
+	if len(queries) != len(wantQueries) {
+		t.Fatalf("want queries %v, got %v", wantQueries, queries)
+	}
+	for i := range queries {
+		if queries[i] != wantQueries[i] {
+			t.Errorf("want queries %v, got %v", wantQueries, queries)
+		}
+	}
+
+	e.ShrinkSelection()
+	if start, end := e.Selection(); start != 9 || end != 2 {
+		t.Errorf("want the provided range restored, got %d, %d", start, end)
+	}
+}
+
+func TestExpandSelectionCommands(t *testing.T) {
+	w := newTestWindow(t, "f(foo)")
+	w.editor.SetCaret(3, 3)
+
+	w.press(key.NameRightArrow, expandSelectionChord)
+	w.press(key.NameRightArrow, expandSelectionChord)
+	if got := w.editor.SelectedText(); got != "(foo)" {
+		t.Errorf("want the brackets selected, got %q", got)
+	}
+	w.press(key.NameLeftArrow, expandSelectionChord)
+	if got := w.editor.SelectedText(); got != "foo" {
+		t.Errorf("want the contents selected, got %q", got)
+	}
+}
@@ -9,11 +9,11 @@ require (
 	github.com/rdleal/intervalst v1.4.1
 	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0
 	golang.org/x/image v0.26.0
+	golang.org/x/text v0.24.0
 )
 
 require (
 	gioui.org/shader v1.0.8 // indirect
 	golang.org/x/exp/shiny v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
-	golang.org/x/text v0.24.0 // indirect
 )
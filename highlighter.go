@@ -2,7 +2,10 @@ package gvcode
 
 import (
 	stdColor "image/color"
+	"time"
 
+	"gioui.org/layout"
+	"gioui.org/op"
 	"github.com/oligo/gvcode/color"
 	"github.com/oligo/gvcode/textstyle/decoration"
 )
@@ -10,6 +13,7 @@ import (
 const (
 	wordHighlightSource      = "_word_highlight"
 	selectionHighlightSource = "_selection_highlight"
+	unbalancedBracketSource  = "_unbalanced_bracket"
 )
 
 // wordHighlighter finds the word at the current caret position and highlights
@@ -181,3 +185,71 @@ func (sh *selectionHighlighter) Clear() {
 func (sh *selectionHighlighter) MarkDirty() {
 	sh.dirty = true
 }
+
+// bracketCheckDelay is the time the text has to stay unchanged before the
+// brackets are checked again, so the document is not scanned on every
+// keystroke.
+const bracketCheckDelay = 300 * time.Millisecond
+
+// bracketChecker marks the brackets without a matching bracket in the document
+// with squiggles. The brackets are checked again once the text or the syntax
+// tokens stop changing for bracketCheckDelay.
+type bracketChecker struct {
+	editor  *Editor
+	enabled bool
+	dirty   bool
+	// due is the time of the next check, set on the first frame after the
+	// checker is marked dirty.
+	due time.Time
+}
+
+// Due reports whether the brackets should be checked in this frame. If the
+// check is not due yet, a frame is scheduled at the time it is.
+func (bc *bracketChecker) Due(gtx layout.Context) bool {
+	if !bc.dirty {
+		return false
+	}
+	if !bc.enabled {
+		// clear the squiggles at once.
+		return true
+	}
+	if bc.due.IsZero() {
+		bc.due = gtx.Now.Add(bracketCheckDelay)
+	}
+	if gtx.Now.Before(bc.due) {
+		gtx.Execute(op.InvalidateCmd{At: bc.due})
+		return false
+	}
+	return true
+}
+
+func (bc *bracketChecker) Check(markColor color.Color) error {
+	bc.editor.ClearDecorations(unbalancedBracketSource)
+	bc.dirty = false
+	if !bc.enabled {
+		return nil
+	}
+
+	if !markColor.IsSet() {
+		markColor = color.MakeColor(stdColor.NRGBA{R: 0xE5, G: 0x39, B: 0x35, A: 0xFF})
+	}
+
+	unbalanced := bc.editor.text.UnbalancedBrackets()
+	decos := make([]decoration.Decoration, 0, len(unbalanced))
+	for _, pos := range unbalanced {
+		decos = append(decos, decoration.Decoration{
+			Source:   unbalancedBracketSource,
+			Start:    pos,
+			End:      pos + 1,
+			Squiggle: &decoration.Squiggle{Color: markColor},
+		})
+	}
+	return bc.editor.AddDecorations(decos...)
+}
+
+// MarkDirty marks that the brackets need to be checked again, postponing the
+// check until the changes stop.
+func (bc *bracketChecker) MarkDirty() {
+	bc.dirty = true
+	bc.due = time.Time{}
+}
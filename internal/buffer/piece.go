@@ -46,6 +46,12 @@ type CursorPos struct {
 	End int
 }
 
+// TextEdit is a replacement of the text in the rune range [Start, OldEnd) with
+// the text in the rune range [Start, NewEnd) of the new text.
+type TextEdit struct {
+	Start, OldEnd, NewEnd int
+}
+
 // A piece-range effectively represents the range of pieces affected by an operation on the sequence.
 // Two kinds range exist here:
 //  1. Normal range of pieces with the first and last all effective pieces.
@@ -60,6 +66,15 @@ type pieceRange struct {
 	// batchId is the id of a group of modifications cased by a atomic operation.
 	// undo/redo should check continuous same batchId to find all batched modifications.
 	batchId *int
+	// carets are the selections of the carets around the batch of the range,
+	// set on the last range of the batch.
+	carets *caretState
+}
+
+// caretState is the selections of the carets before and after a batch of
+// modifications.
+type caretState struct {
+	before, after []CursorPos
 }
 
 func newPieceList() *pieceList {
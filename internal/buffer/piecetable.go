@@ -58,7 +58,12 @@ type PieceTable struct {
 	changed bool
 	// setting a batchId to group
 	currentBatch *int
-	mu           sync.RWMutex
+	// restoredCarets are the carets of the batch restored by the last undo or
+	// redo.
+	restoredCarets []CursorPos
+	// restoredEdits are the edits made by the last undo or redo.
+	restoredEdits []TextEdit
+	mu            sync.RWMutex
 
 	// Index of the slice saves the continuous line number starting from zero.
 	// The value contains the rune length of the line.
@@ -95,6 +100,8 @@ func (pt *PieceTable) SetText(text []byte) {
 	pt.lastInsertPiece = nil
 	pt.changed = false
 	pt.currentBatch = nil
+	pt.restoredCarets = nil
+	pt.restoredEdits = nil
 	pt.markers = pt.markers[:0]
 	pt.init(text)
 }
@@ -304,7 +311,16 @@ func (pt *PieceTable) undoRedo(src *pieceRangeStack, dest *pieceRangeStack) ([]C
 		return nil, false
 	}
 
+	pt.restoredCarets = nil
+	pt.restoredEdits = pt.restoredEdits[:0]
 	restoreFunc := func(rng *pieceRange) CursorPos {
+		if rng.carets != nil {
+			if src == pt.undoStack {
+				pt.restoredCarets = rng.carets.before
+			} else {
+				pt.restoredCarets = rng.carets.after
+			}
+		}
 		newRuneLen, newBytes := rng.Size()
 
 		// restore to the old piece range.
@@ -313,6 +329,7 @@ func (pt *PieceTable) undoRedo(src *pieceRangeStack, dest *pieceRangeStack) ([]C
 		dest.push(rng)
 
 		lastRuneLen, lastBytes := rng.Size()
+		pt.restoredEdits = append(pt.restoredEdits, pt.restoredEdit(rng))
 		pt.seqLength += newRuneLen - lastRuneLen
 		pt.seqBytes += newBytes - lastBytes
 		pt.changed = true
@@ -341,6 +358,83 @@ func (pt *PieceTable) undoRedo(src *pieceRangeStack, dest *pieceRangeStack) ([]C
 	return cursors, true
 }
 
+// restoredEdit returns the edit made by restoring the pieces in place of rng,
+// which holds the pieces removed from the sequence. The runes at both ends shared
+// by the removed and the restored pieces, like the parts of a piece split by an
+// insertion, are not part of the edit.
+func (pt *PieceTable) restoredEdit(rng *pieceRange) TextEdit {
+	prev, next := rng.first, rng.last
+	var removed []*piece
+	if !rng.boundary {
+		prev, next = rng.first.prev, rng.last.next
+		for n := rng.first; n != rng.last.next; n = n.next {
+			removed = append(removed, n)
+		}
+	}
+	var restored []*piece
+	for n := prev.next; n != next; n = n.next {
+		restored = append(restored, n)
+	}
+
+	start := 0
+	for n := pt.pieces.head.next; n != prev.next; n = n.next {
+		start += n.length
+	}
+
+	removedLen, restoredLen := 0, 0
+	for _, p := range removed {
+		removedLen += p.length
+	}
+	for _, p := range restored {
+		restoredLen += p.length
+	}
+
+	prefix := commonRunes(removed, restored, false)
+	suffix := min(commonRunes(removed, restored, true), min(removedLen, restoredLen)-prefix)
+	return TextEdit{
+		Start:  start + prefix,
+		OldEnd: start + removedLen - suffix,
+		NewEnd: start + restoredLen - suffix,
+	}
+}
+
+// commonRunes counts the runes at the start, or at the end if reversed, of the
+// two piece sequences that refer to the same runes of the buffers.
+func commonRunes(a, b []*piece, reversed bool) int {
+	// runeAt returns the position in the buffers of the rune at the distance n
+	// from the start or the end of the piece p.
+	runeAt := func(p *piece, n int) int {
+		if reversed {
+			return p.offset + p.length - n
+		}
+		return p.offset + n
+	}
+
+	common := 0
+	i, j := 0, 0
+	ai, bj := 0, 0
+	for i < len(a) && j < len(b) {
+		pa, pb := a[i], b[j]
+		if reversed {
+			pa, pb = a[len(a)-1-i], b[len(b)-1-j]
+		}
+		if pa.source != pb.source || runeAt(pa, ai) != runeAt(pb, bj) {
+			break
+		}
+
+		n := min(pa.length-ai, pb.length-bj)
+		common += n
+		ai, bj = ai+n, bj+n
+		if ai == pa.length {
+			i, ai = i+1, 0
+		}
+		if bj == pb.length {
+			j, bj = j+1, 0
+		}
+	}
+	return common
+}
+
 func (pt *PieceTable) erase(startOff, endOff int) bool {
 	cursor := CursorPos{Start: startOff, End: endOff}
 
@@ -553,6 +647,37 @@ func (pt *PieceTable) unGroupOp() {
 	}
 }
 
+// SetCarets attaches the selections of the carets before and after the current
+// batch to the batch. It does nothing if there is no batch, or no operation is
+// batched yet.
+func (pt *PieceTable) SetCarets(before, after []CursorPos) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	rng := pt.undoStack.peek()
+	if pt.currentBatch == nil || rng == nil || rng.batchId != pt.currentBatch {
+		return
+	}
+	rng.carets = &caretState{before: before, after: after}
+}
+
+// RestoredCarets returns the selections of the carets attached to the batch
+// restored by the last Undo or Redo. They are the selections before the batch
+// after Undo, and after it after Redo.
+func (pt *PieceTable) RestoredCarets() ([]CursorPos, bool) {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+	return pt.restoredCarets, pt.restoredCarets != nil
+}
+
+// RestoredEdits returns the edits made to the text by the last Undo or Redo, in
+// the order they are made.
+func (pt *PieceTable) RestoredEdits() []TextEdit {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+	return pt.restoredEdits
+}
+
 // Size returns the total length of the document data in runes.
 func (pt *PieceTable) Len() int {
 	pt.mu.RLock()
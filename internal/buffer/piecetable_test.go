@@ -2,6 +2,7 @@ package buffer
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 )
 
@@ -276,6 +277,34 @@ func TestGroupOp(t *testing.T) {
 	}
 }
 
+func TestUndoRedoCarets(t *testing.T) {
+	pt := NewPieceTable([]byte("a b"))
+	before := []CursorPos{{Start: 1, End: 1}, {Start: 3, End: 3}}
+	after := []CursorPos{{Start: 2, End: 2}, {Start: 5, End: 5}}
+
+	// nothing is batched yet.
+	pt.GroupOp()
+	pt.SetCarets(before, after)
+	pt.Replace(1, 1, "x")
+	pt.Replace(4, 4, "x")
+	pt.SetCarets(before, after)
+	pt.UnGroupOp()
+	pt.Replace(0, 0, "y")
+
+	pt.Undo()
+	if _, ok := pt.RestoredCarets(); ok {
+		t.Errorf("unexpected carets restored by undoing an operation without carets")
+	}
+	pt.Undo()
+	if got, ok := pt.RestoredCarets(); !ok || !slices.Equal(got, before) {
+		t.Errorf("undo: want carets %v, got %v", before, got)
+	}
+	pt.Redo()
+	if got, ok := pt.RestoredCarets(); !ok || !slices.Equal(got, after) {
+		t.Errorf("redo: want carets %v, got %v", after, got)
+	}
+}
+
 func TestMarkerOnInsert(t *testing.T) {
 	setup := func(bais MarkerBias, markerPos int) (*PieceTable, *Marker) {
 		pt := NewPieceTable([]byte("hello,world"))
@@ -436,3 +465,66 @@ func TestMarkerOnErase(t *testing.T) {
 		})
 	}
 }
+
+func TestRestoredEdits(t *testing.T) {
+	pt := NewPieceTable([]byte("hello world"))
+
+	steps := []struct {
+		name string
+		edit func()
+		// undo and redo are the edits restored by Undo and Redo.
+		undo, redo []TextEdit
+	}{
+		{
+			name: "insert in a piece",
+			edit: func() { pt.Replace(5, 5, ", big") },
+			undo: []TextEdit{{Start: 5, OldEnd: 10, NewEnd: 5}},
+			redo: []TextEdit{{Start: 5, OldEnd: 5, NewEnd: 10}},
+		},
+		{
+			name: "erase across pieces",
+			edit: func() { pt.Replace(0, 6, "") },
+			undo: []TextEdit{{Start: 0, OldEnd: 0, NewEnd: 6}},
+			redo: []TextEdit{{Start: 0, OldEnd: 6, NewEnd: 0}},
+		},
+		{
+			name: "replace",
+			edit: func() { pt.Replace(4, 9, "there") },
+			undo: []TextEdit{{Start: 4, OldEnd: 9, NewEnd: 4}, {Start: 4, OldEnd: 4, NewEnd: 9}},
+			redo: []TextEdit{{Start: 4, OldEnd: 9, NewEnd: 4}, {Start: 4, OldEnd: 4, NewEnd: 9}},
+		},
+		{
+			name: "grouped inserts",
+			edit: func() {
+				pt.GroupOp()
+				pt.Replace(0, 0, "a")
+				pt.Replace(5, 5, "b")
+				pt.UnGroupOp()
+			},
+			undo: []TextEdit{{Start: 5, OldEnd: 6, NewEnd: 5}, {Start: 0, OldEnd: 1, NewEnd: 0}},
+			redo: []TextEdit{{Start: 0, OldEnd: 0, NewEnd: 1}, {Start: 5, OldEnd: 5, NewEnd: 6}},
+		},
+	}
+
+	for _, step := range steps {
+		before := readTableContent(pt)
+		step.edit()
+		after := readTableContent(pt)
+
+		pt.Undo()
+		if got := pt.RestoredEdits(); !slices.Equal(got, step.undo) {
+			t.Errorf("%s: want undo edits %v, got %v", step.name, step.undo, got)
+		}
+		if got := readTableContent(pt); got != before {
+			t.Fatalf("%s: undo: want %q, got %q", step.name, before, got)
+		}
+
+		pt.Redo()
+		if got := pt.RestoredEdits(); !slices.Equal(got, step.redo) {
+			t.Errorf("%s: want redo edits %v, got %v", step.name, step.redo, got)
+		}
+		if got := readTableContent(pt); got != after {
+			t.Fatalf("%s: redo: want %q, got %q", step.name, after, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package buffer
 
 import (
 	"io"
+	"unicode/utf8"
 )
 
 var _ TextSource = (*PieceTable)(nil)
@@ -129,3 +130,69 @@ func NewTextSource() *PieceTable {
 func NewReader(src TextSource) TextReader {
 	return &pieceTableReader{src: src}
 }
+
+// RuneReader reads the runes of a [TextSource] sequentially from a rune offset,
+// decoding the bytes read in chunks. It implements [io.RuneReader].
+type RuneReader struct {
+	src TextSource
+	// byteOff is the byte offset of buf in the source.
+	byteOff int64
+	buf     []byte
+	pos     int
+	// remaining is the number of runes left to read.
+	remaining int
+}
+
+// NewRuneReader returns a reader of the runes in [start, end) of src. Offsets
+// are in runes.
+func NewRuneReader(src TextSource, start, end int) *RuneReader {
+	start = max(0, min(start, src.Len()))
+	end = max(start, min(end, src.Len()))
+	return &RuneReader{
+		src:       src,
+		byteOff:   int64(src.RuneOffset(start)),
+		remaining: end - start,
+	}
+}
+
+// ReadRune implements [io.RuneReader].
+func (r *RuneReader) ReadRune() (ch rune, size int, err error) {
+	if r.remaining <= 0 {
+		return 0, 0, io.EOF
+	}
+
+	if !utf8.FullRune(r.buf[r.pos:]) {
+		if err := r.fill(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	ch, size = utf8.DecodeRune(r.buf[r.pos:])
+	r.pos += size
+	r.remaining--
+	return ch, size, nil
+}
+
+// fill moves the unread bytes to the start of the buffer, and reads the next
+// chunk of the source after them.
+func (r *RuneReader) fill() error {
+	const chunkSize = 4096
+	if r.buf == nil {
+		r.buf = make([]byte, 0, chunkSize)
+	}
+
+	r.byteOff += int64(r.pos)
+	n := copy(r.buf[:cap(r.buf)], r.buf[r.pos:])
+	r.buf = r.buf[:n]
+	r.pos = 0
+
+	m, err := r.src.ReadAt(r.buf[n:cap(r.buf)], r.byteOff+int64(n))
+	r.buf = r.buf[:n+m]
+	if len(r.buf) == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return err
+	}
+	return nil
+}
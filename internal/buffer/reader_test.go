@@ -1,6 +1,7 @@
 package buffer
 
 import (
+	"strings"
 	"testing"
 	"unicode/utf8"
 )
@@ -69,3 +70,28 @@ func TestReadRuneAt(t *testing.T) {
 	}
 
 }
+
+func TestRuneReader(t *testing.T) {
+	src := NewTextSource()
+	text := strings.Repeat("hello,世界.", 1000)
+	src.Replace(0, 0, text)
+
+	runes := []rune(text)
+	cases := [][2]int{{0, len(runes)}, {3, 20}, {4000, len(runes)}, {5, 5}, {len(runes) - 2, len(runes) + 10}}
+	for _, c := range cases {
+		reader := NewRuneReader(src, c[0], c[1])
+		var got []rune
+		for {
+			r, _, err := reader.ReadRune()
+			if err != nil {
+				break
+			}
+			got = append(got, r)
+		}
+
+		want := runes[c[0]:min(c[1], len(runes))]
+		if string(got) != string(want) {
+			t.Errorf("range %v: want %d runes, got %d runes", c, len(want), len(got))
+		}
+	}
+}
@@ -53,6 +53,18 @@ type TextSource interface {
 	// a group is not batched.
 	UnGroupOp()
 
+	// SetCarets attaches the selections of the carets before and after the
+	// current group of operations to the group. It does nothing if no
+	// operation is grouped yet.
+	SetCarets(before, after []CursorPos)
+	// RestoredCarets returns the selections of the carets attached to the
+	// group restored by the last Undo or Redo, which are the selections before
+	// the group for Undo and after it for Redo.
+	RestoredCarets() ([]CursorPos, bool)
+	// RestoredEdits returns the edits made to the text by the last Undo or
+	// Redo, in the order they are made.
+	RestoredEdits() []TextEdit
+
 	// Changed report whether the contents have changed since the last call to Changed.
 	Changed() bool
 }
@@ -44,6 +44,27 @@ func (f *Animation) Start(c unit.Metric, now time.Time, velocity float32) bool {
 	return true
 }
 
+// StartDistance starts a fling that travels approximately the given distance in
+// pixels before it stops. Returns whether a fling was started. The distance is
+// limited by the maximum fling velocity.
+func (f *Animation) StartDistance(c unit.Metric, now time.Time, distance float32) bool {
+	// The fling position converges to -v0/k, see Tick.
+	return f.Start(c, now, -distance*friction())
+}
+
+// MaxDistance returns the longest distance in pixels a fling can travel.
+func MaxDistance(c unit.Metric) float32 {
+	return -float32(c.Dp(maxFlingVelocity)) / friction()
+}
+
+// friction returns the drag coefficient k of the fling.
+func friction() float32 {
+	if runtime.GOOS == "darwin" {
+		return -2 // iOS
+	}
+	return -4.2 // Android and default
+}
+
 func (f *Animation) init(now time.Time, v0 float32) {
 	f.t0 = now
 	f.v0 = v0
@@ -60,12 +81,7 @@ func (f *Animation) Tick(now time.Time) int {
 	if !f.Active() {
 		return 0
 	}
-	var k float32
-	if runtime.GOOS == "darwin" {
-		k = -2 // iOS
-	} else {
-		k = -4.2 // Android and default
-	}
+	k := friction()
 	t := now.Sub(f.t0)
 	// The acceleration x''(t) of a point mass with a drag
 	// force, f, proportional with velocity, x'(t), is
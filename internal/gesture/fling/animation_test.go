@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: Unlicense OR MIT
+
+package fling
+
+import (
+	"testing"
+	"time"
+
+	"gioui.org/unit"
+)
+
+func TestAnimationStartDistance(t *testing.T) {
+	metric := unit.Metric{PxPerDp: 1}
+	now := time.Now()
+	for _, distance := range []float32{200, -300, 1000} {
+		var anim Animation
+		if !anim.StartDistance(metric, now, distance) {
+			t.Fatalf("fling of %v pixels was not started", distance)
+		}
+
+		total := 0
+		for tick := now; anim.Active(); {
+			tick = tick.Add(16 * time.Millisecond)
+			total += anim.Tick(tick)
+		}
+		if diff := float32(total) - distance; diff > 2 || diff < -2 {
+			t.Errorf("expected a fling of %v pixels, got %d", distance, total)
+		}
+	}
+}
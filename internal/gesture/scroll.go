@@ -38,6 +38,14 @@ type Scroll struct {
 	scrollAxis Axis
 	// True if the axis for the current gesture/scrolling has been determined.
 	axisLocked bool
+	// Accumulated zoom steps of wheel scrolling with the shortcut modifier.
+	zoom int
+	// Position of the last zoom scrolling.
+	zoomPos f32.Point
+	// Modifiers of the last wheel scrolling, and whether it is not reported by
+	// Modifiers yet.
+	mods     key.Modifiers
+	modsSeen bool
 }
 
 type ScrollState uint8
@@ -135,6 +143,20 @@ func (s *Scroll) Update(cfg unit.Metric, q input.Source, t time.Time, scrollx, s
 			s.dragging = false
 			//s.axisLocked = false
 		case pointer.Scroll:
+			s.mods, s.modsSeen = e.Modifiers, true
+			if e.Modifiers.Contain(key.ModShortcut) {
+				// Wheel scrolling with the shortcut modifier zooms instead of
+				// scrolling. Only the direction is used as the distance may have
+				// been clamped by the scroll range.
+				switch {
+				case e.Scroll.Y < 0:
+					s.zoom++
+				case e.Scroll.Y > 0:
+					s.zoom--
+				}
+				s.zoomPos = e.Position
+				break
+			}
 			if e.Modifiers.Contain(key.ModShift) {
 				s.scrollAxis = Horizontal
 			} else {
@@ -208,6 +230,20 @@ func (s *Scroll) Update(cfg unit.Metric, q input.Source, t time.Time, scrollx, s
 	return total
 }
 
+// Zoom returns the zoom steps accumulated by Update since the last call, and
+// the pointer position of the last zoom scrolling. Positive steps zoom in.
+func (s *Scroll) Zoom() (steps int, pos f32.Point) {
+	steps, s.zoom = s.zoom, 0
+	return steps, s.zoomPos
+}
+
+// Modifiers returns the key modifiers of the last wheel scrolling seen by Update.
+// ok is false if there has been no wheel scrolling since the last call.
+func (s *Scroll) Modifiers() (mods key.Modifiers, ok bool) {
+	ok, s.modsSeen = s.modsSeen, false
+	return s.mods, ok
+}
+
 func (s *Scroll) val(axis Axis, p f32.Point) float32 {
 	switch axis {
 	case Horizontal:
@@ -0,0 +1,103 @@
+package layout
+
+import (
+	"unicode"
+
+	"github.com/go-text/typesetting/segmenter"
+	"golang.org/x/text/width"
+)
+
+// FillText breaks text into lines of at most columns display columns, filling
+// each line with as many words as fit. The width of the text is measured with
+// RuneWidth, so the wide East Asian characters take two columns. The spaces at the breaks are dropped. A word wider
+// than columns is put on a line of its own and is not broken. Mandatory breaks
+// in text are kept.
+//
+// The UAX#14 line break opportunities are only taken after spaces, or next to
+// the ideographic characters written without spaces, like those of Chinese and
+// Japanese. So the runs of other text without spaces, like URLs, paths and
+// dotted or hyphenated words, are never broken.
+func FillText(text []rune, columns int) []string {
+	if len(text) == 0 {
+		return []string{""}
+	}
+
+	var seg segmenter.Segmenter
+	seg.Init(text)
+	iter := seg.LineIterator()
+
+	var lines []string
+	var line, word []rune
+	lineWidth := 0
+	for iter.Next() {
+		segment := iter.Line()
+		word = append(word, segment.Text...)
+		end := segment.Offset + len(segment.Text)
+		if !segment.IsMandatoryBreak && end < len(text) && !fillBreak(text[end-1], text[end]) {
+			continue
+		}
+
+		// the trailing spaces of a word may hang past the column.
+		if len(line) > 0 && lineWidth+TextWidth(trimRightSpace(word)) > columns {
+			lines = append(lines, string(trimRightSpace(line)))
+			line = line[:0]
+			lineWidth = 0
+		}
+		line = append(line, word...)
+		lineWidth += TextWidth(word)
+		word = word[:0]
+
+		if segment.IsMandatoryBreak {
+			lines = append(lines, string(trimRightSpace(line)))
+			line = line[:0]
+			lineWidth = 0
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, string(trimRightSpace(line)))
+	}
+	return lines
+}
+
+// fillBreak reports whether FillText takes the line break opportunity between
+// the runes before and after.
+func fillBreak(before, after rune) bool {
+	return unicode.IsSpace(before) || isIdeographic(before) || isIdeographic(after)
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// RuneWidth returns the number of columns r takes in a monospace font: 2 for
+// the wide and fullwidth East Asian characters, 0 for the combining marks and
+// the zero width characters, and 1 for the others.
+func RuneWidth(r rune) int {
+	switch {
+	case r == '\u200b' || r == '\u200d' || r == '\ufeff':
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// TextWidth returns the sum of the widths of the runes of s.
+func TextWidth(s []rune) int {
+	w := 0
+	for _, r := range s {
+		w += RuneWidth(r)
+	}
+	return w
+}
+
+func trimRightSpace(s []rune) []rune {
+	for len(s) > 0 && unicode.IsSpace(s[len(s)-1]) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
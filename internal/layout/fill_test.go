@@ -0,0 +1,78 @@
+package layout
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFillText(t *testing.T) {
+	testcases := []struct {
+		input   string
+		columns int
+		want    []string
+	}{
+		{
+			input:   "",
+			columns: 10,
+			want:    []string{""},
+		},
+		{
+			input:   "the quick brown fox jumps over the lazy dog",
+			columns: 15,
+			want:    []string{"the quick brown", "fox jumps over", "the lazy dog"},
+		},
+		{
+			// a word wider than the columns is not broken.
+			input:   "a verylongwordthatdoesnotfit b",
+			columns: 10,
+			want:    []string{"a", "verylongwordthatdoesnotfit", "b"},
+		},
+		{
+			// hyphenated words are not broken.
+			input:   "a state-of-the-art design",
+			columns: 12,
+			want:    []string{"a", "state-of-the-art", "design"},
+		},
+		{
+			// URLs and paths are not broken, though UAX#14 allows breaks
+			// after their slashes.
+			input:   "see https://example.com/foo/bar-baz for details",
+			columns: 20,
+			want:    []string{"see", "https://example.com/foo/bar-baz", "for details"},
+		},
+		{
+			input:   "call pkg.Func.Method now",
+			columns: 10,
+			want:    []string{"call", "pkg.Func.Method", "now"},
+		},
+		{
+			// ideographs mixed with words. The ideographs take two columns.
+			input:   "日本語のtext文章です",
+			columns: 8,
+			want:    []string{"日本語の", "text文章", "です"},
+		},
+		{
+			// breaks are allowed between ideographs.
+			input:   "日本語の文章です",
+			columns: 8,
+			want:    []string{"日本語の", "文章です"},
+		},
+		{
+			input:   "日本語の文章です",
+			columns: 7,
+			want:    []string{"日本語", "の文章", "です"},
+		},
+		{
+			input:   "first line\nsecond",
+			columns: 80,
+			want:    []string{"first line", "second"},
+		},
+	}
+
+	for i, tc := range testcases {
+		got := FillText([]rune(tc.input), tc.columns)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("case %d: want %q, got %q", i, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package layout
+
+import (
+	"iter"
+	"slices"
+
+	"gioui.org/font"
+	"gioui.org/text"
+)
+
+// FontRun is a range of runes that is shaped with a variant of the base font.
+type FontRun struct {
+	// Start and End are rune offsets in the document. Start is inclusive and
+	// End is exclusive.
+	Start, End int
+	// Weight overrides the weight of the base font if set.
+	Weight font.Weight
+	// Style overrides the style of the base font if set.
+	Style font.Style
+}
+
+// FontRunProvider provides the font variants of the document.
+type FontRunProvider interface {
+	// FontRuns returns the font runs overlapping the rune range [start, end).
+	// The returned runs must not overlap and must be sorted by Start.
+	FontRuns(start, end int) []FontRun
+}
+
+// styledFont returns the base font overridden by the font run.
+func (r FontRun) styledFont(base font.Font) font.Font {
+	if r.Weight != font.Normal {
+		base.Weight = r.Weight
+	}
+	if r.Style != font.Regular {
+		base.Style = r.Style
+	}
+	return base
+}
+
+// shapeStyledParagraph shapes the paragraph segment by segment, using the font
+// variants of the font runs. It returns nil if the paragraph has no font runs.
+// The glyphs of the segments are concatenated as if they were shaped at once,
+// so the line wrapper and the glyph indexing are unaware of the segments.
+func (tl *TextLayout) shapeStyledParagraph(shaper *text.Shaper, params text.Parameters, paragraph []rune, runeOff int) iter.Seq[text.Glyph] {
+	if tl.FontRuns == nil || len(paragraph) == 0 {
+		return nil
+	}
+
+	runs := tl.FontRuns.FontRuns(runeOff, runeOff+len(paragraph))
+	if len(runs) == 0 {
+		return nil
+	}
+
+	tl.styledGlyphs = tl.styledGlyphs[:0]
+	shapeSegment := func(start, end int, f font.Font) {
+		if start >= end {
+			return
+		}
+		p := params
+		p.Font = f
+		shaper.LayoutString(p, string(paragraph[start:end]))
+		segStart := len(tl.styledGlyphs)
+		for {
+			gl, ok := shaper.NextGlyph()
+			if !ok {
+				break
+			}
+			tl.styledGlyphs = append(tl.styledGlyphs, gl)
+		}
+
+		// Only the last segment of the paragraph ends a line.
+		if end < len(paragraph) && len(tl.styledGlyphs) > segStart {
+			tl.styledGlyphs[len(tl.styledGlyphs)-1].Flags &^= text.FlagLineBreak
+		}
+	}
+
+	pos := 0
+	for _, run := range runs {
+		start := max(run.Start-runeOff, pos)
+		end := min(run.End-runeOff, len(paragraph))
+		if start >= end {
+			continue
+		}
+
+		shapeSegment(pos, start, params.Font)
+		shapeSegment(start, end, run.styledFont(params.Font))
+		pos = end
+	}
+	shapeSegment(pos, len(paragraph), params.Font)
+
+	return slices.Values(tl.styledGlyphs)
+}
@@ -2,12 +2,14 @@ package layout
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"image"
 	"io"
+	"iter"
 	"math"
+	"slices"
 	"sort"
-	"strings"
 
 	"gioui.org/layout"
 	"gioui.org/text"
@@ -16,13 +18,43 @@ import (
 	"golang.org/x/image/math/fixed"
 )
 
+// WrapPolicy configures the width at which paragraphs are wrapped into
+// screen lines when line wrapping is enabled.
+type WrapPolicy uint8
+
+const (
+	// WrapViewport wraps lines at the maximum width of the layout
+	// constraints, which is usually the width of the viewport.
+	WrapViewport WrapPolicy = iota
+	// WrapColumn wraps lines at a fixed column, measured in advances of
+	// the space glyph, regardless of the viewport width.
+	WrapColumn
+	// WrapBounded wraps lines at the fixed column or the viewport width,
+	// whichever is smaller.
+	WrapBounded
+)
+
 type TextLayout struct {
+	// WrapPolicy sets how the wrapping width is determined.
+	WrapPolicy WrapPolicy
+	// WrapColumn is the column used by the WrapColumn and WrapBounded
+	// policies. A non-positive value falls back to WrapViewport.
+	WrapColumn int
+	// ExpandControlChars controls whether non-printing control characters
+	// are laid out as blank glyphs wide enough to hold a visible label.
+	ExpandControlChars bool
+	// FontRuns provides the ranges of text that are shaped with a weight or
+	// style variant of the font, like bold keywords and italic comments.
+	FontRuns FontRunProvider
+
 	src        buffer.TextSource
 	reader     *bufio.Reader
 	params     text.Parameters
 	spaceGlyph text.Glyph
 	wrapper    lineWrapper
 	seg        segmenter.Segmenter
+	// buffer of glyphs of a paragraph shaped with font runs.
+	styledGlyphs []text.Glyph
 
 	// Positions contain all possible caret positions, sorted by rune index.
 	Positions []CombinedPos
@@ -76,6 +108,7 @@ func (tl *TextLayout) reset() {
 func (tl *TextLayout) Layout(shaper *text.Shaper, params *text.Parameters, tabWidth int, wrapLine bool) layout.Dimensions {
 	tl.reset()
 	tl.params = *params
+	tl.wrapper.expandControls = tl.ExpandControlChars
 	paragraphCount := tl.src.Lines()
 
 	if shaper == nil {
@@ -90,9 +123,9 @@ func (tl *TextLayout) Layout(shaper *text.Shaper, params *text.Parameters, tabWi
 				text, readErr := tl.reader.ReadString('\n')
 				// the last line returned by ReadBytes returns EOF and may have remaining bytes to process.
 				if len(text) > 0 {
-					tl.layoutNextParagraph(shaper, text, paragraphCount-1 == currentIdx, tabWidth, wrapLine)
-
 					paragraphRunes := []rune(text)
+					tl.layoutNextParagraph(shaper, paragraphRunes, runeOffset, paragraphCount-1 == currentIdx, tabWidth, wrapLine)
+
 					tl.indexGraphemeClusters(paragraphRunes, runeOffset)
 					runeOffset += len(paragraphRunes)
 					currentIdx++
@@ -103,7 +136,7 @@ func (tl *TextLayout) Layout(shaper *text.Shaper, params *text.Parameters, tabWi
 				}
 			}
 		} else {
-			tl.layoutNextParagraph(shaper, "", true, tabWidth, wrapLine)
+			tl.layoutNextParagraph(shaper, nil, 0, true, tabWidth, wrapLine)
 		}
 
 		tl.calculateXOffsets()
@@ -125,25 +158,142 @@ func (tl *TextLayout) Layout(shaper *text.Shaper, params *text.Parameters, tabWi
 	return dims
 }
 
-func (tl *TextLayout) layoutNextParagraph(shaper *text.Shaper, paragraph string, isLastParagrah bool, tabWidth int, wrapLine bool) {
+// SpaceAdvance returns the advance of the space glyph shaped with the
+// current text parameters. For monospace fonts this is the width of one
+// column.
+func (tl *TextLayout) SpaceAdvance() fixed.Int26_6 {
+	return tl.spaceGlyph.Advance
+}
+
+// SpaceGlyphID returns the ID of the space glyph, which is shared by the expanded
+// tabs and control characters.
+func (tl *TextLayout) SpaceGlyphID() text.GlyphID {
+	return tl.spaceGlyph.ID
+}
+
+// wrapWidth returns the width in pixels at which lines are wrapped,
+// according to the configured wrap policy.
+func (tl *TextLayout) wrapWidth() int {
+	if tl.WrapPolicy == WrapViewport || tl.WrapColumn <= 0 {
+		return tl.params.MaxWidth
+	}
+
+	colWidth := tl.spaceGlyph.Advance.Mul(fixed.I(tl.WrapColumn)).Ceil()
+	if colWidth <= 0 {
+		return tl.params.MaxWidth
+	}
+
+	if tl.WrapPolicy == WrapBounded {
+		return min(colWidth, tl.params.MaxWidth)
+	}
+	return colWidth
+}
+
+func (tl *TextLayout) layoutNextParagraph(shaper *text.Shaper, paragraph []rune, runeOff int, isLastParagrah bool, tabWidth int, wrapLine bool) {
+	tl.Lines = append(tl.Lines, tl.shapeParagraph(shaper, paragraph, runeOff, isLastParagrah, tabWidth, wrapLine)...)
+}
+
+// shapeParagraph shapes and wraps a paragraph into screen lines.
+func (tl *TextLayout) shapeParagraph(shaper *text.Shaper, paragraph []rune, runeOff int, isLastParagrah bool, tabWidth int, wrapLine bool) []Line {
 	params := tl.params
-	maxWidth := params.MaxWidth
+	maxWidth := tl.wrapWidth()
 	params.MaxWidth = 1e6
 	if !wrapLine {
 		maxWidth = params.MaxWidth
 	}
-	shaper.LayoutString(params, paragraph)
 
-	lines := tl.wrapParagraph(glyphIter{shaper: shaper}, []rune(paragraph), maxWidth, tabWidth, &tl.spaceGlyph)
-	if strings.HasSuffix(paragraph, "\n") && len(lines) > 0 && !isLastParagrah {
+	glyphs := tl.shapeStyledParagraph(shaper, params, paragraph, runeOff)
+	if glyphs == nil {
+		shaper.LayoutString(params, string(paragraph))
+		glyphs = glyphIter{shaper: shaper}.All()
+	}
+
+	lines := tl.wrapParagraph(glyphs, paragraph, maxWidth, tabWidth, &tl.spaceGlyph)
+	if len(paragraph) > 0 && paragraph[len(paragraph)-1] == '\n' && len(lines) > 0 && !isLastParagrah {
 		lines = lines[:len(lines)-1]
 	}
 
-	tl.Lines = append(tl.Lines, lines...)
+	return lines
+}
+
+// Relayout re-shapes the paragraphs overlapping the rune range [start, end),
+// keeping the lines of the other paragraphs. It is used when the font runs of
+// some paragraphs changed while the text and the layout parameters did not,
+// so the positions are rebuilt without shaping the whole document again.
+func (tl *TextLayout) Relayout(shaper *text.Shaper, start, end int, tabWidth int, wrapLine bool) layout.Dimensions {
+	if shaper == nil || len(tl.Paragraphs) == 0 || len(tl.Lines) == 0 {
+		return tl.Layout(shaper, &tl.params, tabWidth, wrapLine)
+	}
+
+	first := sort.Search(len(tl.Paragraphs), func(i int) bool {
+		p := tl.Paragraphs[i]
+		return p.RuneOff+p.Runes > start
+	})
+	last := sort.Search(len(tl.Paragraphs), func(i int) bool {
+		p := tl.Paragraphs[i]
+		return p.RuneOff+p.Runes >= end
+	})
+	first = min(first, len(tl.Paragraphs)-1)
+	last = max(min(last, len(tl.Paragraphs)-1), first)
+
+	startRune := tl.Paragraphs[first].RuneOff
+	endRune := tl.Paragraphs[last].RuneOff + tl.Paragraphs[last].Runes
+	lineStart := sort.Search(len(tl.Lines), func(i int) bool {
+		return tl.Lines[i].RuneOff >= startRune
+	})
+	lineEnd := lineStart
+	for lineEnd < len(tl.Lines) && tl.Lines[lineEnd].RuneOff < endRune {
+		lineEnd++
+	}
+	isLast := last == len(tl.Paragraphs)-1
+	if isLast {
+		lineEnd = len(tl.Lines)
+	}
+
+	buf := make([]byte, tl.src.RuneOffset(endRune)-tl.src.RuneOffset(startRune))
+	n, _ := tl.src.ReadAt(buf, int64(tl.src.RuneOffset(startRune)))
+	tl.spaceGlyph, _ = tl.shapeRune(shaper, tl.params, '\u0020')
+
+	var lines []Line
+	runeOff := startRune
+	rest := buf[:n]
+	for p := first; p <= last; p++ {
+		next := rest
+		if idx := bytes.IndexByte(rest, '\n'); idx >= 0 {
+			next = rest[:idx+1]
+		}
+		rest = rest[len(next):]
+		paragraph := []rune(string(next))
+
+		shaped := tl.shapeParagraph(shaper, paragraph, runeOff, isLast && p == last, tabWidth, wrapLine)
+		for i := range shaped {
+			alignOff := tl.params.Alignment.Align(tl.params.Locale.Direction, shaped[i].Width, tl.params.MaxWidth)
+			shaped[i].recompute(alignOff, runeOff)
+			runeOff += shaped[i].Runes
+		}
+		lines = append(lines, shaped...)
+	}
+
+	tl.Lines = slices.Replace(tl.Lines, lineStart, lineEnd, lines...)
+	tl.calculateYOffsets()
+
+	tl.Positions = tl.Positions[:0]
+	tl.Paragraphs = tl.Paragraphs[:0]
+	tl.bounds = image.Rectangle{}
+	tl.baseline = 0
+	for idx, line := range tl.Lines {
+		tl.indexGlyphs(idx, line)
+		tl.updateBounds(line)
+	}
+	tl.trackLines(tl.Lines)
+
+	dims := layout.Dimensions{Size: tl.bounds.Size()}
+	dims.Baseline = dims.Size.Y - tl.baseline
+	return dims
 }
 
-func (tl *TextLayout) wrapParagraph(glyphs glyphIter, paragraph []rune, maxWidth int, tabWidth int, spaceGlyph *text.Glyph) []Line {
-	return tl.wrapper.WrapParagraph(glyphs.All(), paragraph, maxWidth, tabWidth, spaceGlyph)
+func (tl *TextLayout) wrapParagraph(glyphs iter.Seq[text.Glyph], paragraph []rune, maxWidth int, tabWidth int, spaceGlyph *text.Glyph) []Line {
+	return tl.wrapper.WrapParagraph(glyphs, paragraph, maxWidth, tabWidth, spaceGlyph)
 }
 
 func (tl *TextLayout) fakeLayout() {
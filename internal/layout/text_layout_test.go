@@ -3,8 +3,11 @@ package layout
 import (
 	"testing"
 
+	"gioui.org/font"
+	"gioui.org/font/gofont"
 	"gioui.org/text"
 	"github.com/oligo/gvcode/internal/buffer"
+	"golang.org/x/image/math/fixed"
 )
 
 func BenchmarkLayout(b *testing.B) {
@@ -20,3 +23,172 @@ func BenchmarkLayout(b *testing.B) {
 
 }
 
+func TestWrapColumn(t *testing.T) {
+	buf := buffer.NewTextSource()
+	buf.SetText([]byte("a fox jumps over the lazy dog"))
+	shaper := text.NewShaper()
+	params := &text.Parameters{PxPerEm: fixed.I(14), MaxWidth: 1000}
+
+	layouter := NewTextLayout(buf)
+	layouter.Layout(shaper, params, 4, true)
+	if len(layouter.Lines) != 1 {
+		t.Fatalf("expected 1 line when wrapping at viewport, got %d", len(layouter.Lines))
+	}
+
+	layouter.WrapPolicy = WrapColumn
+	layouter.WrapColumn = 10
+	layouter.Layout(shaper, params, 4, true)
+	if len(layouter.Lines) < 3 {
+		t.Fatalf("expected at least 3 lines when wrapping at column 10, got %d", len(layouter.Lines))
+	}
+	maxWidth := layouter.spaceGlyph.Advance.Mul(fixed.I(10))
+	for _, line := range layouter.Lines {
+		if line.Width > maxWidth {
+			t.Errorf("line exceeds the wrap column: %s", line)
+		}
+	}
+
+	// the viewport is narrower than the column.
+	layouter.WrapPolicy = WrapBounded
+	layouter.WrapColumn = 100
+	params.MaxWidth = layouter.spaceGlyph.Advance.Mul(fixed.I(10)).Ceil()
+	layouter.Layout(shaper, params, 4, true)
+	if len(layouter.Lines) < 3 {
+		t.Fatalf("expected at least 3 lines when bounded by the viewport, got %d", len(layouter.Lines))
+	}
+}
+
+func TestExpandControlChars(t *testing.T) {
+	buf := buffer.NewTextSource()
+	buf.SetText([]byte("a\x1bb"))
+	shaper := text.NewShaper()
+	params := &text.Parameters{PxPerEm: fixed.I(14), MaxWidth: 1000}
+
+	layouter := NewTextLayout(buf)
+	layouter.ExpandControlChars = true
+	layouter.Layout(shaper, params, 4, false)
+	if len(layouter.Lines) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(layouter.Lines))
+	}
+
+	var escGlyph *text.Glyph
+	runeOff := 0
+	for _, gl := range layouter.Lines[0].Glyphs {
+		if runeOff == 1 {
+			escGlyph = gl
+		}
+		runeOff += int(gl.Runes)
+	}
+	if escGlyph == nil {
+		t.Fatal("glyph of the control character not found")
+	}
+
+	want := layouter.spaceGlyph.Advance.Mul(fixed.I(ControlCharColumns))
+	if escGlyph.Advance != want {
+		t.Errorf("expected the control character to advance %v, got %v", want, escGlyph.Advance)
+	}
+}
+
+type staticFontRuns []FontRun
+
+func (r staticFontRuns) FontRuns(start, end int) []FontRun {
+	return r
+}
+
+func TestLayoutFontRuns(t *testing.T) {
+	buf := buffer.NewTextSource()
+	buf.SetText([]byte("mmmm mmmm\nmmmm"))
+	shaper := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+	params := &text.Parameters{PxPerEm: fixed.I(14), MaxWidth: 1000}
+
+	layouter := NewTextLayout(buf)
+	layouter.Layout(shaper, params, 4, false)
+	regularWidth := layouter.Lines[0].Width
+	positions := len(layouter.Positions)
+
+	layouter.FontRuns = staticFontRuns{{Start: 0, End: 4, Weight: font.Bold}}
+	layouter.Layout(shaper, params, 4, false)
+	if len(layouter.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(layouter.Lines))
+	}
+	if layouter.Lines[0].Runes != 10 || layouter.Lines[1].RuneOff != 10 {
+		t.Errorf("unexpected rune ranges: %s, %s", layouter.Lines[0], layouter.Lines[1])
+	}
+	if layouter.Lines[0].Width <= regularWidth {
+		t.Errorf("expected bold text to be wider: regular %v, styled %v", regularWidth, layouter.Lines[0].Width)
+	}
+	if len(layouter.Positions) != positions {
+		t.Errorf("expected %d caret positions, got %d", positions, len(layouter.Positions))
+	}
+
+	// caret positions follow the advances of the restyled glyphs.
+	pos, _ := layouter.ClosestToRune(5)
+	if want := advanceOfGlyphs(layouter.Lines[0].GetGlyphs(0, 5)); pos.X != want {
+		t.Errorf("expected caret at %v, got %v", want, pos.X)
+	}
+}
+
+func TestRelayoutFontRuns(t *testing.T) {
+	shaper := text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection()))
+
+	cases := []struct {
+		name       string
+		input      string
+		start, end int
+	}{
+		{name: "first paragraph", input: "mmmm mmmm mmmm\nmmmm\nmm", start: 0, end: 14},
+		{name: "middle paragraph", input: "mm\nmmmm mmmm mmmm\nmm", start: 3, end: 17},
+		{name: "trailing newline", input: "mm\nmmmm mmmm mmmm\n", start: 3, end: 18},
+		{name: "empty document", input: "", start: 0, end: 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := buffer.NewTextSource()
+			buf.SetText([]byte(tc.input))
+			// narrow enough to wrap the bold paragraphs onto more lines.
+			params := &text.Parameters{PxPerEm: fixed.I(14), MaxWidth: 150}
+
+			layouter := NewTextLayout(buf)
+			layouter.Layout(shaper, params, 4, true)
+			lineCount := len(layouter.Lines)
+			layouter.FontRuns = staticFontRuns{{Start: tc.start, End: tc.end, Weight: font.Bold}}
+			dims := layouter.Relayout(shaper, tc.start, tc.end, 4, true)
+
+			want := NewTextLayout(buf)
+			want.FontRuns = layouter.FontRuns
+			wantDims := want.Layout(shaper, params, 4, true)
+
+			if dims != wantDims {
+				t.Errorf("expected dimensions %v, got %v", wantDims, dims)
+			}
+			if len(layouter.Lines) != len(want.Lines) {
+				t.Fatalf("expected %d lines, got %d", len(want.Lines), len(layouter.Lines))
+			}
+			if tc.end > tc.start && len(layouter.Lines) == lineCount {
+				t.Errorf("expected the bold paragraph to wrap onto more lines than %d", lineCount)
+			}
+			for i, line := range layouter.Lines {
+				if line.String() != want.Lines[i].String() {
+					t.Errorf("line %d: expected %s, got %s", i, want.Lines[i], line)
+				}
+			}
+			if len(layouter.Positions) != len(want.Positions) {
+				t.Fatalf("expected %d positions, got %d", len(want.Positions), len(layouter.Positions))
+			}
+			for i, pos := range layouter.Positions {
+				if pos != want.Positions[i] {
+					t.Errorf("position %d: expected %v, got %v", i, want.Positions[i], pos)
+				}
+			}
+			if len(layouter.Paragraphs) != len(want.Paragraphs) {
+				t.Fatalf("expected %d paragraphs, got %d", len(want.Paragraphs), len(layouter.Paragraphs))
+			}
+			for i, p := range layouter.Paragraphs {
+				if p != want.Paragraphs[i] {
+					t.Errorf("paragraph %d: expected %v, got %v", i, want.Paragraphs[i], p)
+				}
+			}
+		})
+	}
+}
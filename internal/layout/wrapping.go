@@ -2,6 +2,7 @@ package layout
 
 import (
 	"iter"
+	"unicode"
 
 	"gioui.org/text"
 	"github.com/go-text/typesetting/segmenter"
@@ -196,6 +197,9 @@ func advanceOfGlyphs(glyphs []text.Glyph) fixed.Int26_6 {
 // algorithm. Unlike the normal line breaking routine, it expands tab characters
 // to the next tabstop before wrapping.
 type lineWrapper struct {
+	// expandControls controls whether non-printing control characters are
+	// expanded to make room for a visible label.
+	expandControls  bool
 	seg             segmenter.Segmenter
 	breaker         *breaker
 	maxWidth        int
@@ -323,10 +327,12 @@ func (w *lineWrapper) readToNextBreak(breakAtIdx breakOption, paragraph []rune)
 
 		if gl.Flags&text.FlagClusterBreak != 0 {
 			//log.Println("rune: ", string(paragraph[w.glyphBuf.offset-1]), gl.Flags&text.FlagParagraphStart != 0)
-			isTab := paragraph[w.glyphBuf.offset-1] == '\t'
-			if isTab {
+			r := paragraph[w.glyphBuf.offset-1]
+			if r == '\t' {
 				// the rune is a tab, expand it before line wrapping.
 				w.expandTabGlyph(w.currentLine.Width+advance, &gl)
+			} else if w.expandControls && gl.Runes == 1 && IsControlChar(r) {
+				w.expandControlGlyph(&gl)
 			}
 		}
 
@@ -349,3 +355,28 @@ func (w *lineWrapper) expandTabGlyph(lineWidth fixed.Int26_6, gl *text.Glyph) {
 	gl.Ascent = w.spaceGlyph.Ascent
 	gl.Descent = w.spaceGlyph.Descent
 }
+
+// expandControlGlyph expands a non-printing control character to a blank glyph
+// that is wide enough to hold its code point label.
+func (w *lineWrapper) expandControlGlyph(gl *text.Glyph) {
+	gl.Advance = w.spaceGlyph.Advance.Mul(fixed.I(ControlCharColumns))
+	gl.Offset = fixed.Point26_6{}
+	gl.ID = w.spaceGlyph.ID
+	gl.Ascent = w.spaceGlyph.Ascent
+	gl.Descent = w.spaceGlyph.Descent
+}
+
+// ControlCharColumns is the number of columns a control character occupies
+// when control characters are expanded. The columns hold a label like U+001B.
+const ControlCharColumns = 6
+
+// IsControlChar reports whether r is a non-printing control character that
+// has no visual representation of its own. Tabs and line breaks are not
+// treated as control characters.
+func IsControlChar(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return false
+	}
+	return unicode.IsControl(r)
+}
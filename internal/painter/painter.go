@@ -29,6 +29,8 @@ type TextPainter struct {
 	// runBuffer buffers runs of a line. This is passed down to the splitter to
 	// decrease allocations. It works as we are rendering line by line.
 	runBuffer []RenderRun
+	// markerBuffer buffers the whitespace markers of a run.
+	markerBuffer []whitespaceMarker
 	// line height calculated when layouting the document.
 	lineHeight fixed.Int26_6
 }
@@ -43,10 +45,17 @@ func (tp *TextPainter) SetLineHeight(lineHeight fixed.Int26_6) {
 }
 
 // Paint paints text and various styles originated from syntax hignlighting or decorations.
+// If whitespace is not nil, the whitespace and control characters are marked while
+// painting the glyphs.
 func (tp *TextPainter) Paint(gtx layout.Context, shaper *text.Shaper, lines []lt.Line, defaultColor op.CallOp,
-	syntaxTokens LineSplitter, decorations LineSplitter) {
+	syntaxTokens LineSplitter, decorations LineSplitter, whitespace *WhitespaceStyle) {
 	m := op.Record(gtx.Ops)
 	viewport := tp.viewport
+	if whitespace != nil && whitespace.SelStart > whitespace.SelEnd {
+		style := *whitespace
+		style.SelStart, style.SelEnd = style.SelEnd, style.SelStart
+		whitespace = &style
+	}
 
 	for _, line := range lines {
 		if line.Descent.Ceil()+line.YOff < tp.viewport.Min.Y {
@@ -66,7 +75,7 @@ func (tp *TextPainter) Paint(gtx layout.Context, shaper *text.Shaper, lines []lt
 		}.Sub(layout.FPt(tp.viewport.Min))
 
 		// draw text with syntax token styles first.
-		tp.paintText(gtx, shaper, lineOff, line, defaultColor, syntaxTokens)
+		tp.paintText(gtx, shaper, lineOff, line, defaultColor, syntaxTokens, whitespace)
 		// And then draw decorations.
 		tp.paintDecorations(gtx, shaper, lineOff, line, defaultColor, decorations)
 	}
@@ -80,7 +89,7 @@ func (tp *TextPainter) Paint(gtx layout.Context, shaper *text.Shaper, lines []lt
 }
 
 func (tp *TextPainter) paintText(gtx layout.Context, shaper *text.Shaper, lineOff f32.Point, line lt.Line,
-	defaultMaterial op.CallOp, syntaxTokens LineSplitter) {
+	defaultMaterial op.CallOp, syntaxTokens LineSplitter, whitespace *WhitespaceStyle) {
 	// split the line into runs.
 	if !isNil(syntaxTokens) {
 		syntaxTokens.Split(line, &tp.runBuffer)
@@ -92,7 +101,7 @@ func (tp *TextPainter) paintText(gtx layout.Context, shaper *text.Shaper, lineOf
 		})
 	}
 
-	tp.paintLine(gtx, shaper, lineOff, tp.runBuffer, defaultMaterial, false)
+	tp.paintLine(gtx, shaper, lineOff, tp.runBuffer, defaultMaterial, false, newLineWhitespace(line, whitespace))
 }
 
 func (tp *TextPainter) paintDecorations(gtx layout.Context, shaper *text.Shaper, lineOff f32.Point, line lt.Line,
@@ -101,11 +110,11 @@ func (tp *TextPainter) paintDecorations(gtx layout.Context, shaper *text.Shaper,
 		return
 	}
 	decorations.Split(line, &tp.runBuffer)
-	tp.paintLine(gtx, shaper, lineOff, tp.runBuffer, defaultMaterial, true)
+	tp.paintLine(gtx, shaper, lineOff, tp.runBuffer, defaultMaterial, true, nil)
 }
 
 func (tp *TextPainter) paintLine(gtx layout.Context, shaper *text.Shaper, lineOffset f32.Point, runs []RenderRun,
-	defaultMaterial op.CallOp, noText bool) {
+	defaultMaterial op.CallOp, noText bool, whitespace *lineWhitespace) {
 	// Let drawing begin at the offset of the entire line.
 	defer op.Affine(f32.Affine2D{}.Offset(lineOffset)).Push(gtx.Ops).Pop()
 
@@ -126,6 +135,9 @@ func (tp *TextPainter) paintLine(gtx layout.Context, shaper *text.Shaper, lineOf
 		if !noText {
 			// draw glyph
 			tp.drawText(gtx, shaper, &run, defaultMaterial)
+			if whitespace != nil {
+				tp.paintWhitespaces(gtx, shaper, &run, whitespace)
+			}
 		}
 
 		// draw underline and other styles.
@@ -0,0 +1,307 @@
+package painter
+
+import (
+	"fmt"
+	"image"
+	"unicode"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	lt "github.com/oligo/gvcode/internal/layout"
+	"golang.org/x/image/math/fixed"
+)
+
+// WhitespaceMode controls which whitespace characters are rendered visibly.
+type WhitespaceMode uint8
+
+const (
+	// WhitespaceNone renders no whitespace.
+	WhitespaceNone WhitespaceMode = iota
+	// WhitespaceBoundary renders all whitespace except single spaces between
+	// words.
+	WhitespaceBoundary
+	// WhitespaceSelection renders whitespace inside the selection only.
+	WhitespaceSelection
+	// WhitespaceTrailing renders whitespace at the end of lines only.
+	WhitespaceTrailing
+	// WhitespaceAll renders all whitespace, including line endings.
+	WhitespaceAll
+)
+
+// RuneReader reads a rune at a rune offset of the document.
+type RuneReader interface {
+	ReadRuneAt(runeOff int) (rune, error)
+}
+
+// WhitespaceStyle configures how whitespace and control characters are painted.
+type WhitespaceStyle struct {
+	Mode WhitespaceMode
+	// Src is used to read the runes represented by the glyphs.
+	Src RuneReader
+	// SelStart and SelEnd is the rune range of the selection, used by the
+	// WhitespaceSelection mode.
+	SelStart, SelEnd int
+	// Params is the text parameters used to shape the control character labels.
+	Params text.Parameters
+	// SpaceAdvance is the advance of the space glyph, used to size markers
+	// of zero-width glyphs like line breaks.
+	SpaceAdvance fixed.Int26_6
+	// SpaceGlyph is the ID of the space glyph, which is shared by the expanded
+	// tabs and control characters.
+	SpaceGlyph text.GlyphID
+	// Color is the material used to paint the markers.
+	Color op.CallOp
+}
+
+// lineWhitespace tracks the whitespace painting while iterating through the
+// glyphs of a line.
+type lineWhitespace struct {
+	style *WhitespaceStyle
+	// runeOff is the rune offset of the next glyph.
+	runeOff int
+	// trailingStart is the rune offset where the trailing whitespace of the
+	// line begins, used by the WhitespaceTrailing mode.
+	trailingStart int
+}
+
+func newLineWhitespace(line lt.Line, style *WhitespaceStyle) *lineWhitespace {
+	if style == nil || style.Mode == WhitespaceNone || style.Src == nil {
+		return nil
+	}
+
+	ws := &lineWhitespace{style: style, runeOff: line.RuneOff, trailingStart: -1}
+	if style.Mode == WhitespaceTrailing {
+		ws.trailingStart = trailingStart(line, style.Src)
+	}
+	return ws
+}
+
+// whitespaceMarker is the marker of a whitespace or control character of a run.
+type whitespaceMarker struct {
+	r       rune
+	runeOff int
+	// x is the offset of the marker relative to the start of the run, and
+	// width is the width of the marker.
+	x, width float32
+	glyph    *text.Glyph
+}
+
+// appendWhitespaces appends the markers of the whitespace glyphs of the run to
+// markers, according to the whitespace mode. Only the glyphs without ink, the
+// space glyph shared by the expanded tabs and control characters, and the line
+// breaks are read from the source.
+func (tp *TextPainter) appendWhitespaces(markers []whitespaceMarker, run *RenderRun, ws *lineWhitespace) []whitespaceMarker {
+	style := ws.style
+	advance := fixed.I(0)
+	for i := range run.Glyphs {
+		gl := &run.Glyphs[i]
+		runeOff := ws.runeOff
+		ws.runeOff += int(gl.Runes)
+		x := fixedToFloat(advance)
+		advance += gl.Advance
+
+		if gl.Runes != 1 {
+			continue
+		}
+		if gl.ID != style.SpaceGlyph && gl.Flags&text.FlagParagraphBreak == 0 && !gl.Bounds.Empty() {
+			continue
+		}
+
+		r, err := style.Src.ReadRuneAt(runeOff)
+		if err != nil {
+			continue
+		}
+		marker := whitespaceMarker{r: r, runeOff: runeOff, x: x, width: fixedToFloat(gl.Advance), glyph: gl}
+		switch {
+		case isBlank(r) || r == '\t':
+			if !tp.shouldPaintWhitespace(runeOff, ws.trailingStart, style) {
+				continue
+			}
+		case r == '\n':
+			if style.Mode != WhitespaceAll &&
+				(style.Mode != WhitespaceSelection || runeOff < style.SelStart || runeOff >= style.SelEnd) {
+				continue
+			}
+			// the line break glyph has no advance.
+			marker.width = fixedToFloat(style.SpaceAdvance)
+		case !lt.IsControlChar(r):
+			continue
+		}
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// paintWhitespaces paints visible markers for the whitespace glyphs of the run:
+// middle dots for spaces, arrows for tabs and line ending markers. Non-printing
+// control characters are painted as boxes holding the code point label. The
+// layout is expected to have expanded the control characters to make room for
+// the label. The markers are drawn using the glyph advances, so tab arrows fill
+// the whole tab stop.
+func (tp *TextPainter) paintWhitespaces(gtx layout.Context, shaper *text.Shaper, run *RenderRun, ws *lineWhitespace) {
+	tp.markerBuffer = tp.appendWhitespaces(tp.markerBuffer[:0], run, ws)
+	for _, m := range tp.markerBuffer {
+		switch {
+		case isBlank(m.r):
+			tp.drawSpaceMarker(gtx, m.x, m.width, m.glyph, ws.style.Color)
+		case m.r == '\t':
+			tp.drawTabMarker(gtx, m.x, m.width, m.glyph, ws.style.Color)
+		case m.r == '\n':
+			tp.drawEOLMarker(gtx, m.x, m.width, m.glyph, ws.style.Color)
+		default:
+			tp.drawControlChar(gtx, shaper, m.x, m.width, m.r, m.glyph, ws.style)
+		}
+	}
+}
+
+// shouldPaintWhitespace checks the whitespace at runeOff against the whitespace mode.
+func (tp *TextPainter) shouldPaintWhitespace(runeOff int, trailingStart int, style *WhitespaceStyle) bool {
+	switch style.Mode {
+	case WhitespaceAll:
+		return true
+	case WhitespaceSelection:
+		return runeOff >= style.SelStart && runeOff < style.SelEnd
+	case WhitespaceTrailing:
+		return trailingStart >= 0 && runeOff >= trailingStart
+	case WhitespaceBoundary:
+		// A single space between two non-space characters is not rendered.
+		r, _ := style.Src.ReadRuneAt(runeOff)
+		if r != ' ' {
+			return true
+		}
+		prev, err1 := style.Src.ReadRuneAt(runeOff - 1)
+		next, err2 := style.Src.ReadRuneAt(runeOff + 1)
+		if runeOff == 0 || err1 != nil || err2 != nil {
+			return true
+		}
+		return unicode.IsSpace(prev) || unicode.IsSpace(next)
+	}
+
+	return false
+}
+
+// trailingStart returns the rune offset where the trailing whitespace of the
+// visual line begins.
+func trailingStart(line lt.Line, src RuneReader) int {
+	lastGlyph := line.Glyphs[len(line.Glyphs)-1]
+	end := line.RuneOff + line.Runes
+	if lastGlyph.Flags&text.FlagParagraphBreak != 0 {
+		end--
+	}
+
+	start := end
+	for start > line.RuneOff {
+		r, err := src.ReadRuneAt(start - 1)
+		if err != nil || (!isBlank(r) && r != '\t') {
+			break
+		}
+		start--
+	}
+
+	return start
+}
+
+// isBlank reports whether r is a space character that is painted as a middle dot.
+func isBlank(r rune) bool {
+	return r == ' ' || r == '\u00a0' || r == '\u3000'
+}
+
+// markerY returns the vertical center of lower case letters relative to the baseline.
+func markerY(gl *text.Glyph) float32 {
+	return -fixedToFloat(gl.Ascent) * 0.35
+}
+
+func (tp *TextPainter) drawSpaceMarker(gtx layout.Context, x, width float32, gl *text.Glyph, material op.CallOp) {
+	size := max(float32(gtx.Dp(unit.Dp(2))), fixedToFloat(gl.Ascent)/8)
+	center := f32.Pt(x+width/2, markerY(gl))
+	rect := image.Rectangle{
+		Min: center.Sub(f32.Pt(size/2, size/2)).Round(),
+		Max: center.Add(f32.Pt(size/2, size/2)).Round(),
+	}
+
+	defer clip.Ellipse(rect).Push(gtx.Ops).Pop()
+	material.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+}
+
+func (tp *TextPainter) drawTabMarker(gtx layout.Context, x, width float32, gl *text.Glyph, material op.CallOp) {
+	padding := min(width/8, float32(gtx.Dp(unit.Dp(2))))
+	head := min(width/3, fixedToFloat(gl.Ascent)/4)
+	y := markerY(gl)
+
+	path := clip.Path{}
+	path.Begin(gtx.Ops)
+	path.MoveTo(f32.Pt(x+padding, y))
+	path.LineTo(f32.Pt(x+width-padding, y))
+	path.MoveTo(f32.Pt(x+width-padding-head, y-head))
+	path.LineTo(f32.Pt(x+width-padding, y))
+	path.LineTo(f32.Pt(x+width-padding-head, y+head))
+
+	tp.drawStroke(gtx, path.End(), material)
+}
+
+func (tp *TextPainter) drawEOLMarker(gtx layout.Context, x, width float32, gl *text.Glyph, material op.CallOp) {
+	padding := width / 6
+	head := width / 4
+	top := -fixedToFloat(gl.Ascent) * 0.6
+	y := markerY(gl)
+
+	path := clip.Path{}
+	path.Begin(gtx.Ops)
+	path.MoveTo(f32.Pt(x+width-padding, top))
+	path.LineTo(f32.Pt(x+width-padding, y))
+	path.LineTo(f32.Pt(x+padding, y))
+	path.MoveTo(f32.Pt(x+padding+head, y-head))
+	path.LineTo(f32.Pt(x+padding, y))
+	path.LineTo(f32.Pt(x+padding+head, y+head))
+
+	tp.drawStroke(gtx, path.End(), material)
+}
+
+// drawControlChar draws a box with the code point label of r, like <U+001B>.
+func (tp *TextPainter) drawControlChar(gtx layout.Context, shaper *text.Shaper, x, width float32, r rune,
+	gl *text.Glyph, style *WhitespaceStyle) {
+	inset := float32(gtx.Dp(unit.Dp(1)))
+	box := image.Rectangle{
+		Min: f32.Pt(x+inset, -fixedToFloat(gl.Ascent)+inset).Round(),
+		Max: f32.Pt(x+width-inset, fixedToFloat(gl.Descent)-inset).Round(),
+	}
+	radius := gtx.Dp(unit.Dp(2))
+	tp.drawStroke(gtx, clip.UniformRRect(box, radius).Path(gtx.Ops), style.Color)
+
+	params := style.Params
+	params.PxPerEm = params.PxPerEm.Mul(fixed.I(7)) / 10
+	params.MaxWidth = 1e6
+	params.MinWidth = 0
+	params.Alignment = text.Start
+	shaper.LayoutString(params, fmt.Sprintf("U+%04X", r))
+
+	var glyphs []text.Glyph
+	labelWidth := fixed.I(0)
+	for {
+		g, ok := shaper.NextGlyph()
+		if !ok {
+			break
+		}
+		labelWidth += g.Advance
+		glyphs = append(glyphs, g)
+	}
+	if len(glyphs) == 0 {
+		return
+	}
+
+	// center the label in the box. The shaped path is relative to the dot of
+	// the first glyph.
+	baseline := (fixedToFloat(gl.Descent-gl.Ascent) + fixedToFloat(glyphs[0].Ascent-glyphs[0].Descent)) / 2
+	offset := f32.Pt(x+(width-fixedToFloat(labelWidth))/2, baseline)
+	defer op.Affine(f32.Affine2D{}.Offset(offset)).Push(gtx.Ops).Pop()
+	outline := clip.Outline{Path: shaper.Shape(glyphs)}.Op().Push(gtx.Ops)
+	style.Color.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	outline.Pop()
+}
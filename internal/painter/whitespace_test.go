@@ -0,0 +1,92 @@
+package painter
+
+import (
+	"slices"
+	"testing"
+
+	"gioui.org/text"
+	"github.com/oligo/gvcode/internal/buffer"
+	lt "github.com/oligo/gvcode/internal/layout"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestWhitespaceMarkers(t *testing.T) {
+	// a0 \t1 b2 3 c4 5 6 d7 \x1b8 9 10 \n11 x12
+	src := buffer.NewTextSource()
+	src.SetText([]byte("a\tb c  d\x1b  \nx"))
+	shaper := text.NewShaper()
+	layouter := lt.NewTextLayout(src)
+	layouter.ExpandControlChars = true
+	params := &text.Parameters{PxPerEm: fixed.I(14), MaxWidth: 1000}
+	layouter.Layout(shaper, params, 4, false)
+	line := layouter.Lines[0]
+
+	testcases := []struct {
+		mode             WhitespaceMode
+		selStart, selEnd int
+		want             []int
+	}{
+		{mode: WhitespaceAll, want: []int{1, 3, 5, 6, 8, 9, 10, 11}},
+		// the single space between words is skipped.
+		{mode: WhitespaceBoundary, want: []int{1, 5, 6, 8, 9, 10}},
+		// control characters are always marked.
+		{mode: WhitespaceTrailing, want: []int{8, 9, 10}},
+		{mode: WhitespaceSelection, selStart: 2, selEnd: 6, want: []int{3, 5, 8}},
+		{mode: WhitespaceSelection, selStart: 10, selEnd: 12, want: []int{8, 10, 11}},
+	}
+
+	for _, tc := range testcases {
+		style := &WhitespaceStyle{
+			Mode:         tc.mode,
+			Src:          src,
+			SelStart:     tc.selStart,
+			SelEnd:       tc.selEnd,
+			Params:       *params,
+			SpaceAdvance: layouter.SpaceAdvance(),
+			SpaceGlyph:   layouter.SpaceGlyphID(),
+		}
+		run := RenderRun{Glyphs: line.GetGlyphs(0, len(line.Glyphs))}
+		tp := &TextPainter{}
+		markers := tp.appendWhitespaces(nil, &run, newLineWhitespace(line, style))
+
+		var offsets []int
+		for _, m := range markers {
+			offsets = append(offsets, m.runeOff)
+
+			// the markers are placed at the glyphs of the runes.
+			pos, _ := layouter.ClosestToRune(m.runeOff)
+			if want := fixedToFloat(pos.X - line.XOff); m.x != want {
+				t.Errorf("mode %d: want the marker of %d at %v, got %v", tc.mode, m.runeOff, want, m.x)
+			}
+			if m.width <= 0 {
+				t.Errorf("mode %d: want the marker of %d to have a width, got %v", tc.mode, m.runeOff, m.width)
+			}
+		}
+		if !slices.Equal(offsets, tc.want) {
+			t.Errorf("mode %d: want markers at %v, got %v", tc.mode, tc.want, offsets)
+		}
+	}
+
+	// the tab marker fills the tab stop, and the control character marker
+	// fills the label columns.
+	style := &WhitespaceStyle{Mode: WhitespaceAll, Src: src, SpaceAdvance: layouter.SpaceAdvance(), SpaceGlyph: layouter.SpaceGlyphID()}
+	run := RenderRun{Glyphs: line.GetGlyphs(0, len(line.Glyphs))}
+	markers := (&TextPainter{}).appendWhitespaces(nil, &run, newLineWhitespace(line, style))
+	space := fixedToFloat(layouter.SpaceAdvance())
+	for _, m := range markers {
+		switch m.r {
+		case '\t':
+			if want := fixedToFloat(layouter.SpaceAdvance().Mul(fixed.I(4))) - m.x; m.width != want {
+				t.Errorf("want the tab marker to end at the tab stop, got width %v, want %v", m.width, want)
+			}
+		case '\x1b':
+			if want := space * lt.ControlCharColumns; m.width != want {
+				t.Errorf("want the control marker %v wide, got %v", want, m.width)
+			}
+		case ' ', '\n':
+			if m.width != space {
+				t.Errorf("want the marker of %q %v wide, got %v", m.r, space, m.width)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package gvcode
+
+import (
+	"github.com/oligo/gvcode/language"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+// SetLanguage applies the configuration of a language to the editor at once.
+// The bracket pairs and the auto-closing quote pairs of the configuration
+// replace the ones set with WithBracketPairs and WithQuotePairs, its word
+// pattern is used to find words, and its indentation rules decide the
+// indentation of new and typed lines. A nil config restores the pairs set with
+// the options, or the built-in ones.
+func (e *Editor) SetLanguage(cfg *language.Config) {
+	e.initBuffer()
+	e.lang = cfg
+	clear(e.autoInsertions)
+	e.bracketChecker.MarkDirty()
+
+	e.text.BracketsQuotes.Reset()
+	if e.bracketPairs != nil {
+		e.text.BracketsQuotes.SetBrackets(e.bracketPairs)
+	}
+	if e.quotePairs != nil {
+		e.text.BracketsQuotes.SetQuotes(e.quotePairs)
+	}
+	e.text.WordPattern = nil
+	e.text.IndentRules = nil
+	if cfg == nil {
+		return
+	}
+
+	if len(cfg.Brackets) > 0 {
+		e.text.BracketsQuotes.SetBrackets(cfg.BracketRunes())
+	}
+	if len(cfg.AutoClosingPairs) > 0 {
+		e.text.BracketsQuotes.SetQuotes(cfg.QuoteRunes())
+	}
+	e.text.WordPattern = cfg.WordPattern
+	e.text.IndentRules = &cfg.IndentationRules
+}
+
+// Language returns the configuration of the language of the editor, or nil if
+// there is none.
+func (e *Editor) Language() *language.Config {
+	return e.lang
+}
+
+// WithLanguage configures the language of the editor. See Editor.SetLanguage.
+func WithLanguage(cfg *language.Config) EditorOption {
+	return func(e *Editor) {
+		e.SetLanguage(cfg)
+	}
+}
+
+// canAutoClose checks the auto-closing pairs of the language to see if the
+// opening half open typed at runeOff can be closed automatically.
+func (e *Editor) canAutoClose(runeOff int, open rune) bool {
+	if e.lang == nil || len(e.lang.AutoClosingPairs) == 0 {
+		return true
+	}
+
+	pair, ok := e.lang.AutoClosingPair(string(open))
+	if !ok {
+		return false
+	}
+	for _, scope := range pair.NotIn {
+		if e.inSyntaxScope(runeOff, syntax.StyleScope(scope)) {
+			return false
+		}
+	}
+	return true
+}
+
+// inSyntaxScope reports whether the position runeOff between two runes is inside
+// a syntax token of scope. A position at the end of a line comment is inside the
+// comment.
+func (e *Editor) inSyntaxScope(runeOff int, scope syntax.StyleScope) bool {
+	if runeOff <= 0 {
+		return false
+	}
+	if prev, ok := e.text.SyntaxScopeAt(runeOff - 1); !ok || !prev.IsWithin(scope) {
+		return false
+	}
+
+	if next, ok := e.text.SyntaxScopeAt(runeOff); ok && next.IsWithin(scope) {
+		return true
+	}
+	if !scope.IsWithin(syntax.StyleScope(language.ScopeComment)) {
+		return false
+	}
+	r, err := e.text.ReadRuneAt(runeOff)
+	return runeOff >= e.text.Len() || err != nil || r == '\n'
+}
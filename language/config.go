@@ -0,0 +1,141 @@
+// Package language defines the per-language configuration of the editor, like
+// the comment tokens, the bracket pairs and the indentation rules. A
+// configuration can be loaded from a VSCode language-configuration.json file.
+package language
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// Scope is a syntax scope in which a pair is not auto-closed.
+type Scope string
+
+const (
+	// ScopeString is the scope of string literals.
+	ScopeString Scope = "string"
+	// ScopeComment is the scope of comments.
+	ScopeComment Scope = "comment"
+)
+
+// Pair is a pair of opening and closing text, like a bracket pair.
+type Pair struct {
+	Open  string
+	Close string
+}
+
+// IsRune reports whether both halves of the pair are single runes.
+func (p Pair) IsRune() bool {
+	return utf8.RuneCountInString(p.Open) == 1 && utf8.RuneCountInString(p.Close) == 1
+}
+
+// AutoClosingPair is a pair whose closing half is inserted automatically when
+// the opening half is typed.
+type AutoClosingPair struct {
+	Pair
+	// NotIn lists the scopes in which the pair is not auto-closed.
+	NotIn []Scope
+}
+
+// Comments defines the comment tokens of a language.
+type Comments struct {
+	// LineComment is the token that starts a line comment, like "//".
+	LineComment string
+	// BlockComment is the pair of tokens that enclose a block comment, like
+	// "/*" and "*/". It is empty if the language has no block comments.
+	BlockComment Pair
+}
+
+// IndentationRules defines the patterns that decide how lines are indented. A
+// nil pattern is not checked.
+type IndentationRules struct {
+	// IncreaseIndentPattern matches a line after which the next lines are
+	// indented by one more level.
+	IncreaseIndentPattern *regexp.Regexp
+	// DecreaseIndentPattern matches a line that is indented by one less level
+	// than the lines before it.
+	DecreaseIndentPattern *regexp.Regexp
+	// IndentNextLinePattern matches a line after which only the next line is
+	// indented by one more level.
+	IndentNextLinePattern *regexp.Regexp
+	// UnIndentedLinePattern matches a line whose indentation is ignored when
+	// the indentation of the next lines is decided.
+	UnIndentedLinePattern *regexp.Regexp
+}
+
+// Config is the configuration of a language.
+type Config struct {
+	// ID is the identifier of the language, like "go" or "python".
+	ID string
+	// Comments defines the comment tokens.
+	Comments Comments
+	// Brackets are the bracket pairs used for matching and indentation.
+	Brackets []Pair
+	// AutoClosingPairs are the pairs that are closed automatically.
+	AutoClosingPairs []AutoClosingPair
+	// SurroundingPairs are the pairs that surround the selection when the
+	// opening half is typed.
+	SurroundingPairs []Pair
+	// WordPattern matches the words of the language. The default word
+	// separators are used if it is nil.
+	WordPattern *regexp.Regexp
+	// IndentationRules decides how lines are indented.
+	IndentationRules IndentationRules
+}
+
+// AutoClosingPair returns the auto-closing pair opened by open.
+func (c *Config) AutoClosingPair(open string) (AutoClosingPair, bool) {
+	if c == nil {
+		return AutoClosingPair{}, false
+	}
+	for _, p := range c.AutoClosingPairs {
+		if p.Open == open {
+			return p, true
+		}
+	}
+	return AutoClosingPair{}, false
+}
+
+// SurroundingPair returns the surrounding pair opened by open.
+func (c *Config) SurroundingPair(open string) (Pair, bool) {
+	if c == nil {
+		return Pair{}, false
+	}
+	for _, p := range c.SurroundingPairs {
+		if p.Open == open {
+			return p, true
+		}
+	}
+	return Pair{}, false
+}
+
+// BracketRunes returns the bracket pairs of single runes, as a map of the
+// opening runes to the closing runes.
+func (c *Config) BracketRunes() map[rune]rune {
+	pairs := make(map[rune]rune)
+	for _, p := range c.Brackets {
+		if p.IsRune() {
+			open, _ := utf8.DecodeRuneInString(p.Open)
+			pairs[open], _ = utf8.DecodeRuneInString(p.Close)
+		}
+	}
+	return pairs
+}
+
+// QuoteRunes returns the auto-closing pairs of single runes that are not
+// brackets, as a map of the opening runes to the closing runes.
+func (c *Config) QuoteRunes() map[rune]rune {
+	brackets := c.BracketRunes()
+	pairs := make(map[rune]rune)
+	for _, p := range c.AutoClosingPairs {
+		if !p.IsRune() {
+			continue
+		}
+		open, _ := utf8.DecodeRuneInString(p.Open)
+		if _, ok := brackets[open]; ok {
+			continue
+		}
+		pairs[open], _ = utf8.DecodeRuneInString(p.Close)
+	}
+	return pairs
+}
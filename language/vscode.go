@@ -0,0 +1,264 @@
+package language
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// vscodeConfig is the layout of the VSCode language-configuration.json file.
+type vscodeConfig struct {
+	Comments *struct {
+		LineComment  string    `json:"lineComment"`
+		BlockComment *[]string `json:"blockComment"`
+	} `json:"comments"`
+	Brackets         [][]string        `json:"brackets"`
+	AutoClosingPairs []json.RawMessage `json:"autoClosingPairs"`
+	SurroundingPairs []json.RawMessage `json:"surroundingPairs"`
+	WordPattern      json.RawMessage   `json:"wordPattern"`
+	IndentationRules *struct {
+		IncreaseIndentPattern json.RawMessage `json:"increaseIndentPattern"`
+		DecreaseIndentPattern json.RawMessage `json:"decreaseIndentPattern"`
+		IndentNextLinePattern json.RawMessage `json:"indentNextLinePattern"`
+		UnIndentedLinePattern json.RawMessage `json:"unIndentedLinePattern"`
+	} `json:"indentationRules"`
+}
+
+// LoadVSCodeFile loads the configuration of the language id from a VSCode
+// language-configuration.json file.
+func LoadVSCodeFile(id, path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadVSCode(id, f)
+}
+
+// LoadVSCode loads the configuration of the language id from the content of a
+// VSCode language-configuration.json file, which may have comments and
+// trailing commas.
+//
+// The regular expressions are compiled with the Go regexp package. Patterns
+// using the features it does not support, like lookarounds, are left unset,
+// and reported by an *UnsupportedPatternError returned with the configuration.
+// Callers can ignore the error to use the rest of the configuration.
+func LoadVSCode(id string, r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw vscodeConfig
+	if err := json.Unmarshal(stripJSONC(data), &raw); err != nil {
+		return nil, fmt.Errorf("parse language configuration: %w", err)
+	}
+
+	var unsupported UnsupportedPatternError
+	pattern := func(name string, msg json.RawMessage) (*regexp.Regexp, error) {
+		re, err := parsePattern(msg)
+		var syntaxErr *syntax.Error
+		if errors.As(err, &syntaxErr) {
+			unsupported.Patterns = append(unsupported.Patterns, PatternError{Name: name, Err: syntaxErr})
+			return nil, nil
+		}
+		return re, err
+	}
+
+	cfg := &Config{ID: id}
+	if c := raw.Comments; c != nil {
+		cfg.Comments.LineComment = c.LineComment
+		if c.BlockComment != nil && len(*c.BlockComment) == 2 {
+			cfg.Comments.BlockComment = Pair{Open: (*c.BlockComment)[0], Close: (*c.BlockComment)[1]}
+		}
+	}
+
+	for _, b := range raw.Brackets {
+		if len(b) == 2 {
+			cfg.Brackets = append(cfg.Brackets, Pair{Open: b[0], Close: b[1]})
+		}
+	}
+
+	for _, msg := range raw.AutoClosingPairs {
+		p, err := parseAutoClosingPair(msg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.AutoClosingPairs = append(cfg.AutoClosingPairs, p)
+	}
+
+	for _, msg := range raw.SurroundingPairs {
+		p, err := parseAutoClosingPair(msg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.SurroundingPairs = append(cfg.SurroundingPairs, p.Pair)
+	}
+
+	if cfg.WordPattern, err = pattern("wordPattern", raw.WordPattern); err != nil {
+		return nil, err
+	}
+
+	if rules := raw.IndentationRules; rules != nil {
+		ir := &cfg.IndentationRules
+		for _, p := range []struct {
+			name string
+			dst  **regexp.Regexp
+			msg  json.RawMessage
+		}{
+			{"increaseIndentPattern", &ir.IncreaseIndentPattern, rules.IncreaseIndentPattern},
+			{"decreaseIndentPattern", &ir.DecreaseIndentPattern, rules.DecreaseIndentPattern},
+			{"indentNextLinePattern", &ir.IndentNextLinePattern, rules.IndentNextLinePattern},
+			{"unIndentedLinePattern", &ir.UnIndentedLinePattern, rules.UnIndentedLinePattern},
+		} {
+			if *p.dst, err = pattern(p.name, p.msg); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(unsupported.Patterns) > 0 {
+		return cfg, &unsupported
+	}
+	return cfg, nil
+}
+
+// UnsupportedPatternError reports the regular expressions of a language
+// configuration that the Go regexp package fails to compile, like the ones
+// using lookarounds. The rules of the patterns are left unset.
+type UnsupportedPatternError struct {
+	Patterns []PatternError
+}
+
+// PatternError is the compile error of a pattern, named after its key in the
+// configuration file, like "indentNextLinePattern".
+type PatternError struct {
+	Name string
+	Err  error
+}
+
+func (e *UnsupportedPatternError) Error() string {
+	msgs := make([]string, 0, len(e.Patterns))
+	for _, p := range e.Patterns {
+		msgs = append(msgs, p.Name+": "+p.Err.Error())
+	}
+	return "unsupported patterns: " + strings.Join(msgs, "; ")
+}
+
+// parseAutoClosingPair parses a pair in the array form ["(", ")"], or in the
+// object form {"open": "(", "close": ")", "notIn": ["string"]}.
+func parseAutoClosingPair(msg json.RawMessage) (AutoClosingPair, error) {
+	var arr []string
+	if err := json.Unmarshal(msg, &arr); err == nil {
+		if len(arr) != 2 {
+			return AutoClosingPair{}, fmt.Errorf("invalid pair: %s", msg)
+		}
+		return AutoClosingPair{Pair: Pair{Open: arr[0], Close: arr[1]}}, nil
+	}
+
+	var obj struct {
+		Open  string   `json:"open"`
+		Close string   `json:"close"`
+		NotIn []string `json:"notIn"`
+	}
+	if err := json.Unmarshal(msg, &obj); err != nil || obj.Open == "" {
+		return AutoClosingPair{}, fmt.Errorf("invalid pair: %s", msg)
+	}
+
+	p := AutoClosingPair{Pair: Pair{Open: obj.Open, Close: obj.Close}}
+	for _, s := range obj.NotIn {
+		p.NotIn = append(p.NotIn, Scope(s))
+	}
+	return p, nil
+}
+
+// parsePattern parses a regular expression in the string form, or in the
+// object form {"pattern": "...", "flags": "i"}. It returns nil if msg is
+// empty, and a *syntax.Error if the pattern is not supported.
+func parsePattern(msg json.RawMessage) (*regexp.Regexp, error) {
+	if len(msg) == 0 || string(msg) == "null" {
+		return nil, nil
+	}
+
+	var pattern, flags string
+	if err := json.Unmarshal(msg, &pattern); err != nil {
+		var obj struct {
+			Pattern string `json:"pattern"`
+			Flags   string `json:"flags"`
+		}
+		if err := json.Unmarshal(msg, &obj); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %s", msg)
+		}
+		pattern, flags = obj.Pattern, obj.Flags
+	}
+
+	var goFlags string
+	for _, f := range flags {
+		// the other JavaScript flags have no effect on matching a line.
+		switch f {
+		case 'i', 'm', 's':
+			goFlags += string(f)
+		}
+	}
+	if goFlags != "" {
+		pattern = "(?" + goFlags + ")" + pattern
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// stripJSONC removes the comments and the trailing commas of JSON with
+// comments.
+func stripJSONC(data []byte) []byte {
+	var out bytes.Buffer
+	// lastComma is the position in out of the last comma, which is removed if
+	// it is followed by a closing bracket.
+	lastComma := -1
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out.WriteByte(data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			out.WriteByte(c)
+		default:
+			if (c == ']' || c == '}') && lastComma >= 0 {
+				out.Bytes()[lastComma] = ' '
+			}
+			lastComma = -1
+			if c == ',' {
+				lastComma = out.Len()
+			}
+			inString = c == '"'
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,128 @@
+package language
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const goConfig = `{
+	// comments of Go.
+	"comments": {
+		"lineComment": "//",
+		"blockComment": ["/*", "*/"]
+	},
+	"brackets": [
+		["{", "}"],
+		["[", "]"],
+		["(", ")"],
+	],
+	/* auto closing pairs */
+	"autoClosingPairs": [
+		{ "open": "{", "close": "}" },
+		["(", ")"],
+		{ "open": "\"", "close": "\"", "notIn": ["string"] },
+		{ "open": "'", "close": "'", "notIn": ["string", "comment"] },
+	],
+	"surroundingPairs": [
+		["{", "}"],
+		["/*", "*/"],
+	],
+	"wordPattern": "(-?\\d*\\.\\d\\w*)|([^\\-\\` + "`" + `\\~\\!\\@\\#\\%\\^\\&\\*\\(\\)\\=\\+\\[\\{\\]\\}\\\\\\|\\;\\:\\'\\\"\\,\\.\\<\\>\\/\\?\\s]+)",
+	"indentationRules": {
+		"increaseIndentPattern": "^.*(\\{[^}\"'` + "`" + `]*|\\([^)\"'` + "`" + `]*|\\[[^\\]\"'` + "`" + `]*)$",
+		"decreaseIndentPattern": { "pattern": "^\\s*(\\}|\\)|\\]).*$", "flags": "i" },
+		"indentNextLinePattern": "^(?!.*;$).*\\bif\\b.*$"
+	}
+}`
+
+func TestLoadVSCode(t *testing.T) {
+	cfg, err := LoadVSCode("go", strings.NewReader(goConfig))
+	// lookaheads are not supported by Go.
+	var unsupported *UnsupportedPatternError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("expected the unsupported patterns to be reported, got %v", err)
+	}
+	if len(unsupported.Patterns) != 1 || unsupported.Patterns[0].Name != "indentNextLinePattern" {
+		t.Errorf("unexpected unsupported patterns: %v", unsupported)
+	}
+
+	if cfg.ID != "go" {
+		t.Errorf("expected ID go, got %q", cfg.ID)
+	}
+	if cfg.Comments.LineComment != "//" || cfg.Comments.BlockComment != (Pair{"/*", "*/"}) {
+		t.Errorf("unexpected comments: %+v", cfg.Comments)
+	}
+	if len(cfg.Brackets) != 3 || cfg.Brackets[2] != (Pair{"(", ")"}) {
+		t.Errorf("unexpected brackets: %+v", cfg.Brackets)
+	}
+
+	if len(cfg.AutoClosingPairs) != 4 {
+		t.Fatalf("expected 4 auto closing pairs, got %d", len(cfg.AutoClosingPairs))
+	}
+	quote, ok := cfg.AutoClosingPair("'")
+	if !ok || !slices.Equal(quote.NotIn, []Scope{ScopeString, ScopeComment}) {
+		t.Errorf("unexpected auto closing pair: %+v", quote)
+	}
+	if p, ok := cfg.SurroundingPair("/*"); !ok || p.Close != "*/" {
+		t.Errorf("unexpected surrounding pair: %+v", p)
+	}
+
+	if cfg.WordPattern == nil {
+		t.Fatal("expected the word pattern to be loaded")
+	}
+	if got := cfg.WordPattern.FindString("  foo_bar(x)"); got != "foo_bar" {
+		t.Errorf("expected the word foo_bar, got %q", got)
+	}
+
+	rules := cfg.IndentationRules
+	if rules.IncreaseIndentPattern == nil || !rules.IncreaseIndentPattern.MatchString("func main() {") {
+		t.Error("expected the increase indent pattern to match")
+	}
+	if rules.DecreaseIndentPattern == nil || !rules.DecreaseIndentPattern.MatchString("  }") {
+		t.Error("expected the decrease indent pattern to match")
+	}
+	if rules.IndentNextLinePattern != nil {
+		t.Error("expected the unsupported pattern to be ignored")
+	}
+}
+
+func TestLoadVSCodeInvalid(t *testing.T) {
+	for _, input := range []string{
+		`{"brackets": [["(", ")"]`,
+		`{"autoClosingPairs": [["("]]}`,
+		`{"autoClosingPairs": [{"close": ")"}]}`,
+	} {
+		if _, err := LoadVSCode("x", strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error for %s", input)
+		}
+	}
+}
+
+func TestRunePairs(t *testing.T) {
+	cfg := &Config{
+		Brackets: []Pair{{"(", ")"}, {"begin", "end"}},
+		AutoClosingPairs: []AutoClosingPair{
+			{Pair: Pair{"(", ")"}},
+			{Pair: Pair{"\"", "\""}},
+			{Pair: Pair{"/*", " */"}},
+		},
+	}
+
+	if got := cfg.BracketRunes(); len(got) != 1 || got['('] != ')' {
+		t.Errorf("unexpected bracket runes: %v", got)
+	}
+	if got := cfg.QuoteRunes(); len(got) != 1 || got['"'] != '"' {
+		t.Errorf("unexpected quote runes: %v", got)
+	}
+}
+
+func TestStripJSONC(t *testing.T) {
+	input := `{"a": "// not a comment, ]", /* x */ "b": [1, 2, ], // y
+}`
+	want := "{\"a\": \"// not a comment, ]\",  \"b\": [1, 2  ]  \n}"
+	if got := string(stripJSONC([]byte(input))); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,344 @@
+package gvcode
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SortLinesOptions configures how SortLines compares the lines.
+type SortLinesOptions struct {
+	// Descending sorts the lines in descending order.
+	Descending bool
+	// IgnoreCase compares the lines case-insensitively.
+	IgnoreCase bool
+	// Natural compares the runs of digits in the lines by their numeric value,
+	// so that "item2" sorts before "item10".
+	Natural bool
+}
+
+// textRange returns the text of the rune range [start, end).
+func (e *Editor) textRange(start, end int) string {
+	startOff := e.text.ByteOffset(start)
+	endOff := e.text.ByteOffset(end)
+	if endOff <= startOff {
+		return ""
+	}
+	buf := make([]byte, endOff-startOff)
+	n, _ := e.buffer.ReadAt(buf, startOff)
+	return string(buf[:n])
+}
+
+// lineBlock holds the logical lines of a range of the document, without their
+// line breaks. trailingBreak records whether the last line has a line break.
+type lineBlock struct {
+	start, end    int
+	lines         []string
+	trailingBreak bool
+}
+
+func (b *lineBlock) String() string {
+	s := strings.Join(b.lines, "\n")
+	if b.trailingBreak {
+		s += "\n"
+	}
+	return s
+}
+
+func newLineBlock(start, end int, text string) *lineBlock {
+	b := &lineBlock{start: start, end: end, trailingBreak: strings.HasSuffix(text, "\n")}
+	b.lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	return b
+}
+
+// readLineBlock reads the lines of the rune range [start, end), which is
+// expected to start and end at line boundaries.
+func (e *Editor) readLineBlock(start, end int) *lineBlock {
+	return newLineBlock(start, end, e.textRange(start, end))
+}
+
+// selectedLineBlock reads the lines covered by the selection, or the line of the
+// caret if there is no selection.
+func (e *Editor) selectedLineBlock() *lineBlock {
+	var start, end int
+	e.scratch, start, end = e.text.SelectedLineText(e.scratch)
+	return newLineBlock(start, end, string(e.scratch))
+}
+
+// replaceLineBlock writes the lines of the block back to the document as a
+// single undo step.
+func (e *Editor) replaceLineBlock(b *lineBlock) {
+	e.replace(b.start, b.end, b.String())
+}
+
+// shiftSelection moves the selection by delta runes, keeping its direction.
+func (e *Editor) shiftSelection(start, end, delta int) {
+	e.SetCaret(start+delta, end+delta)
+}
+
+// MoveLines moves the lines covered by the selection up by one line if
+// direction is negative, or down by one line otherwise. The selection moves with
+// the lines. It reports whether the lines are moved.
+func (e *Editor) MoveLines(direction int) bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	selStart, selEnd := e.text.Selection()
+	start, end := e.text.SelectedLineRange()
+	firstLine, _ := e.text.FindParagraph(start)
+
+	var b *lineBlock
+	var delta int
+	if direction < 0 {
+		if firstLine == 0 {
+			return false
+		}
+		prevStart, _ := e.text.RangeOfLines(firstLine-1, 1, false)
+		b = e.readLineBlock(prevStart, end)
+		// move the previous line after the selected lines.
+		prev := b.lines[0]
+		b.lines = append(b.lines[1:], prev)
+		delta = -(utf8.RuneCountInString(prev) + 1)
+	} else {
+		lastLine, _ := e.text.FindParagraph(max(start, end-1))
+		nextStart, nextEnd := e.text.RangeOfLines(lastLine+1, 1, false)
+		if lastLine+1 >= e.text.Paragraphs() || nextStart == nextEnd {
+			return false
+		}
+		b = e.readLineBlock(start, nextEnd)
+		// move the next line before the selected lines.
+		next := b.lines[len(b.lines)-1]
+		b.lines = append([]string{next}, b.lines[:len(b.lines)-1]...)
+		delta = utf8.RuneCountInString(next) + 1
+	}
+
+	e.replaceLineBlock(b)
+	e.shiftSelection(selStart, selEnd, delta)
+	return true
+}
+
+// DuplicateLines inserts a copy of the lines covered by the selection below
+// them, and moves the selection to the copy. If above is set, the copy is
+// inserted above the lines and the selection stays on the original lines.
+func (e *Editor) DuplicateLines(above bool) bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	selStart, selEnd := e.text.Selection()
+	b := e.selectedLineBlock()
+	text := b.String()
+	if !b.trailingBreak {
+		text += "\n"
+	}
+
+	if above {
+		e.replace(b.start, b.start, text)
+		e.shiftSelection(selStart, selEnd, utf8.RuneCountInString(text))
+		return true
+	}
+
+	if b.trailingBreak {
+		e.replace(b.end, b.end, text)
+	} else {
+		// the last line of the document has no line break.
+		e.replace(b.end, b.end, "\n"+strings.TrimSuffix(text, "\n"))
+	}
+	e.shiftSelection(selStart, selEnd, utf8.RuneCountInString(text))
+	return true
+}
+
+// JoinLines joins the lines covered by the selection into one line, or the
+// line of the caret with the next line if the selection covers a single line.
+// The leading whitespace of the joined lines is replaced with a single space.
+func (e *Editor) JoinLines() bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	selStart, selEnd := e.text.Selection()
+	b := e.selectedLineBlock()
+	if len(b.lines) < 2 {
+		line, _ := e.text.FindParagraph(b.start)
+		if line+1 >= e.text.Paragraphs() {
+			return false
+		}
+		_, end := e.text.RangeOfLines(line+1, 1, false)
+		if end == b.end {
+			// the next line is the empty last line.
+			return false
+		}
+		b = e.readLineBlock(b.start, end)
+	}
+
+	joined := b.lines[0]
+	for _, line := range b.lines[1:] {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if line != "" && joined != "" && !strings.HasSuffix(joined, " ") && !strings.HasSuffix(joined, "\t") {
+			joined += " "
+		}
+		// the caret is placed at the last joint.
+		if selStart == selEnd {
+			selStart = b.start + utf8.RuneCountInString(joined)
+			selEnd = selStart
+		}
+		joined += line
+	}
+	b.lines = []string{joined}
+	e.replaceLineBlock(b)
+
+	if selStart == selEnd {
+		e.SetCaret(selStart, selEnd)
+	} else {
+		// select the joined line.
+		newEnd := b.start + utf8.RuneCountInString(joined)
+		if selStart < selEnd {
+			e.SetCaret(b.start, newEnd)
+		} else {
+			e.SetCaret(newEnd, b.start)
+		}
+	}
+	return true
+}
+
+// transformLines replaces the lines covered by the selection with the result
+// of fn as a single undo step, and selects the lines if there was a selection.
+// It does nothing if fewer than two lines are selected.
+func (e *Editor) transformLines(fn func(lines []string) []string) bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	selStart, selEnd := e.text.Selection()
+	b := e.selectedLineBlock()
+	if len(b.lines) < 2 {
+		return false
+	}
+
+	old := b.String()
+	b.lines = fn(b.lines)
+	text := b.String()
+	if text == old {
+		return false
+	}
+	e.replace(b.start, b.end, text)
+
+	// keep the selection over the lines, dropping the removed ones.
+	newEnd := b.start + utf8.RuneCountInString(text)
+	if selStart <= selEnd {
+		e.SetCaret(selStart, min(selEnd, newEnd))
+	} else {
+		e.SetCaret(min(selStart, newEnd), selEnd)
+	}
+	return true
+}
+
+// SortLines sorts the lines covered by the selection. It reports whether the
+// lines are changed.
+func (e *Editor) SortLines(opts SortLinesOptions) bool {
+	return e.transformLines(func(lines []string) []string {
+		slices.SortStableFunc(lines, func(a, b string) int {
+			c := compareLines(a, b, opts)
+			if opts.Descending {
+				return -c
+			}
+			return c
+		})
+		return lines
+	})
+}
+
+// UniqueLines removes the duplicates of the lines covered by the selection,
+// keeping the first occurrence of each line. It reports whether the lines are
+// changed.
+func (e *Editor) UniqueLines() bool {
+	return e.transformLines(func(lines []string) []string {
+		seen := make(map[string]struct{}, len(lines))
+		return slices.DeleteFunc(lines, func(line string) bool {
+			if _, ok := seen[line]; ok {
+				return true
+			}
+			seen[line] = struct{}{}
+			return false
+		})
+	})
+}
+
+// ReverseLines reverses the order of the lines covered by the selection. It
+// reports whether the lines are changed.
+func (e *Editor) ReverseLines() bool {
+	return e.transformLines(func(lines []string) []string {
+		slices.Reverse(lines)
+		return lines
+	})
+}
+
+func compareLines(a, b string, opts SortLinesOptions) int {
+	if opts.IgnoreCase {
+		la, lb := strings.ToLower(a), strings.ToLower(b)
+		var c int
+		if opts.Natural {
+			c = naturalCompare(la, lb)
+		} else {
+			c = strings.Compare(la, lb)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	if opts.Natural {
+		return naturalCompare(a, b)
+	}
+	return strings.Compare(a, b)
+}
+
+// naturalCompare compares a and b, comparing the runs of ASCII digits by their
+// numeric value.
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			da, db := digitPrefix(a), digitPrefix(b)
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			// the longer number without leading zeros is larger.
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			if c := cmp.Compare(len(da), len(db)); c != 0 {
+				return c
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+
+		ra, sa := utf8.DecodeRuneInString(a)
+		rb, sb := utf8.DecodeRuneInString(b)
+		if c := cmp.Compare(ra, rb); c != 0 {
+			return c
+		}
+		a, b = a[sa:], b[sb:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
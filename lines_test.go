@@ -0,0 +1,242 @@
+package gvcode
+
+import (
+	"testing"
+
+	"gioui.org/io/key"
+)
+
+func TestLineCommands(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		// sel is the selection before the command, with the caret at Start.
+		sel     TextRange
+		command func(e *Editor) bool
+		want    string
+		wantSel TextRange
+		// unchanged is set if the command is expected to do nothing.
+		unchanged bool
+	}{
+		{
+			name:      "move the first line up",
+			input:     "a\nb\nc",
+			command:   func(e *Editor) bool { return e.MoveLines(-1) },
+			want:      "a\nb\nc",
+			unchanged: true,
+		},
+		{
+			name:    "move the first line down",
+			input:   "a\nb\nc",
+			command: func(e *Editor) bool { return e.MoveLines(1) },
+			want:    "b\na\nc",
+			wantSel: TextRange{Start: 2, End: 2},
+		},
+		{
+			name:      "move the last line down",
+			input:     "a\nb\nc",
+			sel:       TextRange{Start: 4, End: 4},
+			command:   func(e *Editor) bool { return e.MoveLines(1) },
+			want:      "a\nb\nc",
+			unchanged: true,
+		},
+		{
+			name:      "move the last line with a line break down",
+			input:     "a\nb\n",
+			sel:       TextRange{Start: 2, End: 2},
+			command:   func(e *Editor) bool { return e.MoveLines(1) },
+			want:      "a\nb\n",
+			unchanged: true,
+		},
+		{
+			name:    "move the last line without a line break up",
+			input:   "a\nb\nc",
+			sel:     TextRange{Start: 5, End: 5},
+			command: func(e *Editor) bool { return e.MoveLines(-1) },
+			want:    "a\nc\nb",
+			wantSel: TextRange{Start: 3, End: 3},
+		},
+		{
+			name:    "move a partial selection down",
+			input:   "ab\ncd\nef",
+			sel:     TextRange{Start: 4, End: 1},
+			command: func(e *Editor) bool { return e.MoveLines(1) },
+			want:    "ef\nab\ncd",
+			wantSel: TextRange{Start: 7, End: 4},
+		},
+		{
+			name:    "move a reversed selection up",
+			input:   "ab\ncd\nef",
+			sel:     TextRange{Start: 4, End: 7},
+			command: func(e *Editor) bool { return e.MoveLines(-1) },
+			want:    "cd\nef\nab",
+			wantSel: TextRange{Start: 1, End: 4},
+		},
+		{
+			name:    "duplicate the last line",
+			input:   "a\nb",
+			sel:     TextRange{Start: 3, End: 3},
+			command: func(e *Editor) bool { return e.DuplicateLines(false) },
+			want:    "a\nb\nb",
+			wantSel: TextRange{Start: 5, End: 5},
+		},
+		{
+			name:    "duplicate a partial selection",
+			input:   "ab\ncd",
+			sel:     TextRange{Start: 4, End: 1},
+			command: func(e *Editor) bool { return e.DuplicateLines(false) },
+			want:    "ab\ncd\nab\ncd",
+			wantSel: TextRange{Start: 10, End: 7},
+		},
+		{
+			name:    "duplicate above",
+			input:   "a\nb",
+			command: func(e *Editor) bool { return e.DuplicateLines(true) },
+			want:    "a\na\nb",
+			wantSel: TextRange{Start: 2, End: 2},
+		},
+		{
+			name:    "join with the next line",
+			input:   "foo\n    bar\nbaz",
+			sel:     TextRange{Start: 1, End: 1},
+			command: func(e *Editor) bool { return e.JoinLines() },
+			want:    "foo bar\nbaz",
+			wantSel: TextRange{Start: 4, End: 4},
+		},
+		{
+			name:    "join the selected lines",
+			input:   "a\n b\n c\nd",
+			sel:     TextRange{Start: 1, End: 6},
+			command: func(e *Editor) bool { return e.JoinLines() },
+			want:    "a b c\nd",
+			wantSel: TextRange{Start: 0, End: 5},
+		},
+		{
+			name:      "join the last line",
+			input:     "a\nb",
+			sel:       TextRange{Start: 3, End: 3},
+			command:   func(e *Editor) bool { return e.JoinLines() },
+			want:      "a\nb",
+			unchanged: true,
+		},
+		{
+			name:    "sort",
+			input:   "b\nitem10\nB\nitem2\na",
+			sel:     TextRange{Start: 0, End: 18},
+			command: func(e *Editor) bool { return e.SortLines(SortLinesOptions{}) },
+			want:    "B\na\nb\nitem10\nitem2",
+			wantSel: TextRange{Start: 0, End: 18},
+		},
+		{
+			name:    "natural sort",
+			input:   "item10\nitem2\nitem1",
+			sel:     TextRange{Start: 0, End: 18},
+			command: func(e *Editor) bool { return e.SortLines(SortLinesOptions{Natural: true}) },
+			want:    "item1\nitem2\nitem10",
+			wantSel: TextRange{Start: 0, End: 18},
+		},
+		{
+			name:    "case-insensitive sort",
+			input:   "b\nA\nB\na",
+			sel:     TextRange{Start: 0, End: 7},
+			command: func(e *Editor) bool { return e.SortLines(SortLinesOptions{IgnoreCase: true}) },
+			want:    "A\na\nB\nb",
+			wantSel: TextRange{Start: 0, End: 7},
+		},
+		{
+			name:    "descending sort",
+			input:   "a\nc\nb",
+			sel:     TextRange{Start: 0, End: 5},
+			command: func(e *Editor) bool { return e.SortLines(SortLinesOptions{Descending: true}) },
+			want:    "c\nb\na",
+			wantSel: TextRange{Start: 0, End: 5},
+		},
+		{
+			name:    "sort a partial selection",
+			input:   "z\nc\nb\na",
+			sel:     TextRange{Start: 3, End: 5},
+			command: func(e *Editor) bool { return e.SortLines(SortLinesOptions{}) },
+			want:    "z\nb\nc\na",
+			wantSel: TextRange{Start: 3, End: 5},
+		},
+		{
+			name:    "remove duplicate lines",
+			input:   "a\nb\na\nb\nc",
+			sel:     TextRange{Start: 0, End: 9},
+			command: func(e *Editor) bool { return e.UniqueLines() },
+			want:    "a\nb\nc",
+			wantSel: TextRange{Start: 0, End: 5},
+		},
+		{
+			name:    "reverse lines",
+			input:   "a\nb\nc\n",
+			sel:     TextRange{Start: 6, End: 0},
+			command: func(e *Editor) bool { return e.ReverseLines() },
+			want:    "c\nb\na\n",
+			wantSel: TextRange{Start: 6, End: 0},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEditor(t, tc.input)
+			e.SetCaret(tc.sel.Start, tc.sel.End)
+
+			if got := tc.command(e); got == tc.unchanged {
+				t.Fatalf("want changed %v, got %v", !tc.unchanged, got)
+			}
+			if got := e.Text(); got != tc.want {
+				t.Fatalf("want text %q, got %q", tc.want, got)
+			}
+			if tc.unchanged {
+				return
+			}
+			if start, end := e.Selection(); (TextRange{Start: start, End: end}) != tc.wantSel {
+				t.Errorf("want selection %v, got %d, %d", tc.wantSel, start, end)
+			}
+
+			// each command is a single undo step.
+			if _, ok := e.undo(); !ok {
+				t.Fatal("nothing to undo")
+			}
+			if got := e.Text(); got != tc.input {
+				t.Errorf("undo: want text %q, got %q", tc.input, got)
+			}
+		})
+	}
+}
+
+func TestSortCommands(t *testing.T) {
+	testcases := []struct {
+		mods key.Modifiers
+		want string
+	}{
+		{mods: 0, want: "B\na\nitem10\nitem9"},
+		{mods: key.ModShift, want: "item9\nitem10\na\nB"},
+		{mods: key.ModShortcut, want: "a\nB\nitem10\nitem9"},
+		{mods: key.ModAlt, want: "B\na\nitem9\nitem10"},
+		{mods: key.ModShortcut | key.ModAlt | key.ModShift, want: "item10\nitem9\nB\na"},
+	}
+
+	input := "item10\nB\nitem9\na"
+	for _, tc := range testcases {
+		w := newTestWindow(t, input)
+		w.editor.SetCaret(0, w.editor.Len())
+		w.press(key.NameF9, tc.mods)
+		if got := w.editor.Text(); got != tc.want {
+			t.Errorf("F9 with %v: want %q, got %q", tc.mods, tc.want, got)
+		}
+	}
+
+	w := newTestWindow(t, "a\nb\na")
+	w.editor.SetCaret(0, w.editor.Len())
+	w.press(key.NameF10, 0)
+	if got := w.editor.Text(); got != "a\nb" {
+		t.Errorf("F10: want the duplicates removed, got %q", got)
+	}
+	w.editor.SetCaret(0, w.editor.Len())
+	w.press(key.NameF10, key.ModShift)
+	if got := w.editor.Text(); got != "b\na" {
+		t.Errorf("Shift+F10: want the lines reversed, got %q", got)
+	}
+}
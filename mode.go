@@ -1,5 +1,13 @@
 package gvcode
 
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gioui.org/io/key"
+	"github.com/go-text/typesetting/segmenter"
+)
+
 // Mode defines a mode for the editor. The editor can be switched
 // back and forth bewteen different modes, depending on the context.
 type EditorMode uint8
@@ -22,16 +30,129 @@ const (
 	//
 	// See https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#snippet_syntax.
 	ModeSnippet
+
+	// ModeOverwrite makes the typed text replace the grapheme clusters after
+	// the caret instead of being inserted, up to the end of the line. Users can
+	// toggle it with Ctrl+Shift+I, or Cmd+Shift+I on macOS.
+	ModeOverwrite
 )
 
+// A ModeChangedEvent is generated when the mode of the editor is changed, e.g.,
+// when the user toggles the overwrite mode. Applications can show the mode in
+// their status bar.
+type ModeChangedEvent struct {
+	Mode EditorMode
+}
+
+func (s ModeChangedEvent) isEditorEvent() {}
+
 func (e *Editor) setMode(mode EditorMode) {
 	switch mode {
-	case ModeNormal, ModeReadOnly:
+	case ModeNormal, ModeReadOnly, ModeOverwrite:
 		if e.snippetCtx != nil {
 			e.snippetCtx.Cancel()
 			e.snippetCtx = nil
 		}
 	}
 
+	if mode == ModeNormal || mode == ModeOverwrite {
+		e.editingMode = mode
+	}
+	if e.mode != mode {
+		e.pending = append(e.pending, ModeChangedEvent{Mode: mode})
+	}
 	e.mode = mode
 }
+
+// restoreEditingMode switches the editor back to the normal or the overwrite
+// mode it was in before the read-only or the snippet mode.
+func (e *Editor) restoreEditingMode() {
+	e.setMode(e.editingMode)
+}
+
+// ToggleOverwrite switches the editor between the normal mode and the
+// overwrite mode. It reports whether the mode is changed, which is not the case
+// in the read-only and snippet modes.
+func (e *Editor) ToggleOverwrite() bool {
+	switch e.mode {
+	case ModeNormal:
+		e.setMode(ModeOverwrite)
+	case ModeOverwrite:
+		e.setMode(ModeNormal)
+	default:
+		return false
+	}
+	return true
+}
+
+// overwriteInput removes the grapheme clusters after the range of the input
+// that its text overwrites in the overwrite mode, and reports whether any is
+// removed. An input replacing a range, like the text composed by an input
+// method, overwrites as many clusters as it adds to the range, so that the
+// committed composition overwrites as many clusters as it has. If the input has
+// fewer clusters than the range, the clusters overwritten by the previous
+// inputs of the composition are restored. A line break overwrites nothing.
+func (e *Editor) overwriteInput(ke key.EditEvent) bool {
+	start, end := min(ke.Range.Start, ke.Range.End), max(ke.Range.Start, ke.Range.End)
+	if start == end || end != e.ime.overwriteAt {
+		// the input doesn't replace the text of the previous inputs.
+		e.ime.overwritten = e.ime.overwritten[:0]
+	}
+	defer func() { e.ime.overwriteAt = start + utf8.RuneCountInString(ke.Text) }()
+	if strings.Contains(ke.Text, "\n") {
+		e.ime.overwritten = e.ime.overwritten[:0]
+		return false
+	}
+
+	delta := graphemeCount(ke.Text) - graphemeCount(e.textRange(start, end))
+	switch {
+	case delta > 0:
+		overwriteEnd := e.overwriteEnd(end, delta)
+		if overwriteEnd == end {
+			return false
+		}
+		for pos := end; pos < overwriteEnd; {
+			next := e.text.NextGrapheme(pos)
+			e.ime.overwritten = append(e.ime.overwritten, e.textRange(pos, next))
+			pos = next
+		}
+		e.replace(end, overwriteEnd, "")
+		return true
+	case delta < 0:
+		n := min(-delta, len(e.ime.overwritten))
+		if n > 0 {
+			restored := e.ime.overwritten[len(e.ime.overwritten)-n:]
+			e.ime.overwritten = e.ime.overwritten[:len(e.ime.overwritten)-n]
+			// the restored text follows the input, so the caret stays.
+			caret, anchor := e.text.Selection()
+			e.replace(end, end, strings.Join(restored, ""))
+			e.text.SetCaret(caret, anchor)
+		}
+	}
+	return false
+}
+
+// overwriteEnd returns the end of the range of count grapheme clusters after
+// start. The range stops at the end of the line.
+func (e *Editor) overwriteEnd(start int, count int) int {
+	end := start
+	for range count {
+		if r, err := e.text.ReadRuneAt(end); err != nil || r == '\n' {
+			break
+		}
+		end = e.text.NextGrapheme(end)
+	}
+	return end
+}
+
+// graphemeCount returns the number of grapheme clusters of text.
+func graphemeCount(text string) int {
+	var seg segmenter.Segmenter
+	seg.Init([]rune(text))
+	iter := seg.GraphemeIterator()
+	count := 0
+	for iter.Next() {
+		count++
+	}
+	return count
+}
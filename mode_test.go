@@ -0,0 +1,138 @@
+package gvcode
+
+import (
+	"testing"
+
+	"gioui.org/io/key"
+)
+
+func TestOverwriteEnd(t *testing.T) {
+	testcases := []struct {
+		input string
+		start int
+		typed string
+		want  int
+	}{
+		// one rune over one rune.
+		{input: "abc", start: 0, typed: "x", want: 1},
+		// the grapheme cluster of "e" and a combining accent is overwritten
+		// as a whole.
+		{input: "ae\u0301b", start: 1, typed: "x", want: 3},
+		// a typed cluster of two runes overwrites one cluster.
+		{input: "abc", start: 0, typed: "e\u0301", want: 1},
+		// the range stops at the end of the line.
+		{input: "ab\ncd", start: 1, typed: "xyz", want: 2},
+		{input: "ab\ncd", start: 2, typed: "x", want: 2},
+		// and at the end of the text.
+		{input: "ab", start: 1, typed: "xyz", want: 2},
+	}
+
+	for i, tc := range testcases {
+		e := newTestEditor(t, tc.input)
+		if got := e.overwriteEnd(tc.start, graphemeCount(tc.typed)); got != tc.want {
+			t.Errorf("case %d: want %d, got %d", i, tc.want, got)
+		}
+	}
+}
+
+func TestOverwriteMode(t *testing.T) {
+	e := newTestEditor(t, "abcde\nxyz")
+	if !e.ToggleOverwrite() || e.Mode() != ModeOverwrite {
+		t.Fatalf("overwrite mode not entered")
+	}
+	if len(e.pending) != 1 || e.pending[0] != (ModeChangedEvent{Mode: ModeOverwrite}) {
+		t.Errorf("no ModeChangedEvent queued: %v", e.pending)
+	}
+
+	e.SetCaret(2, 2)
+	typeText(e, "XYZW")
+	if got := e.Text(); got != "abXYZW\nxyz" {
+		t.Errorf("unexpected text: %q", got)
+	}
+	if start, _ := e.Selection(); start != 6 {
+		t.Errorf("unexpected caret: %d", start)
+	}
+
+	e.undo()
+	if got := e.Text(); got != "abXYZ\nxyz" {
+		t.Errorf("each overwrite should be a single undo step: %q", got)
+	}
+
+	e.ToggleOverwrite()
+	e.SetCaret(0, 0)
+	typeText(e, "1")
+	if got := e.Text(); got != "1abXYZ\nxyz" {
+		t.Errorf("text inserted in the normal mode: %q", got)
+	}
+}
+
+func TestOverwriteComposition(t *testing.T) {
+	e := newTestEditor(t, "abcdef\nxyz")
+	e.ToggleOverwrite()
+	e.SetCaret(1, 1)
+
+	// the input method composes "ni" and commits "你".
+	edit := func(start, end int, text string) {
+		t.Helper()
+		e.onCaretsTextInput(key.EditEvent{Range: key.Range{Start: start, End: end}, Text: text})
+	}
+	edit(1, 1, "n")
+	if got := e.Text(); got != "ancdef\nxyz" {
+		t.Fatalf("unexpected text: %q", got)
+	}
+	edit(1, 2, "ni")
+	if got := e.Text(); got != "anidef\nxyz" {
+		t.Fatalf("unexpected text: %q", got)
+	}
+	edit(1, 3, "你")
+	if got := e.Text(); got != "a你cdef\nxyz" {
+		t.Fatalf("the commit should overwrite one cluster: %q", got)
+	}
+	if start, _ := e.Selection(); start != 2 {
+		t.Errorf("unexpected caret: %d", start)
+	}
+
+	// a commit longer than the composition overwrites its clusters, up to the
+	// end of the line.
+	edit(2, 2, "h")
+	edit(2, 3, "你好吗你好")
+	if got := e.Text(); got != "a你你好吗你好\nxyz" {
+		t.Errorf("unexpected text: %q", got)
+	}
+
+	// a line break overwrites nothing.
+	e.SetCaret(8, 8)
+	edit(8, 8, "\n")
+	if got := e.Text(); got != "a你你好吗你好\n\nxyz" {
+		t.Errorf("unexpected text: %q", got)
+	}
+}
+
+func TestEditingModeRestored(t *testing.T) {
+	e := newTestWindow(t, "abc").editor
+	e.ToggleOverwrite()
+
+	ReadOnlyMode(true)(e)
+	ReadOnlyMode(false)(e)
+	if e.Mode() != ModeOverwrite {
+		t.Errorf("want the overwrite mode after the read-only mode, got %v", e.Mode())
+	}
+
+	if _, err := e.InsertSnippet("f(${1:a}, $0)"); err != nil {
+		t.Fatal(err)
+	}
+	if e.Mode() != ModeSnippet {
+		t.Fatalf("want the snippet mode, got %v", e.Mode())
+	}
+	e.snippetCtx.NextTabStop()
+	if e.Mode() != ModeOverwrite {
+		t.Errorf("want the overwrite mode after the snippet, got %v", e.Mode())
+	}
+
+	e.ToggleOverwrite()
+	ReadOnlyMode(true)(e)
+	ReadOnlyMode(false)(e)
+	if e.Mode() != ModeNormal {
+		t.Errorf("want the normal mode after the read-only mode, got %v", e.Mode())
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/oligo/gvcode/gutter"
 	"github.com/oligo/gvcode/gutter/providers"
 	"github.com/oligo/gvcode/textstyle/syntax"
+	"github.com/oligo/gvcode/textview"
 )
 
 // EditorOption defines a function to configure the editor.
@@ -92,6 +93,7 @@ func WithWordSeperators(seperators string) EditorOption {
 func WithQuotePairs(quotePairs map[rune]rune) EditorOption {
 	return func(e *Editor) {
 		e.initBuffer()
+		e.quotePairs = quotePairs
 		e.text.BracketsQuotes.SetQuotes(quotePairs)
 	}
 }
@@ -101,6 +103,7 @@ func WithQuotePairs(quotePairs map[rune]rune) EditorOption {
 func WithBracketPairs(bracketPairs map[rune]rune) EditorOption {
 	return func(e *Editor) {
 		e.initBuffer()
+		e.bracketPairs = bracketPairs
 		e.text.BracketsQuotes.SetBrackets(bracketPairs)
 	}
 }
@@ -114,7 +117,7 @@ func ReadOnlyMode(enabled bool) EditorOption {
 		if enabled {
 			e.setMode(ModeReadOnly)
 		} else {
-			e.setMode(ModeNormal)
+			e.restoreEditingMode()
 		}
 	}
 }
@@ -127,6 +130,110 @@ func WrapLine(enabled bool) EditorOption {
 	}
 }
 
+// WithWrapPolicy configures where lines are broken when line wrapping is enabled.
+// Under the [textview.WrapColumn] policy lines are wrapped at column, measured in
+// advances of the space glyph, regardless of the viewport width. Under the
+// [textview.WrapBounded] policy lines are wrapped at the column or the viewport
+// width, whichever is smaller.
+func WithWrapPolicy(policy textview.WrapPolicy, column int) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.text.SetWrapPolicy(policy, column)
+	}
+}
+
+// WithRulers configures vertical rulers drawn behind the text at the given columns.
+// The columns are measured in advances of the space glyph, so rulers line up with
+// the text when a monospace font is used. Rulers with no color set are painted
+// using the RulerColor of the color palette.
+func WithRulers(rulers ...textview.Ruler) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.rulers = rulers
+	}
+}
+
+// WithIndentGuides configures whether to paint vertical guides at every indentation
+// level. The indentation levels are measured in TabWidth, and the guide of the block
+// containing the caret is highlighted using the ActiveIndentGuideColor of the
+// color palette.
+func WithIndentGuides(enabled bool) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.indentGuides = enabled
+	}
+}
+
+// WithSmoothScroll configures whether the scrolls to the caret, e.g., when the caret is
+// moved by keyboard or by pages, are animated.
+func WithSmoothScroll(enabled bool) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.text.SmoothScroll = enabled
+	}
+}
+
+// WithScrollPastEnd configures whether the editor can scroll past the last line by
+// up to one viewport.
+func WithScrollPastEnd(enabled bool) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.text.ScrollPastEnd = enabled
+	}
+}
+
+// WithCaretSurroundingLines sets the minimum number of lines kept visible above and
+// below the caret when the editor scrolls to the caret.
+func WithCaretSurroundingLines(lines int) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.text.CaretMargin = max(0, lines)
+	}
+}
+
+// WithTypewriterMode configures whether to keep the caret line vertically centered
+// in the viewport.
+func WithTypewriterMode(enabled bool) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.text.Typewriter = enabled
+	}
+}
+
+// WithStickyScroll configures sticky scroll, which pins the header lines of the
+// scopes enclosing the top visible line at the top of the viewport. At most maxLines
+// lines are pinned, and a maxLines of zero disables sticky scroll. kind determines how
+// the scopes are detected, unless a scope provider is set using [WithScopeProvider].
+// The pinned lines are painted over the Background of the color palette, or over
+// white or black, contrasting with the text, if it is not set.
+func WithStickyScroll(maxLines int, kind textview.ScopeKind) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.sticky.maxLines = maxLines
+		e.sticky.kind = kind
+	}
+}
+
+// WithScopeProvider sets an application supplied provider of the enclosing scopes
+// used by sticky scroll, e.g., scopes computed from a syntax tree.
+func WithScopeProvider(provider textview.ScopeProvider) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.sticky.provider = provider
+	}
+}
+
+// WithWhitespaceMode configures which whitespace characters are rendered visibly.
+// Spaces are rendered as middle dots, tabs as arrows spanning the tab stop and line
+// endings as return markers. Except for WhitespaceNone, non-printing control
+// characters are rendered as labeled boxes like <U+001B>.
+func WithWhitespaceMode(mode textview.WhitespaceMode) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.text.WhitespaceMode = mode
+	}
+}
+
 // Deprecated: Please use [WithGutter] or [WithDefaultGutters]
 // WithLineNumber configures whether to show line number or not.
 func WithLineNumber(enabled bool) EditorOption {
@@ -170,6 +277,18 @@ func AddBeforePasteHook(hook BeforePasteHook) EditorOption {
 	}
 }
 
+// WithSmartPaste configures whether to reindent the text of multiple lines when
+// it is pasted, so that its first line is indented as the line of the caret and
+// the other lines keep their indentation relative to the first line. The
+// indentation is converted to the tabs or spaces of the editor. The text is
+// reindented after the BeforePasteHook is called.
+func WithSmartPaste(enabled bool) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.smartPaste = enabled
+	}
+}
+
 // WithGutter adds a gutter provider to the editor. Creates a gutter manager if needed.
 // Multiple providers can be added by calling this function multiple times.
 func WithGutter(provider gutter.GutterProvider) EditorOption {
@@ -193,3 +312,12 @@ func WithDefaultGutters() EditorOption {
 		e.gutterManager.Register(providers.NewLineNumberProvider())
 	}
 }
+
+// WithZoom sets the initial zoom level of the editor, e.g., to restore a level
+// persisted from a [ZoomChangedEvent]. The level is clamped to [MinZoom, MaxZoom].
+func WithZoom(level float32) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.zoom.level = clampZoom(level)
+	}
+}
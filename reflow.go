@@ -0,0 +1,310 @@
+package gvcode
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/oligo/gvcode/internal/layout"
+)
+
+// defaultReflowColumn is the column the text is reflowed to if none is set.
+const defaultReflowColumn = 80
+
+// hardWrap holds the options of ReflowParagraphs and the hard wrapping on
+// typing.
+type hardWrap struct {
+	// column is the maximum width of the reflowed lines.
+	column int
+	// onType breaks the line when the text typed goes past the column.
+	onType bool
+}
+
+func (w hardWrap) maxColumn() int {
+	if w.column <= 0 {
+		return defaultReflowColumn
+	}
+	return w.column
+}
+
+// WithHardWrap sets the column that ReflowParagraphs reflows the text to, which
+// is 80 by default. If onType is true, the line is also broken when the text
+// typed at its end goes past the column, continuing the indentation and the
+// comment prefix of the line. In code, only the comment lines are broken as
+// typed, while all the lines of prose, like markdown or plain text, are.
+func WithHardWrap(column int, onType bool) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.hardWrap = hardWrap{column: column, onType: onType}
+	}
+}
+
+// reflowLine is a line split into its prefix, made of the indentation, the
+// comment token and the spaces after it, and the text content.
+type reflowLine struct {
+	prefix  string
+	content string
+	// leader is the comment token with the indentation before it, or the
+	// indentation only if the line is not a comment.
+	leader string
+	// bullet is the list marker starting the content, with the spaces after
+	// it.
+	bullet string
+}
+
+// proseLanguages are the IDs of the languages whose text is wrapped as typed,
+// not only the comments.
+var proseLanguages = []string{"markdown", "plaintext", "restructuredtext", "asciidoc", "latex", "tex"}
+
+// isProse reports whether the text of the editor is prose rather than code.
+func (e *Editor) isProse() bool {
+	return e.lang == nil || slices.Contains(proseLanguages, e.lang.ID)
+}
+
+// commentTokens returns the tokens starting the comment lines that are
+// reflowed, the longest first. They are the comment tokens of the language, or
+// the common ones and the quote marker of markdown for prose.
+func (e *Editor) commentTokens() []string {
+	if e.isProse() || (e.lang.Comments.LineComment == "" && e.lang.Comments.BlockComment.Open == "") {
+		return []string{"///", "//", "##", "#", "--", ";;", ";", ">"}
+	}
+
+	var tokens []string
+	if t := e.lang.Comments.LineComment; t != "" {
+		tokens = append(tokens, t)
+	}
+	// the continuation lines of block comments, like " * " in C.
+	if strings.HasSuffix(e.lang.Comments.BlockComment.Open, "*") {
+		tokens = append(tokens, "*")
+	}
+	return tokens
+}
+
+func (e *Editor) parseReflowLine(line string) reflowLine {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	indent := leadingSpaces(line)
+	rest := line[len(indent):]
+
+	l := reflowLine{leader: indent}
+	for _, token := range e.commentTokens() {
+		if strings.HasPrefix(rest, token) {
+			l.leader = indent + token
+			rest = rest[len(token):]
+			break
+		}
+	}
+	content := strings.TrimLeft(rest, " \t")
+	l.prefix = line[:len(line)-len(content)]
+	l.content = content
+	l.bullet = listBullet(content)
+	return l
+}
+
+// listBullet returns the marker of a list item starting s, like "- " or "1. ",
+// with the spaces after it.
+func listBullet(s string) string {
+	n := 0
+	switch {
+	case strings.HasPrefix(s, "- "), strings.HasPrefix(s, "* "), strings.HasPrefix(s, "+ "):
+		n = 1
+	default:
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 0 || n > 9 || n+1 >= len(s) || (s[n] != '.' && s[n] != ')') || s[n+1] != ' ' {
+			return ""
+		}
+		n++
+	}
+	return s[:len(s)-len(strings.TrimLeft(s[n:], " "))]
+}
+
+// verbatim reports whether the line is kept as it is, like the code blocks
+// of Go doc comments, which are indented after the comment token. The indented
+// list items are not verbatim.
+func (l reflowLine) verbatim() bool {
+	if l.leader == leadingSpaces(l.leader) {
+		return false
+	}
+	space := l.prefix[len(l.leader):]
+	return strings.Contains(space, "\t") || (len(space) > 1 && l.bullet == "")
+}
+
+// reflow rewrites lines as paragraphs filled to the column of the hard wrap.
+// The lines with the same prefix make up a paragraph, and a list item makes up
+// a paragraph with the lines after it indented to its text. Blank lines and
+// the lines without text separate the paragraphs, and are kept.
+func (e *Editor) reflow(lines []string) []string {
+	column := e.hardWrap.maxColumn()
+	var out []string
+	fence := false
+	for i := 0; i < len(lines); {
+		first := e.parseReflowLine(lines[i])
+		if strings.HasPrefix(first.content, "```") {
+			fence = !fence
+		}
+		if fence || first.content == "" || first.verbatim() || strings.HasPrefix(first.content, "```") {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+
+		// the prefix of the lines following the first line.
+		next := first.prefix
+		if first.bullet != "" {
+			next = first.prefix + strings.Repeat(" ", utf8.RuneCountInString(first.bullet))
+		}
+		words := []string{first.content}
+		i++
+		for ; i < len(lines); i++ {
+			l := e.parseReflowLine(lines[i])
+			if l.content == "" || l.prefix != next || l.bullet != "" || strings.HasPrefix(l.content, "```") {
+				break
+			}
+			words = append(words, l.content)
+		}
+
+		width := columnWidth(first.prefix, e.text.TabWidth)
+		text := strings.Join(words, " ")
+		for j, line := range layout.FillText([]rune(text), max(1, column-width)) {
+			if j == 0 {
+				out = append(out, first.prefix+line)
+			} else {
+				out = append(out, next+line)
+			}
+		}
+	}
+	return out
+}
+
+// columnWidth returns the width of s in columns, expanding the tabs and
+// counting the wide East Asian characters as two columns.
+func columnWidth(s string, tabWidth int) int {
+	tabWidth = max(1, tabWidth)
+	width := 0
+	for _, r := range s {
+		if r == '\t' {
+			width += tabWidth - width%tabWidth
+		} else {
+			width += layout.RuneWidth(r)
+		}
+	}
+	return width
+}
+
+// reflowKey returns the leader of the lines in the paragraph of the line, or
+// false if the line has no text.
+func (e *Editor) reflowKey(lineIdx int) (string, bool) {
+	l := e.parseReflowLine(e.lineText(lineIdx))
+	if l.content == "" {
+		return "", false
+	}
+	if l.leader == leadingSpaces(l.leader) {
+		// the indentation of the lines of a list item differs.
+		return "", true
+	}
+	return l.leader, true
+}
+
+// ReflowParagraphs reflows the paragraphs covered by the selection, or the
+// paragraph at the caret if there is no selection, to the column set by
+// WithHardWrap, like the gq command of Vim. The indentation and the comment
+// prefix of the lines are preserved. The lines are broken at the spaces, or
+// between the ideographs of Chinese and Japanese, so URLs and paths stay whole.
+// The selection covers the reflowed lines after it, and the caret is moved to
+// the end of the paragraph if there was no selection. It reports whether the
+// text is changed.
+func (e *Editor) ReflowParagraphs() bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	selStart, selEnd := e.text.Selection()
+	var b *lineBlock
+	if selStart != selEnd {
+		b = e.selectedLineBlock()
+	} else {
+		lineIdx, _ := e.text.FindParagraph(selStart)
+		key, ok := e.reflowKey(lineIdx)
+		if !ok {
+			return false
+		}
+		first, last := lineIdx, lineIdx
+		for first > 0 {
+			if k, ok := e.reflowKey(first - 1); !ok || k != key {
+				break
+			}
+			first--
+		}
+		for last+1 < e.text.Paragraphs() {
+			if k, ok := e.reflowKey(last + 1); !ok || k != key {
+				break
+			}
+			last++
+		}
+		start, end := e.text.RangeOfLines(first, last-first+1, false)
+		b = e.readLineBlock(start, end)
+	}
+
+	old := b.String()
+	b.lines = e.reflow(b.lines)
+	text := b.String()
+	if text == old {
+		return false
+	}
+	e.replaceLineBlock(b)
+
+	end := b.start + utf8.RuneCountInString(strings.TrimSuffix(text, "\n"))
+	if selStart == selEnd {
+		e.SetCaret(end, end)
+	} else if selStart < selEnd {
+		e.SetCaret(b.start, end)
+	} else {
+		e.SetCaret(end, b.start)
+	}
+	return true
+}
+
+// hardWrapOnType breaks the line of the caret if the text before the caret
+// goes past the column of the hard wrap, after s is typed. The lines after the
+// first continue the prefix of the line. In code, only the comment lines are
+// broken.
+func (e *Editor) hardWrapOnType(s string) {
+	if !e.hardWrap.onType || strings.TrimSpace(s) == "" || strings.Contains(s, "\n") {
+		return
+	}
+
+	caret, _ := e.text.Selection()
+	_, p := e.text.FindParagraph(caret)
+	head := e.textRange(p.RuneOff, caret)
+	column := e.hardWrap.maxColumn()
+	if columnWidth(head, e.text.TabWidth) <= column {
+		return
+	}
+
+	l := e.parseReflowLine(head)
+	if l.content == "" || l.verbatim() {
+		return
+	}
+	if !e.isProse() && l.leader == leadingSpaces(l.leader) {
+		return
+	}
+	lines := layout.FillText([]rune(l.content), max(1, column-columnWidth(l.prefix, e.text.TabWidth)))
+	if len(lines) < 2 {
+		return
+	}
+
+	next := l.prefix
+	if l.bullet != "" {
+		next = l.prefix + strings.Repeat(" ", utf8.RuneCountInString(l.bullet))
+	}
+	var b strings.Builder
+	b.WriteString(l.prefix)
+	b.WriteString(strings.Join(lines, "\n"+next))
+	wrapped := b.String()
+	e.replace(p.RuneOff, caret, wrapped)
+	caret = p.RuneOff + utf8.RuneCountInString(wrapped)
+	e.SetCaret(caret, caret)
+}
@@ -0,0 +1,106 @@
+package gvcode
+
+import (
+	"testing"
+
+	"github.com/oligo/gvcode/language"
+)
+
+func goLanguage() *language.Config {
+	cfg := &language.Config{ID: "go"}
+	cfg.Comments.LineComment = "//"
+	cfg.Comments.BlockComment = language.Pair{Open: "/*", Close: "*/"}
+	return cfg
+}
+
+func TestReflowParagraphs(t *testing.T) {
+	testcases := []struct {
+		input string
+		want  string
+	}{
+		{
+			input: "\t// Foo does a thing that is rather long and should be wrapped. See\n\t// https://example.com/foo/bar-baz for the details.\n",
+			want:  "\t// Foo does a thing that is rather\n\t// long and should be wrapped. See\n\t// https://example.com/foo/bar-baz\n\t// for the details.\n",
+		},
+		{
+			// code blocks of Go doc comments are kept.
+			input: "// Example:\n//\n//\tfoo(a, b, c, d, e, f, g, h, i, j, k, l, m, n)\n",
+			want:  "// Example:\n//\n//\tfoo(a, b, c, d, e, f, g, h, i, j, k, l, m, n)\n",
+		},
+		{
+			// list items wrap to the text of the item.
+			input: "//  - a list item that is long enough to wrap around the column\n//  - second\n",
+			want:  "//  - a list item that is long enough to\n//    wrap around the column\n//  - second\n",
+		},
+		{
+			// the ideographs take two columns.
+			input: "// 一二三四五六七八九十一二三四五六七八九十\n",
+			want:  "// 一二三四五六七八九十一二三四五六七八\n// 九十\n",
+		},
+	}
+
+	for i, tc := range testcases {
+		e := newTestEditor(t, tc.input, WithHardWrap(40, false))
+		e.SetLanguage(goLanguage())
+		e.SetCaret(0, e.Len())
+		e.ReflowParagraphs()
+		if got := e.Text(); got != tc.want {
+			t.Errorf("case %d: want %q, got %q", i, tc.want, got)
+		}
+	}
+}
+
+func TestHardWrapOnType(t *testing.T) {
+	testcases := []struct {
+		input string
+		lang  *language.Config
+		typed string
+		want  string
+	}{
+		{
+			input: "\t// a comment that goes on",
+			lang:  goLanguage(),
+			typed: " and on",
+			want:  "\t// a comment that goes on\n\t// and on",
+		},
+		{
+			// code lines are not wrapped.
+			input: "\tx := foo(aaaa, bbbb, cccc)",
+			lang:  goLanguage(),
+			typed: " + dddd",
+			want:  "\tx := foo(aaaa, bbbb, cccc) + dddd",
+		},
+		{
+			// nor the lines starting with the comment tokens of other
+			// languages.
+			input: "\t# not a comment in Go code",
+			lang:  goLanguage(),
+			typed: " at all",
+			want:  "\t# not a comment in Go code at all",
+		},
+		{
+			// plain text is wrapped.
+			input: "some plain text that goes",
+			typed: " on and on",
+			want:  "some plain text that goes on\nand on",
+		},
+		{
+			// and URLs are not broken.
+			input: "see the page at",
+			typed: " https://example.com/a/b",
+			want:  "see the page at\nhttps://example.com/a/b",
+		},
+	}
+
+	for i, tc := range testcases {
+		e := newTestEditor(t, tc.input, WithHardWrap(30, true))
+		if tc.lang != nil {
+			e.SetLanguage(tc.lang)
+		}
+		e.SetCaret(e.Len(), e.Len())
+		typeText(e, tc.typed)
+		if got := e.Text(); got != tc.want {
+			t.Errorf("case %d: want %q, got %q", i, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package gvcode
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/oligo/gvcode/language"
+)
+
+// indentWidth returns the width in columns of the leading whitespace of line.
+func indentWidth(line string, tabWidth int) int {
+	width := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += tabWidth - width%tabWidth
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// indentUnit guesses the width in columns of one indentation level of text.
+// For the text indented with spaces, it is the greatest common divisor of the
+// indentation widths, as the most common width may span several levels.
+func (e *Editor) indentUnit(text string) int {
+	style, _, size := GuessIndentation(text)
+	if style != Spaces {
+		return max(1, e.text.TabWidth)
+	}
+
+	unit := 0
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, " ") && strings.TrimSpace(line) != "" {
+			unit = gcd(unit, indentWidth(line, e.text.TabWidth))
+		}
+	}
+	if unit < 2 || unit > 8 {
+		unit = size
+	}
+	return max(1, unit)
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// makeIndent returns the leading whitespace of level levels with extra spaces,
+// using the indentation of the editor.
+func (e *Editor) makeIndent(level, extra int) string {
+	return strings.Repeat(e.text.Indentation(), max(0, level)) + strings.Repeat(" ", max(0, extra))
+}
+
+// reindenter decides the indentation levels of consecutive lines from the
+// indentation rules of the language, or from the brackets if the language has
+// no rules. A line which matches no rule keeps its indentation relative to the
+// previous line.
+type reindenter struct {
+	e     *Editor
+	rules *language.IndentationRules
+	// prev and prevPrev are the two previous non-blank lines.
+	prev, prevPrev string
+	// prevLevel is the new level of prev, and prevOld is its original level.
+	prevLevel, prevOld int
+}
+
+func (r *reindenter) match(re *regexp.Regexp, line string) bool {
+	return re != nil && re.MatchString(line)
+}
+
+func (r *reindenter) increases(line string) bool {
+	if r.rules != nil && r.rules.IncreaseIndentPattern != nil {
+		return r.rules.IncreaseIndentPattern.MatchString(line)
+	}
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	last, _ := utf8.DecodeLastRuneInString(line)
+	_, ok := r.e.text.BracketsQuotes.GetClosingBracket(last)
+	return ok
+}
+
+func (r *reindenter) decreases(line string) bool {
+	if r.rules != nil && r.rules.DecreaseIndentPattern != nil {
+		return r.rules.DecreaseIndentPattern.MatchString(line)
+	}
+	line = strings.TrimLeftFunc(line, unicode.IsSpace)
+	first, _ := utf8.DecodeRuneInString(line)
+	_, ok := r.e.text.BracketsQuotes.GetOpeningBracket(first)
+	return ok
+}
+
+func (r *reindenter) indentsNext(line string) bool {
+	return r.rules != nil && r.match(r.rules.IndentNextLinePattern, line)
+}
+
+// level returns the new level of line, whose original level is old, and makes
+// it the previous line. It returns -1 if the indentation of line is kept as it
+// is.
+func (r *reindenter) level(line string, old int) int {
+	if r.rules != nil && r.match(r.rules.UnIndentedLinePattern, line) {
+		return -1
+	}
+
+	base, relative := r.prevLevel, true
+	switch {
+	case r.prev == "":
+		// no previous line.
+	case r.increases(r.prev) || r.indentsNext(r.prev):
+		base, relative = base+1, false
+	case r.indentsNext(r.prevPrev) && !r.increases(r.prevPrev):
+		// the previous line is the single line indented by the line before it.
+		base, relative = base-1, false
+	}
+
+	level := base
+	if relative {
+		level = r.prevLevel + old - r.prevOld
+	}
+	if r.decreases(line) {
+		level = min(level, base-1)
+	}
+	level = max(0, level)
+
+	r.prevPrev, r.prev = r.prev, line
+	r.prevLevel, r.prevOld = level, old
+	return level
+}
+
+// newReindenter returns a reindenter of the lines starting at lineIdx, whose
+// original levels are measured in unit columns. The non-blank lines before
+// lineIdx are the previous lines.
+func (e *Editor) newReindenter(lineIdx, unit int) *reindenter {
+	r := &reindenter{e: e}
+	if e.lang != nil {
+		r.rules = &e.lang.IndentationRules
+	}
+
+	found := e.prevLines(lineIdx)
+	if len(found) > 0 {
+		r.prev = found[0]
+		// prev keeps its indentation, so its new and original levels are
+		// the same.
+		r.prevOld = indentWidth(r.prev, e.text.TabWidth) / unit
+		r.prevLevel = r.prevOld
+	}
+	if len(found) > 1 {
+		r.prevPrev = found[1]
+	}
+	return r
+}
+
+// prevLines returns the two non-blank lines before lineIdx, the nearest first.
+func (e *Editor) prevLines(lineIdx int) []string {
+	var found []string
+	for i := lineIdx - 1; i >= 0 && len(found) < 2; i-- {
+		if line := e.lineText(i); strings.TrimSpace(line) != "" {
+			found = append(found, line)
+		}
+	}
+	return found
+}
+
+// lineText returns the text of the logical line at lineIdx, without the line
+// break.
+func (e *Editor) lineText(lineIdx int) string {
+	start, end := e.text.RangeOfLines(lineIdx, 1, false)
+	return strings.TrimSuffix(e.textRange(start, end), "\n")
+}
+
+// ReindentLines recomputes the indentation of the lines covered by the
+// selection from the indentation rules of the language, or from the brackets if
+// there are no rules. The lines matching no rule keep their indentation relative
+// to the previous line. The indentation is rewritten with the indentation of
+// the editor, converting between tabs and spaces. It reports whether the text
+// is changed.
+func (e *Editor) ReindentLines() bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	selStart, selEnd := e.text.Selection()
+	b := e.selectedLineBlock()
+	firstLine, _ := e.text.FindParagraph(b.start)
+	// the previous lines are measured in the unit of the lines, so that the
+	// lines keep their indentation relative to them.
+	context := append(e.prevLines(firstLine), b.String())
+	unit := e.indentUnit(strings.Join(context, "\n"))
+	r := e.newReindenter(firstLine, unit)
+
+	var edits []offsetEdit
+	lineOff := b.start
+	for i, line := range b.lines {
+		lineLen := utf8.RuneCountInString(line)
+		indent := leadingSpaces(line)
+		content := line[len(indent):]
+
+		var newIndent string
+		switch {
+		case content == "":
+			// blank lines are cleared.
+		default:
+			width := indentWidth(line, e.text.TabWidth)
+			if level := r.level(line, width/unit); level >= 0 {
+				newIndent = e.makeIndent(level, width%unit)
+			} else {
+				newIndent = indent
+			}
+		}
+
+		if newIndent != indent {
+			b.lines[i] = newIndent + content
+			edits = append(edits, offsetEdit{
+				pos:      lineOff,
+				deleted:  utf8.RuneCountInString(indent),
+				inserted: utf8.RuneCountInString(newIndent),
+			})
+		}
+		lineOff += lineLen + 1
+	}
+	if len(edits) == 0 {
+		return false
+	}
+
+	e.replaceLineBlock(b)
+	e.mapSelection(selStart, selEnd, edits)
+	return true
+}
+
+// ConvertIndentation rewrites the indentation of all the lines with tabs or
+// spaces of TabWidth columns, keeping the width of the indentation, and makes
+// style the indentation of the editor. It reports whether the text is changed.
+func (e *Editor) ConvertIndentation(style TabStyle) bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	e.text.SoftTab = style == Spaces
+	tabWidth := max(1, e.text.TabWidth)
+	selStart, selEnd := e.text.Selection()
+	b := e.readLineBlock(0, e.text.Len())
+
+	var edits []offsetEdit
+	lineOff := 0
+	for i, line := range b.lines {
+		lineLen := utf8.RuneCountInString(line)
+		indent := leadingSpaces(line)
+		width := indentWidth(line, tabWidth)
+		newIndent := e.makeIndent(width/tabWidth, width%tabWidth)
+		if newIndent != indent {
+			b.lines[i] = newIndent + line[len(indent):]
+			edits = append(edits, offsetEdit{
+				pos:      lineOff,
+				deleted:  utf8.RuneCountInString(indent),
+				inserted: utf8.RuneCountInString(newIndent),
+			})
+		}
+		lineOff += lineLen + 1
+	}
+	if len(edits) == 0 {
+		return false
+	}
+
+	e.replaceLineBlock(b)
+	e.mapSelection(selStart, selEnd, edits)
+	return true
+}
+
+// reindentPaste reindents the multi-line text pasted at the caret, so that its
+// first line is indented as the line of the caret and the other lines keep
+// their indentation relative to the first line. It returns the range to
+// replace, which includes the indentation of the line if the caret is in it.
+func (e *Editor) reindentPaste(text string) (string, int, int) {
+	start, end := e.text.Selection()
+	start = min(start, end)
+	lineIdx, p := e.text.FindParagraph(start)
+	before := e.textRange(p.RuneOff, start)
+
+	lines := strings.Split(text, "\n")
+	unit := e.indentUnit(text)
+	oldLevels := make([]int, len(lines))
+	extras := make([]int, len(lines))
+	for i, line := range lines {
+		width := indentWidth(line, e.text.TabWidth)
+		oldLevels[i], extras[i] = width/unit, width%unit
+	}
+
+	// the first line may be copied without its indentation, which is then
+	// guessed from the other lines.
+	firstOld := oldLevels[0]
+	if leadingSpaces(lines[0]) == "" {
+		r := e.newReindenter(0, unit)
+		minLevel, closed := -1, false
+		for i, line := range lines[1:] {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if minLevel < 0 || oldLevels[i+1] < minLevel {
+				minLevel, closed = oldLevels[i+1], false
+			}
+			if oldLevels[i+1] == minLevel && r.decreases(line) {
+				closed = true
+			}
+		}
+		if minLevel > 0 {
+			// the lines after an opening line are indented deeper than it,
+			// unless the block is closed in the text.
+			if r.increases(lines[0]) && !closed {
+				minLevel--
+			}
+			firstOld = minLevel
+		}
+	}
+
+	replaceStart, replaceEnd := start, max(start, end)
+	var target int
+	var indent string
+	if strings.TrimSpace(before) == "" {
+		// the caret is in the indentation, which is replaced.
+		indent = leadingSpaces(e.lineText(lineIdx))
+		replaceStart = p.RuneOff
+		replaceEnd = max(replaceEnd, p.RuneOff+utf8.RuneCountInString(indent))
+		if indent != "" {
+			target = indentWidth(indent, e.text.TabWidth) / max(1, e.text.TabWidth)
+		} else {
+			// the previous lines are in the document, whose levels are
+			// those of the editor.
+			r := e.newReindenter(lineIdx, max(1, e.text.TabWidth))
+			// only the rules decide the level of the first line.
+			r.prevOld = firstOld
+			target = r.level(lines[0], firstOld)
+			if target < 0 {
+				target = firstOld
+			}
+		}
+		lines[0] = e.makeIndent(target, 0) + strings.TrimLeft(lines[0], " \t")
+		if lines[len(lines)-1] == "" {
+			// the rest of the line follows the text with its indentation.
+			lines[len(lines)-1] = indent
+		}
+	} else {
+		target = indentWidth(before, e.text.TabWidth) / max(1, e.text.TabWidth)
+		lines[0] = strings.TrimLeft(lines[0], " \t")
+	}
+
+	delta := target - firstOld
+	for i := 1; i < len(lines); i++ {
+		if i == len(lines)-1 && lines[i] == indent {
+			break
+		}
+		content := strings.TrimLeft(lines[i], " \t")
+		if content == "" {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = e.makeIndent(oldLevels[i]+delta, extras[i]) + content
+	}
+	return strings.Join(lines, "\n"), replaceStart, replaceEnd
+}
+
+// pasteReindented replaces the selection with the reindented text, and places
+// the caret after it.
+func (e *Editor) pasteReindented(text string) int {
+	text, replaceStart, replaceEnd := e.reindentPaste(text)
+	moves := e.replace(replaceStart, replaceEnd, text)
+	e.text.MoveCaret(0, 0)
+	e.SetCaret(replaceStart+moves, replaceStart+moves)
+	e.scrollCaret = true
+	return moves
+}
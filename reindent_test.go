@@ -0,0 +1,231 @@
+package gvcode
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"gioui.org/io/key"
+	"github.com/oligo/gvcode/language"
+)
+
+// pythonLanguage returns a language with indentation rules.
+func pythonLanguage() *language.Config {
+	cfg := &language.Config{ID: "python"}
+	cfg.IndentationRules.IncreaseIndentPattern = regexp.MustCompile(`:\s*$`)
+	cfg.IndentationRules.DecreaseIndentPattern = regexp.MustCompile(`^\s*(else|elif|except|finally)\b`)
+	return cfg
+}
+
+// setCaretMarker removes the "|" marking the caret from input and returns the
+// text with the caret offset.
+func setCaretMarker(input string) (string, int) {
+	caret := strings.Index(input, "|")
+	return strings.Replace(input, "|", "", 1), caret
+}
+
+func TestReindentPaste(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		paste string
+		want  string
+	}{
+		{
+			name:  "at depth 1",
+			input: "func a() {\n    |\n}",
+			paste: "if x {\n    y()\n}",
+			want:  "func a() {\n    if x {\n        y()\n    }\n}",
+		},
+		{
+			name:  "at depth 2",
+			input: "func a() {\n    if b {\n        |\n    }\n}",
+			paste: "if x {\n    y()\n}",
+			want:  "func a() {\n    if b {\n        if x {\n            y()\n        }\n    }\n}",
+		},
+		{
+			name:  "copied from a deeper block",
+			input: "func a() {\n    |\n}",
+			paste: "            if x {\n                y()\n            }",
+			want:  "func a() {\n    if x {\n        y()\n    }\n}",
+		},
+		{
+			name:  "first line copied without its indentation",
+			input: "func a() {\n    |\n}",
+			paste: "if x {\n                y()\n            }",
+			want:  "func a() {\n    if x {\n        y()\n    }\n}",
+		},
+		{
+			name:  "blank line after an opening line",
+			input: "func a() {\n|\n}",
+			paste: "x()\ny()",
+			want:  "func a() {\n    x()\n    y()\n}",
+		},
+		{
+			name:  "after text",
+			input: "func a() {\n    x := |\n}",
+			paste: "[]int{\n    1,\n}",
+			want:  "func a() {\n    x := []int{\n        1,\n    }\n}",
+		},
+		{
+			name:  "tabs converted to spaces",
+			input: "func a() {\n    |\n}",
+			paste: "if x {\n\ty()\n}",
+			want:  "func a() {\n    if x {\n        y()\n    }\n}",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			input, caret := setCaretMarker(tc.input)
+			e := newTestEditor(t, input)
+			// the indentation guessed from the text is overridden.
+			e.text.SoftTab, e.text.TabWidth = true, 4
+			e.SetCaret(caret, caret)
+			e.pasteReindented(tc.paste)
+			if got := e.Text(); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReindentLines(t *testing.T) {
+	testcases := []struct {
+		name  string
+		lang  *language.Config
+		input string
+		// line is the first line reindented.
+		line int
+		want string
+	}{
+		{
+			name:  "brackets",
+			input: "func a() {\nx()\nif b {\ny()\n}\n}",
+			want:  "func a() {\n    x()\n    if b {\n        y()\n    }\n}",
+		},
+		{
+			name:  "relative indentation kept",
+			input: "func a() {\nx()\n    y()\nz()\n}",
+			want:  "func a() {\n    x()\n        y()\n    z()\n}",
+		},
+		{
+			name:  "brackets keep the relative indentation",
+			input: "f(a,\n    b)\ng()",
+			want:  "f(a,\n    b)\ng()",
+		},
+		{
+			name:  "rules",
+			lang:  pythonLanguage(),
+			input: "if a:\nx = 1\nelse:\n        y = 2\nz = 3",
+			want:  "if a:\n    x = 1\nelse:\n    y = 2\nz = 3",
+		},
+		{
+			name:  "rules override the brackets",
+			lang:  pythonLanguage(),
+			input: "x = [\n    1,\n]\ny = 2",
+			want:  "x = [\n    1,\n]\ny = 2",
+		},
+		{
+			name:  "brackets without rules",
+			input: "x = [\n1,\n]\ny = 2",
+			want:  "x = [\n    1,\n]\ny = 2",
+		},
+		{
+			// the previous lines are measured in the unit of the lines.
+			name:  "after unselected lines",
+			input: "if a {\n  x()\n  if b {\n  y()\n  }\n}",
+			line:  2,
+			want:  "if a {\n  x()\n    if b {\n        y()\n    }\n}",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEditor(t, tc.input)
+			e.text.SoftTab, e.text.TabWidth = true, 4
+			if tc.lang != nil {
+				e.SetLanguage(tc.lang)
+			}
+			start, _ := e.text.RangeOfLines(tc.line, 1, false)
+			e.SetCaret(start, e.Len())
+			e.ReindentLines()
+			if got := e.Text(); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestConvertIndentation(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		style TabStyle
+		want  string
+	}{
+		{
+			name:  "tabs to spaces",
+			input: "a {\n\tb {\n\t\tc\n\t}\n}",
+			style: Spaces,
+			want:  "a {\n    b {\n        c\n    }\n}",
+		},
+		{
+			name:  "spaces to tabs",
+			input: "a {\n    b {\n        c\n    }\n}",
+			style: Tabs,
+			want:  "a {\n\tb {\n\t\tc\n\t}\n}",
+		},
+		{
+			name:  "mixed to tabs",
+			input: "a\n  \tb\n\t    c",
+			style: Tabs,
+			want:  "a\n\tb\n\t\tc",
+		},
+		{
+			name:  "extra spaces kept",
+			input: "a\n\t  b",
+			style: Spaces,
+			want:  "a\n      b",
+		},
+		{
+			name:  "extra spaces after tabs",
+			input: "a\n      b",
+			style: Tabs,
+			want:  "a\n\t  b",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEditor(t, tc.input)
+			e.text.TabWidth = 4
+			e.ConvertIndentation(tc.style)
+			if got := e.Text(); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+			if got := e.text.SoftTab; got != (tc.style == Spaces) {
+				t.Errorf("want soft tab %v, got %v", tc.style == Spaces, got)
+			}
+		})
+	}
+}
+
+func TestReindentCommands(t *testing.T) {
+	w := newTestWindow(t, "a {\nb\n}")
+	w.editor.text.SoftTab = true
+	w.editor.SetCaret(0, w.editor.Len())
+	w.press("I", key.ModShortcut|key.ModAlt)
+	if got, want := w.editor.Text(), "a {\n    b\n}"; got != want {
+		t.Fatalf("Ctrl+Alt+I: want %q, got %q", want, got)
+	}
+
+	w.press("I", key.ModShortcut|key.ModAlt|key.ModShift)
+	if got, want := w.editor.Text(), "a {\n\tb\n}"; got != want || w.editor.text.SoftTab {
+		t.Fatalf("Ctrl+Alt+Shift+I: want %q with tabs, got %q", want, got)
+	}
+	w.press("I", key.ModShortcut|key.ModAlt|key.ModShift)
+	if got, want := w.editor.Text(), "a {\n    b\n}"; got != want || !w.editor.text.SoftTab {
+		t.Fatalf("Ctrl+Alt+Shift+I: want %q with spaces, got %q", want, got)
+	}
+}
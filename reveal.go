@@ -0,0 +1,99 @@
+package gvcode
+
+import (
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/oligo/gvcode/textstyle/decoration"
+	"github.com/oligo/gvcode/textview"
+)
+
+// RevealPolicy specifies how RevealRange scrolls a range into view.
+type RevealPolicy uint8
+
+const (
+	// RevealIfOffscreen scrolls only if the range is not fully visible, and
+	// centers it in that case.
+	RevealIfOffscreen RevealPolicy = iota
+	// RevealCenter centers the range vertically.
+	RevealCenter
+	// RevealTop places the range at the top of the viewport.
+	RevealTop
+
+	// RevealFlash can be combined with the other policies to flash the range
+	// with a temporary background after revealing it.
+	RevealFlash RevealPolicy = 1 << 7
+)
+
+const (
+	// revealFlashSource is the source of the flash decoration.
+	revealFlashSource   = "reveal-flash"
+	revealFlashDuration = 600 * time.Millisecond
+)
+
+// revealState tracks a pending reveal, which is applied in the next layout when
+// the size of the viewport is known.
+type revealState struct {
+	pending    bool
+	start, end int
+	policy     RevealPolicy
+	// flashUntil is the time the running flash ends at.
+	flashUntil time.Time
+	flashing   bool
+}
+
+// RevealRange scrolls the editor in the next layout to show the rune range
+// [start, end) as specified by policy. The selection is not changed.
+func (e *Editor) RevealRange(start, end int, policy RevealPolicy) {
+	e.initBuffer()
+	e.reveal.pending = true
+	e.reveal.start = min(start, end)
+	e.reveal.end = max(start, end)
+	e.reveal.policy = policy
+	e.scroller.Stop()
+}
+
+// applyReveal applies the pending reveal and ends the running flash when it
+// is due. It overrides the scroll to the caret of the same frame.
+func (e *Editor) applyReveal(gtx layout.Context) {
+	r := &e.reveal
+	if r.flashing && !gtx.Now.Before(r.flashUntil) {
+		r.flashing = false
+		e.text.ClearDecorations(revealFlashSource)
+	}
+	if !r.pending {
+		return
+	}
+	r.pending = false
+
+	var align textview.ScrollAlign
+	switch r.policy &^ RevealFlash {
+	case RevealCenter:
+		align = textview.ScrollCenter
+	case RevealTop:
+		align = textview.ScrollTop
+	default:
+		align = textview.ScrollIfOffscreen
+	}
+	e.text.ScrollToRange(r.start, r.end, align)
+
+	if r.policy&RevealFlash == 0 || r.start == r.end || e.colorPalette == nil {
+		return
+	}
+	flashColor := e.colorPalette.SelectColor
+	if !flashColor.IsSet() {
+		flashColor = e.colorPalette.Foreground.MulAlpha(0x60)
+	}
+	e.text.ClearDecorations(revealFlashSource)
+	e.text.AddDecorations(decoration.Decoration{
+		Source:     revealFlashSource,
+		Priority:   10,
+		Start:      r.start,
+		End:        r.end,
+		Background: &decoration.Background{Color: flashColor},
+	})
+	r.flashing = true
+	r.flashUntil = gtx.Now.Add(revealFlashDuration)
+	gtx.Execute(op.InvalidateCmd{At: r.flashUntil})
+}
@@ -38,7 +38,7 @@ func newSnippetContext(editor *Editor) *snippetContext {
 	// register a key command used to quit the snippet mode when pressed.
 	editor.RegisterCommand(sc, key.Filter{Name: key.NameEscape},
 		func(gtx layout.Context, evt key.Event) EditorEvent {
-			editor.setMode(ModeNormal)
+			editor.restoreEditingMode()
 			return nil
 		})
 	return sc
@@ -86,7 +86,7 @@ func (sc *snippetContext) NextTabStop() error {
 
 	if sc.currentIdx >= sc.state.TabStopSize() || currentTabStop.IsFinal() {
 		// Reached the end of the tabstops
-		sc.editor.setMode(ModeNormal)
+		sc.editor.restoreEditingMode()
 	}
 
 	return nil
@@ -105,7 +105,7 @@ func (sc *snippetContext) PrevTabStop() error {
 		return nil
 	} else {
 		// Reached the end of the tabstops
-		sc.editor.setMode(ModeNormal)
+		sc.editor.restoreEditingMode()
 		return nil
 	}
 }
@@ -121,7 +121,7 @@ func (sc *snippetContext) OnInsertAt(runeStart, runeEnd int) {
 
 	start, end := sc.getTabStopPosition(sc.currentIdx)
 	if runeStart < start || runeEnd > end+1 {
-		sc.editor.setMode(ModeNormal)
+		sc.editor.restoreEditingMode()
 	}
 
 }
@@ -0,0 +1,120 @@
+package gvcode
+
+import (
+	stdColor "image/color"
+
+	"gioui.org/layout"
+	"github.com/oligo/gvcode/color"
+	"github.com/oligo/gvcode/textview"
+)
+
+// stickyScroll pins the header lines of the scopes enclosing the top visible
+// line at the top of the viewport.
+type stickyScroll struct {
+	// maxLines limits the number of pinned lines. Sticky scroll is disabled
+	// if it is zero.
+	maxLines int
+	kind     textview.ScopeKind
+	provider textview.ScopeProvider
+	// lines are the logical lines pinned in the last frame.
+	lines []int
+	// height is the height of the pinned area in the last frame.
+	height int
+}
+
+func (s *stickyScroll) scopes(text *textview.TextView, line int) []int {
+	if s.provider != nil {
+		return s.provider(line)
+	}
+	return text.EnclosingScopes(line, s.kind)
+}
+
+// update computes the pinned lines for the current scroll position. A header is
+// pinned at a slot only if the header line has scrolled above the slot.
+func (s *stickyScroll) update(text *textview.TextView) {
+	s.lines = s.lines[:0]
+	if s.maxLines <= 0 || text.Len() == 0 {
+		return
+	}
+
+	lineHeight := text.GetLineHeight().Ceil()
+	// The pinned lines cover the top lines of the viewport, so the scopes are
+	// re-evaluated for the first line below them until they are stable.
+	for range s.maxLines + 1 {
+		scopes := s.scopes(text, text.LineAt(len(s.lines)*lineHeight))
+		if len(scopes) > s.maxLines {
+			scopes = scopes[len(scopes)-s.maxLines:]
+		}
+
+		n := 0
+		for i, header := range scopes {
+			if text.LineTop(header) >= i*lineHeight {
+				break
+			}
+			n++
+		}
+
+		if n == len(s.lines) {
+			break
+		}
+		s.lines = append(s.lines[:0], scopes[:n]...)
+	}
+}
+
+// lineAt returns the pinned line at y, or -1 if y is not in the pinned area.
+func (s *stickyScroll) lineAt(text *textview.TextView, y int) (line int, slot int) {
+	if s.height <= 0 || y < 0 || y >= s.height || len(s.lines) == 0 {
+		return -1, -1
+	}
+
+	slot = min(y/max(1, text.GetLineHeight().Ceil()), len(s.lines)-1)
+	return s.lines[slot], slot
+}
+
+// paintStickyLines paints the pinned header lines over the text.
+func (e *Editor) paintStickyLines(gtx layout.Context, textColor color.Color) {
+	e.sticky.height = 0
+	if e.sticky.maxLines <= 0 {
+		return
+	}
+
+	e.sticky.update(e.text)
+	if len(e.sticky.lines) == 0 {
+		return
+	}
+
+	bgColor := stickyBackground(e.colorPalette.Background, textColor)
+	borderColor := textColor.MulAlpha(0x40)
+	e.sticky.height = e.text.PaintStickyLines(gtx, e.sticky.lines, textColor.Op(gtx.Ops),
+		bgColor.Op(gtx.Ops), borderColor.Op(gtx.Ops))
+}
+
+// stickyBackground returns the background of the pinned lines, which hides the
+// text scrolled under them. If the color palette has no background, it is white
+// behind dark text, and black behind light text.
+func stickyBackground(bg, textColor color.Color) color.Color {
+	if bg.IsSet() {
+		return bg
+	}
+	c := textColor.NRGBA()
+	// the luma of the text color.
+	if 299*int(c.R)+587*int(c.G)+114*int(c.B) < 128*1000 {
+		return color.MakeColor(stdColor.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF})
+	}
+	return color.MakeColor(stdColor.NRGBA{A: 0xFF})
+}
+
+// jumpToStickyLine moves the caret to the pinned line at y, and scrolls the line
+// to its slot. It reports whether y is in the pinned area.
+func (e *Editor) jumpToStickyLine(y int) bool {
+	line, slot := e.sticky.lineAt(e.text, y)
+	if line < 0 {
+		return false
+	}
+
+	start, _ := e.text.RangeOfLines(line, 1, false)
+	e.text.SetCaret(start, start)
+	lineHeight := e.text.GetLineHeight().Ceil()
+	e.text.ScrollRel(0, e.text.LineTop(line)-slot*lineHeight)
+	return true
+}
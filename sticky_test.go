@@ -0,0 +1,45 @@
+package gvcode
+
+import (
+	stdColor "image/color"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/oligo/gvcode/color"
+	"github.com/oligo/gvcode/textview"
+)
+
+func TestStickyBackground(t *testing.T) {
+	white := color.MakeColor(stdColor.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF})
+	black := color.MakeColor(stdColor.NRGBA{A: 0xFF})
+	gray := color.MakeColor(stdColor.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xFF})
+
+	testcases := []struct {
+		bg, text color.Color
+		want     color.Color
+	}{
+		{bg: gray, text: black, want: gray},
+		{text: black, want: white},
+		{text: color.MakeColor(stdColor.NRGBA{R: 0x20, G: 0x20, B: 0x60, A: 0xFF}), want: white},
+		{text: white, want: black},
+		{text: color.MakeColor(stdColor.NRGBA{R: 0xD4, G: 0xD4, B: 0xD4, A: 0xFF}), want: black},
+	}
+
+	for i, tc := range testcases {
+		if got := stickyBackground(tc.bg, tc.text); got != tc.want {
+			t.Errorf("case %d: want %v, got %v", i, tc.want, got)
+		}
+	}
+}
+
+func TestStickyLinesWithoutBackground(t *testing.T) {
+	input := "func a() {\n" + strings.Repeat("\tx()\n", 100) + "}\n"
+	w := newTestWindow(t, input, WithStickyScroll(3, textview.ScopeByIndentation))
+	w.editor.text.ScrollRel(0, 200)
+	w.frame()
+
+	if w.editor.sticky.height <= 0 || !slices.Equal(w.editor.sticky.lines, []int{0}) {
+		t.Errorf("want the header pinned, got lines %v, height %d", w.editor.sticky.lines, w.editor.sticky.height)
+	}
+}
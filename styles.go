@@ -33,4 +33,12 @@ func (e *Editor) SetSyntaxTokens(tokens ...syntax.Token) {
 		return
 	}
 	e.text.SetSyntaxTokens(tokens...)
+	e.bracketChecker.MarkDirty()
+}
+
+// SyntaxTokens returns the styles of the syntax tokens overlapping the rune range
+// [start, end). Colors of the styles can be resolved using the color palette.
+func (e *Editor) SyntaxTokens(start, end int) []syntax.TokenStyle {
+	e.initBuffer()
+	return e.text.QuerySyntaxTokens(start, end)
 }
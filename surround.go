@@ -0,0 +1,191 @@
+package gvcode
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gioui.org/io/key"
+	"github.com/oligo/gvcode/language"
+)
+
+// WithSurroundingPairs configures extra pairs that surround the selection when
+// the text of the key is typed, like "*" for "/*" and "*/". They take
+// precedence over the brackets, the quotes and the surrounding pairs of the
+// language.
+func WithSurroundingPairs(pairs map[string]language.Pair) EditorOption {
+	return func(e *Editor) {
+		e.initBuffer()
+		e.surroundPairs = pairs
+	}
+}
+
+// surroundingPair returns the pair that surrounds the selection when s is
+// typed.
+func (e *Editor) surroundingPair(s string) (language.Pair, bool) {
+	if p, ok := e.surroundPairs[s]; ok {
+		return p, true
+	}
+	if e.lang != nil && len(e.lang.SurroundingPairs) > 0 {
+		return e.lang.SurroundingPair(s)
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) {
+		return language.Pair{}, false
+	}
+	counterpart, isOpening := e.text.BracketsQuotes.GetCounterpart(r)
+	if counterpart == 0 || !isOpening {
+		return language.Pair{}, false
+	}
+	return language.Pair{Open: s, Close: string(counterpart)}, true
+}
+
+// surroundSelection surrounds the selection with the pair opened by the typed
+// text, if the input replaces the selection. It reports whether the selection
+// is surrounded.
+func (e *Editor) surroundSelection(ke key.EditEvent) bool {
+	start, end := e.text.Selection()
+	if start == end || min(start, end) != min(ke.Range.Start, ke.Range.End) ||
+		max(start, end) != max(ke.Range.Start, ke.Range.End) {
+		return false
+	}
+
+	pair, ok := e.surroundingPair(ke.Text)
+	if !ok {
+		return false
+	}
+	return e.Surround(pair)
+}
+
+// Surround encloses the selection with the opening and closing text of pair,
+// and keeps the inner text selected. It reports whether the text is changed.
+func (e *Editor) Surround(pair language.Pair) bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly || pair.Open == "" {
+		return false
+	}
+
+	start, end := e.text.Selection()
+	if start == end {
+		return false
+	}
+	lo, hi := min(start, end), max(start, end)
+	e.replace(lo, hi, pair.Open+e.textRange(lo, hi)+pair.Close)
+
+	openLen := utf8.RuneCountInString(pair.Open)
+	e.shiftSelection(start, end, openLen)
+	return true
+}
+
+// SurroundWithTag encloses the selection with the HTML tag, which may have
+// attributes, like `a href="#"`. The closing tag uses the tag name only.
+func (e *Editor) SurroundWithTag(tag string) bool {
+	tag = strings.TrimSpace(tag)
+	fields := strings.Fields(tag)
+	if len(fields) == 0 {
+		return false
+	}
+	return e.Surround(language.Pair{Open: "<" + tag + ">", Close: "</" + fields[0] + ">"})
+}
+
+// nearestSurround finds the innermost pair of brackets or quotes around the
+// selection, returning the rune offsets of its opening and closing halves, or
+// -1 if there is none. Only the quotes on the line of the selection start are
+// searched.
+func (e *Editor) nearestSurround() (left, right int) {
+	start, end := e.text.Selection()
+	lo, hi := min(start, end), max(start, end)
+
+	// the brackets at the selection edges, like the ones after or before the
+	// caret, do not enclose it.
+	left, right = e.text.NearestMatchingBracketsAt(lo)
+	if left < 0 || right < 0 || left >= lo || right < hi {
+		left, right = -1, -1
+	}
+
+	if qLeft, qRight := e.enclosingQuotes(lo); qLeft > left && qRight >= hi {
+		left, right = qLeft, qRight
+	}
+	return left, right
+}
+
+// enclosingQuotes finds the quotes on the line of runeOff enclosing it. A
+// quote preceded by a backslash is escaped.
+func (e *Editor) enclosingQuotes(runeOff int) (left, right int) {
+	left, right = -1, -1
+	lineIdx, p := e.text.FindParagraph(runeOff)
+	line := []rune(e.lineText(lineIdx))
+	col := runeOff - p.RuneOff
+
+	var closing rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+			continue
+		case r == '\\':
+			escaped = true
+			continue
+		}
+
+		if left >= 0 && r == closing {
+			if i >= col {
+				return p.RuneOff + left, p.RuneOff + i
+			}
+			left = -1
+			continue
+		}
+		if i >= col {
+			if left < 0 {
+				break
+			}
+			continue
+		}
+		if left < 0 {
+			if c, ok := e.text.BracketsQuotes.GetClosingQuote(r); ok {
+				left, closing = i, c
+			}
+		}
+	}
+	return -1, -1
+}
+
+// ChangeSurround replaces the innermost pair of brackets or quotes around the
+// selection with pair. It reports whether the text is changed.
+func (e *Editor) ChangeSurround(pair language.Pair) bool {
+	e.initBuffer()
+	if e.mode == ModeReadOnly {
+		return false
+	}
+
+	left, right := e.nearestSurround()
+	if left < 0 {
+		return false
+	}
+	return e.replaceSurround(left, right, pair)
+}
+
+// DeleteSurround removes the innermost pair of brackets or quotes around the
+// selection. It reports whether the text is changed.
+func (e *Editor) DeleteSurround() bool {
+	return e.ChangeSurround(language.Pair{})
+}
+
+// replaceSurround replaces the runes at left and right with the halves of pair
+// as a single undo step, keeping the selection on the same text.
+func (e *Editor) replaceSurround(left, right int, pair language.Pair) bool {
+	selStart, selEnd := e.text.Selection()
+	edits := []offsetEdit{
+		{pos: left, deleted: 1, inserted: utf8.RuneCountInString(pair.Open)},
+		{pos: right, deleted: 1, inserted: utf8.RuneCountInString(pair.Close)},
+	}
+
+	e.buffer.GroupOp()
+	e.replace(right, right+1, pair.Close)
+	e.replace(left, left+1, pair.Open)
+	e.buffer.UnGroupOp()
+
+	e.mapSelection(selStart, selEnd, edits)
+	return true
+}
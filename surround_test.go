@@ -0,0 +1,178 @@
+package gvcode
+
+import (
+	"testing"
+
+	"gioui.org/io/key"
+	"github.com/oligo/gvcode/language"
+)
+
+func TestSurround(t *testing.T) {
+	testcases := []struct {
+		name    string
+		input   string
+		sel     TextRange
+		command func(e *Editor) bool
+		want    string
+		wantSel TextRange
+		// unchanged is set if the command is expected to do nothing.
+		unchanged bool
+	}{
+		{
+			name:    "type an opening bracket over the selection",
+			input:   "a bc d",
+			sel:     TextRange{Start: 4, End: 2},
+			command: func(e *Editor) bool { typeText(e, "("); return true },
+			want:    "a (bc) d",
+			wantSel: TextRange{Start: 5, End: 3},
+		},
+		{
+			name:    "type a quote over the selection",
+			input:   "a bc d",
+			sel:     TextRange{Start: 2, End: 4},
+			command: func(e *Editor) bool { typeText(e, `"`); return true },
+			want:    `a "bc" d`,
+			wantSel: TextRange{Start: 3, End: 5},
+		},
+		{
+			name:    "surround with a tag",
+			input:   "a bc d",
+			sel:     TextRange{Start: 2, End: 4},
+			command: func(e *Editor) bool { return e.SurroundWithTag(`a href="#"`) },
+			want:    `a <a href="#">bc</a> d`,
+			wantSel: TextRange{Start: 14, End: 16},
+		},
+		{
+			name:      "surround nothing",
+			input:     "a bc d",
+			sel:       TextRange{Start: 2, End: 2},
+			command:   func(e *Editor) bool { return e.Surround(language.Pair{Open: "(", Close: ")"}) },
+			want:      "a bc d",
+			unchanged: true,
+		},
+		{
+			name:    "change the brackets around the caret",
+			input:   "f(a, [b])",
+			sel:     TextRange{Start: 3, End: 3},
+			command: func(e *Editor) bool { return e.ChangeSurround(language.Pair{Open: "{", Close: "}"}) },
+			want:    "f{a, [b]}",
+			wantSel: TextRange{Start: 3, End: 3},
+		},
+		{
+			name:    "change to a longer pair",
+			input:   "(ab)",
+			sel:     TextRange{Start: 1, End: 3},
+			command: func(e *Editor) bool { return e.ChangeSurround(language.Pair{Open: "/*", Close: "*/"}) },
+			want:    "/*ab*/",
+			wantSel: TextRange{Start: 2, End: 4},
+		},
+		{
+			name:    "delete the innermost brackets",
+			input:   "f(a, [b])",
+			sel:     TextRange{Start: 6, End: 6},
+			command: func(e *Editor) bool { return e.DeleteSurround() },
+			want:    "f(a, b)",
+			wantSel: TextRange{Start: 5, End: 5},
+		},
+		{
+			name:    "delete the quotes around the caret",
+			input:   `f("a(b")`,
+			sel:     TextRange{Start: 4, End: 4},
+			command: func(e *Editor) bool { return e.DeleteSurround() },
+			want:    `f(a(b)`,
+			wantSel: TextRange{Start: 3, End: 3},
+		},
+		{
+			name:    "delete the brackets around the selection",
+			input:   "[(ab)]",
+			sel:     TextRange{Start: 2, End: 4},
+			command: func(e *Editor) bool { return e.DeleteSurround() },
+			want:    "[ab]",
+			wantSel: TextRange{Start: 1, End: 3},
+		},
+		{
+			name:      "the brackets after the caret do not enclose it",
+			input:     "(a)",
+			sel:       TextRange{Start: 0, End: 0},
+			command:   func(e *Editor) bool { return e.DeleteSurround() },
+			want:      "(a)",
+			unchanged: true,
+		},
+		{
+			name:      "the brackets before the caret do not enclose it",
+			input:     "(a)",
+			sel:       TextRange{Start: 3, End: 3},
+			command:   func(e *Editor) bool { return e.DeleteSurround() },
+			want:      "(a)",
+			unchanged: true,
+		},
+		{
+			name:      "the quotes before the caret do not enclose it",
+			input:     `"a" b`,
+			sel:       TextRange{Start: 3, End: 3},
+			command:   func(e *Editor) bool { return e.DeleteSurround() },
+			want:      `"a" b`,
+			unchanged: true,
+		},
+		{
+			name:      "the selection extends past the brackets",
+			input:     "(a) b",
+			sel:       TextRange{Start: 1, End: 5},
+			command:   func(e *Editor) bool { return e.DeleteSurround() },
+			want:      "(a) b",
+			unchanged: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newTestEditor(t, tc.input)
+			e.SetCaret(tc.sel.Start, tc.sel.End)
+
+			if got := tc.command(e); got == tc.unchanged {
+				t.Fatalf("want changed %v, got %v", !tc.unchanged, got)
+			}
+			if got := e.Text(); got != tc.want {
+				t.Fatalf("want text %q, got %q", tc.want, got)
+			}
+			if tc.unchanged {
+				return
+			}
+			if start, end := e.Selection(); (TextRange{Start: start, End: end}) != tc.wantSel {
+				t.Errorf("want selection %v, got %d, %d", tc.wantSel, start, end)
+			}
+
+			// each command is a single undo step.
+			if _, ok := e.undo(); !ok {
+				t.Fatal("nothing to undo")
+			}
+			if got := e.Text(); got != tc.input {
+				t.Errorf("undo: want text %q, got %q", tc.input, got)
+			}
+		})
+	}
+}
+
+func TestSurroundCommands(t *testing.T) {
+	w := newTestWindow(t, "f(a, [b])")
+	w.editor.SetCaret(6, 6)
+	w.press("D", key.ModShortcut|key.ModAlt)
+	if got := w.editor.Text(); got != "f(a, b)" {
+		t.Fatalf("Ctrl+Alt+D: want the brackets deleted, got %q", got)
+	}
+
+	// the typed text opens the new pair.
+	w.press("R", key.ModShortcut|key.ModAlt)
+	typeText(w.editor, "{")
+	if got := w.editor.Text(); got != "f{a, b}" {
+		t.Fatalf("Ctrl+Alt+R: want the brackets changed, got %q", got)
+	}
+
+	// the text typed after Escape is inserted.
+	w.press("R", key.ModShortcut|key.ModAlt)
+	w.press(key.NameEscape, 0)
+	typeText(w.editor, "x")
+	if got := w.editor.Text(); got != "f{a, xb}" {
+		t.Errorf("Escape: want the change cancelled, got %q", got)
+	}
+}
@@ -63,6 +63,15 @@ func (s StyleScope) IsChild(other StyleScope) bool {
 	return other.Parent() == s
 }
 
+// IsWithin checks if s is other, or a sub scope of other at any depth.
+func (s StyleScope) IsWithin(other StyleScope) bool {
+	if !s.IsValid() || !other.IsValid() {
+		return false
+	}
+
+	return s == other || strings.HasPrefix(string(s), string(other)+".")
+}
+
 const (
 	defaultScope = StyleScope("_default_")
 )
@@ -90,3 +90,25 @@ func TestGetTokenStyle(t *testing.T) {
 		})
 	}
 }
+
+func TestScopeIsWithin(t *testing.T) {
+	cases := []struct {
+		scope    string
+		other    string
+		expected bool
+	}{
+		{scope: "string", other: "string", expected: true},
+		{scope: "string.quoted.double", other: "string", expected: true},
+		{scope: "string.quoted", other: "string.quoted.double", expected: false},
+		{scope: "stringx", other: "string", expected: false},
+		{scope: "comment.line", other: "", expected: false},
+	}
+
+	for idx, c := range cases {
+		t.Run(fmt.Sprintf("case-%d: %s", idx, c.scope), func(t *testing.T) {
+			if StyleScope(c.scope).IsWithin(StyleScope(c.other)) != c.expected {
+				t.Fail()
+			}
+		})
+	}
+}
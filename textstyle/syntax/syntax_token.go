@@ -58,6 +58,16 @@ func (t *TextTokens) GetColor(colorID int) color.Color {
 	return t.colorScheme.GetColor(colorID)
 }
 
+// HasTextStyle checks if any of the tokens has one of the text styles in mask.
+func (t *TextTokens) HasTextStyle(mask TextStyle) bool {
+	for _, token := range t.tokens {
+		if token.Style.TextStyle().HasStyle(mask) {
+			return true
+		}
+	}
+	return false
+}
+
 // Query tokens for rune range. start and end are in runes. start is inclusive
 // and end is exclusive. This method assumes the tokens are sorted by start or end
 // in ascending order.
@@ -91,6 +101,21 @@ func (t *TextTokens) QueryRange(start, end int) []TokenStyle {
 	return result
 }
 
+// ScopeAt returns the scope of the token containing the rune at runeOff.
+func (t *TextTokens) ScopeAt(runeOff int) (StyleScope, bool) {
+	tokens := t.QueryRange(runeOff, runeOff+1)
+	if len(tokens) == 0 {
+		return "", false
+	}
+
+	scopeID := tokens[0].Style.TokenType()
+	scopes := t.colorScheme.Scopes()
+	if scopeID < 0 || scopeID >= len(scopes) {
+		return "", false
+	}
+	return scopes[scopeID], true
+}
+
 // AdjustOffsets shifts token positions after a text edit.
 // start and end define the old replaced range (in runes), newEnd = start + inserted runes.
 // Tokens before the edit are unchanged, tokens after are shifted by delta (newEnd - end),
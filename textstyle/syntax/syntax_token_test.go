@@ -3,6 +3,8 @@ package syntax
 import (
 	"reflect"
 	"testing"
+
+	"github.com/oligo/gvcode/color"
 )
 
 func TestTextTokens_QueryRange(t *testing.T) {
@@ -172,3 +174,37 @@ func TestTextTokens_AdjustOffsets(t *testing.T) {
 		})
 	}
 }
+
+func TestTextTokens_ScopeAt(t *testing.T) {
+	scheme := &ColorScheme{}
+	scheme.AddStyle("string", 0, color.Color{}, color.Color{})
+	scheme.AddStyle("comment", 0, color.Color{}, color.Color{})
+	scheme.AddStyle("constant.character.escape", 0, color.Color{}, color.Color{})
+
+	tokens := NewTextTokens(scheme)
+	tokens.Set(
+		Token{Start: 0, End: 4, Scope: "string.quoted.double"},
+		Token{Start: 4, End: 6, Scope: "constant.character.escape"},
+		Token{Start: 6, End: 10, Scope: "string.quoted.double"},
+		Token{Start: 12, End: 20, Scope: "comment.line"},
+	)
+
+	tests := []struct {
+		runeOff  int
+		expected StyleScope
+		ok       bool
+	}{
+		{runeOff: 0, expected: "string", ok: true},
+		{runeOff: 5, expected: "constant.character.escape", ok: true},
+		{runeOff: 9, expected: "string", ok: true},
+		{runeOff: 10, ok: false},
+		{runeOff: 15, expected: "comment", ok: true},
+	}
+
+	for _, tt := range tests {
+		scope, ok := tokens.ScopeAt(tt.runeOff)
+		if scope != tt.expected || ok != tt.ok {
+			t.Errorf("ScopeAt(%d) = %q, %v; want %q, %v", tt.runeOff, scope, ok, tt.expected, tt.ok)
+		}
+	}
+}
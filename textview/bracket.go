@@ -2,6 +2,10 @@ package textview
 
 import (
 	"maps"
+	"slices"
+
+	"github.com/oligo/gvcode/internal/buffer"
+	"github.com/oligo/gvcode/textstyle/syntax"
 )
 
 // Built-in quote pairs used for auto-insertion. This can be
@@ -92,6 +96,12 @@ func (bq *bracketsQuotes) SetQuotes(quotePairs map[rune]rune) {
 	bq.quotePairs.set(quotePairs)
 }
 
+// Reset restores the built-in bracket pairs and quote pairs.
+func (bq *bracketsQuotes) Reset() {
+	bq.quotePairs = nil
+	bq.bracketPairs = nil
+}
+
 // Contains check if r is contained in the configured quotes, and if r is a opening
 // quote.
 func (bq bracketsQuotes) ContainsQuote(r rune) (_ bool, isOpening bool) {
@@ -171,12 +181,18 @@ func (bq *bracketsQuotes) GetClosingQuote(r rune) (rune, bool) {
 
 // NearestMatchingBrackets finds the nearest matching brackets of the caret.
 func (e *TextView) NearestMatchingBrackets() (left int, right int) {
-	left, right = -1, -1
 	start, end := e.Selection()
 	if start != end {
-		return
+		return -1, -1
 	}
+	return e.NearestMatchingBracketsAt(start)
+}
 
+// NearestMatchingBracketsAt finds the nearest matching brackets of the rune
+// offset start. A bracket right after or before start is matched first,
+// otherwise the brackets enclosing start are searched.
+func (e *TextView) NearestMatchingBracketsAt(start int) (left int, right int) {
+	left, right = -1, -1
 	stack := &bracketStack{}
 	stack.reset()
 
@@ -188,6 +204,8 @@ func (e *TextView) NearestMatchingBrackets() (left int, right int) {
 		nearest, _ = e.src.ReadRuneAt(start)
 	}
 
+	// brackets in strings and comments only match the ones in the same scope.
+	scope := e.bracketScope(start)
 	if isBracket, isLeft := e.BracketsQuotes.ContainsBracket(nearest); isBracket {
 		if isLeft {
 			left = start
@@ -207,6 +225,12 @@ func (e *TextView) NearestMatchingBrackets() (left int, right int) {
 			if err != nil {
 				break
 			}
+			if e.bracketScope(offset) != scope {
+				if offset <= 0 {
+					break
+				}
+				continue
+			}
 
 			// Check if next is a opening bracket.
 			if br, ok := e.BracketsQuotes.GetClosingBracket(next); ok {
@@ -246,6 +270,12 @@ func (e *TextView) NearestMatchingBrackets() (left int, right int) {
 			if err != nil {
 				break
 			}
+			if e.bracketScope(offset) != scope {
+				if offset >= e.Len() {
+					break
+				}
+				continue
+			}
 
 			// found left half bracket
 			if _, isOpening := e.BracketsQuotes.ContainsBracket(next); isOpening {
@@ -276,6 +306,105 @@ func (e *TextView) NearestMatchingBrackets() (left int, right int) {
 	return left, right
 }
 
+// EnclosingBrackets finds the innermost pair of brackets enclosing the rune
+// range [start, end), returning the rune offsets of the left and right
+// brackets, or -1 if there is none.
+func (e *TextView) EnclosingBrackets(start, end int) (left int, right int) {
+	depth := 0
+	scope := e.bracketScope(start)
+	for offset := min(start, e.Len()) - 1; offset >= 0; offset-- {
+		next, err := e.src.ReadRuneAt(offset)
+		if err != nil {
+			break
+		}
+		if e.bracketScope(offset) != scope {
+			continue
+		}
+
+		if exists, isOpening := e.BracketsQuotes.ContainsBracket(next); !exists {
+			continue
+		} else if !isOpening {
+			depth++
+			continue
+		}
+		if depth > 0 {
+			depth--
+			continue
+		}
+
+		// an unmatched left bracket.
+		l, r := e.NearestMatchingBracketsAt(offset)
+		if l == offset && r >= end {
+			return l, r
+		}
+	}
+	return -1, -1
+}
+
+// UnbalancedBrackets returns the rune offsets of the brackets without a
+// matching bracket, in the order of the document. The brackets in strings and
+// comments are skipped.
+func (e *TextView) UnbalancedBrackets() []int {
+	var unbalanced []int
+	stack := &bracketStack{}
+	stack.reset()
+
+	reader := buffer.NewRuneReader(e.src, 0, e.src.Len())
+	for offset := 0; ; offset++ {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			break
+		}
+		exists, isOpening := e.BracketsQuotes.ContainsBracket(r)
+		if !exists || e.bracketScope(offset) != "" {
+			continue
+		}
+		if isOpening {
+			stack.push(r, offset)
+			continue
+		}
+
+		// a right bracket closes the nearest matching left bracket, and the
+		// left brackets after it are unbalanced.
+		opening, _ := e.BracketsQuotes.GetOpeningBracket(r)
+		idx := len(stack.idx) - 1
+		for idx >= 0 && stack.idx[idx].r != opening {
+			idx--
+		}
+		for i := len(stack.idx) - 1; i > idx && idx >= 0; i-- {
+			_, pos := stack.pop()
+			unbalanced = append(unbalanced, pos)
+		}
+		if idx >= 0 {
+			stack.pop()
+		} else {
+			unbalanced = append(unbalanced, offset)
+		}
+	}
+
+	for stack.depth() > 0 {
+		_, pos := stack.pop()
+		unbalanced = append(unbalanced, pos)
+	}
+	slices.Sort(unbalanced)
+	return unbalanced
+}
+
+// bracketScope returns the scope of the string or the comment containing the
+// rune at runeOff, or an empty scope if it is not in a string or a comment.
+func (e *TextView) bracketScope(runeOff int) syntax.StyleScope {
+	scope, ok := e.SyntaxScopeAt(runeOff)
+	if !ok {
+		return ""
+	}
+	for _, s := range []syntax.StyleScope{"string", "comment"} {
+		if scope.IsWithin(s) {
+			return s
+		}
+	}
+	return ""
+}
+
 type bracketPos struct {
 	r   rune
 	pos int // rune offset.
@@ -2,10 +2,13 @@ package textview
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 
 	"gioui.org/layout"
 	"gioui.org/text"
+	"github.com/oligo/gvcode/color"
+	"github.com/oligo/gvcode/textstyle/syntax"
 )
 
 func TestNearestMatchingBrackets(t *testing.T) {
@@ -77,3 +80,82 @@ func TestNearestMatchingBrackets(t *testing.T) {
 		})
 	}
 }
+
+func TestEnclosingBrackets(t *testing.T) {
+	view := NewTextView()
+	view.SetText("f(a, [b(c)], d)")
+	view.Layout(layout.Context{}, text.NewShaper())
+
+	cases := []struct {
+		start, end int
+		want       []int
+	}{
+		{start: 8, end: 9, want: []int{7, 9}},
+		{start: 7, end: 10, want: []int{5, 10}},
+		{start: 5, end: 11, want: []int{1, 14}},
+		{start: 3, end: 3, want: []int{1, 14}},
+		{start: 0, end: 15, want: []int{-1, -1}},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("case %d", i), func(t *testing.T) {
+			left, right := view.EnclosingBrackets(tc.start, tc.end)
+			if left != tc.want[0] || right != tc.want[1] {
+				t.Errorf("want %v, got [%d %d]", tc.want, left, right)
+			}
+		})
+	}
+}
+
+func setupBracketView(input string, tokens ...syntax.Token) *TextView {
+	view := NewTextView()
+	scheme := &syntax.ColorScheme{}
+	scheme.AddStyle("string", 0, color.Color{}, color.Color{})
+	scheme.AddStyle("comment", 0, color.Color{}, color.Color{})
+	view.SetColorScheme(scheme)
+	view.SetText(input)
+	view.SetSyntaxTokens(tokens...)
+	view.Layout(layout.Context{}, text.NewShaper())
+	return view
+}
+
+func TestMatchingBracketsSkipStrings(t *testing.T) {
+	// f(")", x) // (
+	view := setupBracketView(`f(")", x) // (`,
+		syntax.Token{Start: 2, End: 5, Scope: "string"},
+		syntax.Token{Start: 10, End: 14, Scope: "comment"},
+	)
+
+	view.SetCaret(1, 1)
+	if left, right := view.NearestMatchingBrackets(); left != 1 || right != 8 {
+		t.Errorf("want [1 8], got [%d %d]", left, right)
+	}
+	if left, right := view.EnclosingBrackets(6, 6); left != 1 || right != 8 {
+		t.Errorf("want [1 8], got [%d %d]", left, right)
+	}
+	if got := view.UnbalancedBrackets(); len(got) != 0 {
+		t.Errorf("want no unbalanced brackets, got %v", got)
+	}
+}
+
+func TestUnbalancedBrackets(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []int
+	}{
+		{input: "f(a[b])", want: nil},
+		{input: "f(a[b)", want: []int{3}},
+		{input: "f(a]", want: []int{1, 3}},
+		{input: "}{", want: []int{0, 1}},
+		{input: "((x)", want: []int{0}},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("case %d", i), func(t *testing.T) {
+			view := setupBracketView(tc.input)
+			if got := view.UnbalancedBrackets(); !slices.Equal(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
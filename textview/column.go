@@ -0,0 +1,275 @@
+package textview
+
+import (
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"golang.org/x/image/math/fixed"
+)
+
+// columnSelection is a rectangular selection of the same visual columns across
+// logical lines. Columns are measured in advances of the space glyph, so hard tabs
+// expanded to tab stops by the layout span multiple columns. The columns of a soft
+// wrapped line continue on its continuation rows, as if the line was not wrapped.
+type columnSelection struct {
+	active               bool
+	anchorLine, headLine int
+	anchorCol, headCol   int
+}
+
+// ColumnRange is the part of a logical line covered by a column selection. Start is
+// on the side of the caret, like the values returned by Selection. StartVirtual and
+// EndVirtual count the columns of the selection beyond the end of the line, which
+// are not backed by any text.
+type ColumnRange struct {
+	Line                     int
+	Start, End               int
+	StartVirtual, EndVirtual int
+}
+
+// ColumnAt returns the logical line and the visual column of the rune offset.
+func (e *TextView) ColumnAt(runeOff int) (line, col int) {
+	e.makeValid()
+	line, _ = e.FindParagraph(runeOff)
+	pos := e.closestToRune(runeOff)
+	return line, e.xToColumn(e.rowStartX(line, pos.LineCol.Line) + pos.X - e.rowXOff(pos.LineCol.Line))
+}
+
+// OffsetAtColumn returns the rune offset closest to the visual column of the logical
+// line. If the line ends before the column, the offset of the line end is returned,
+// with the number of the remaining virtual columns.
+func (e *TextView) OffsetAtColumn(line, col int) (runeOff, virtual int) {
+	e.makeValid()
+	if len(e.layouter.Paragraphs) == 0 {
+		return 0, col
+	}
+	line = max(0, min(line, len(e.layouter.Paragraphs)-1))
+	p := e.layouter.Paragraphs[line]
+
+	colX := e.ColumnX(col)
+	row, rowStart := e.columnRow(line, colX)
+	x := e.rowXOff(row) + colX - rowStart
+	pos := e.layouter.ClosestToXY(x, e.layouter.Lines[row].YOff)
+	runeOff = e.moveByGraphemes(pos.Runes, 0)
+	if runeOff < p.RuneOff {
+		runeOff = p.RuneOff
+	}
+
+	if d := x - e.closestToRune(runeOff).X; d > 0 {
+		virtual = e.xToColumn(d)
+	}
+	return runeOff, virtual
+}
+
+// paragraphRows returns the range of the visual rows of the logical line.
+func (e *TextView) paragraphRows(line int) (first, last int) {
+	p := e.layouter.Paragraphs[line]
+	first = e.screenLineOf(line)
+	last = first
+	for last+1 < len(e.layouter.Lines) && e.layouter.Lines[last+1].RuneOff < p.RuneOff+p.Runes {
+		last++
+	}
+	return first, last
+}
+
+// rowXOff returns the x position of the first glyph of the visual row.
+func (e *TextView) rowXOff(row int) fixed.Int26_6 {
+	if li := e.layouter.Lines[row]; len(li.Glyphs) > 0 {
+		return li.Glyphs[0].X
+	}
+	return 0
+}
+
+// rowStartX returns the distance of the start of the visual row from the start of
+// the logical line, as if the line was not wrapped.
+func (e *TextView) rowStartX(line, row int) fixed.Int26_6 {
+	first, _ := e.paragraphRows(line)
+	var x fixed.Int26_6
+	for i := first; i < row; i++ {
+		x += e.layouter.Lines[i].Width
+	}
+	return x
+}
+
+// columnRow returns the visual row of the logical line showing the distance x
+// from the start of the line, and the distance of the start of the row. Distances
+// past the end of the line fall in its last row.
+func (e *TextView) columnRow(line int, x fixed.Int26_6) (row int, rowStart fixed.Int26_6) {
+	first, last := e.paragraphRows(line)
+	for row = first; row < last; row++ {
+		width := e.layouter.Lines[row].Width
+		if x < rowStart+width {
+			break
+		}
+		rowStart += width
+	}
+	return row, rowStart
+}
+
+func (e *TextView) xToColumn(x fixed.Int26_6) int {
+	advance := e.layouter.SpaceAdvance()
+	if advance <= 0 || x <= 0 {
+		return 0
+	}
+	return int((x + advance/2) / advance)
+}
+
+// pointToColumn converts a point relative to the viewport to a logical line and
+// a visual column.
+func (e *TextView) pointToColumn(pt image.Point) (line, col int) {
+	e.makeValid()
+	x := fixed.I(pt.X + e.scrollOff.X)
+	y := pt.Y + e.scrollOff.Y
+	pos := e.layouter.ClosestToXY(x, y)
+	line, _ = e.FindParagraph(pos.Runes)
+	row := pos.LineCol.Line
+	return line, e.xToColumn(e.rowStartX(line, row) + x - e.rowXOff(row))
+}
+
+// StartColumnSelection starts an empty column selection at the rune offset.
+func (e *TextView) StartColumnSelection(runeOff int) {
+	line, col := e.ColumnAt(runeOff)
+	e.column = columnSelection{active: true, anchorLine: line, headLine: line, anchorCol: col, headCol: col}
+}
+
+// StartColumnSelectionAt starts an empty column selection at the point relative
+// to the viewport. The point can be beyond the end of the line.
+func (e *TextView) StartColumnSelectionAt(pt image.Point) {
+	line, col := e.pointToColumn(pt)
+	e.column = columnSelection{active: true, anchorLine: line, headLine: line, anchorCol: col, headCol: col}
+}
+
+// ExtendColumnSelectionTo moves the head of the column selection to the point
+// relative to the viewport.
+func (e *TextView) ExtendColumnSelectionTo(pt image.Point) {
+	if !e.column.active {
+		return
+	}
+	e.column.headLine, e.column.headCol = e.pointToColumn(pt)
+}
+
+// MoveColumnSelection moves the head of the column selection by lines and columns.
+func (e *TextView) MoveColumnSelection(lines, cols int) {
+	if !e.column.active {
+		return
+	}
+	e.column.headLine = max(0, min(e.column.headLine+lines, e.Paragraphs()-1))
+	e.column.headCol = max(0, e.column.headCol+cols)
+}
+
+// ColumnSelectionActive reports whether there is a column selection.
+func (e *TextView) ColumnSelectionActive() bool {
+	return e.column.active
+}
+
+// ClearColumnSelection removes the column selection.
+func (e *TextView) ClearColumnSelection() {
+	e.column = columnSelection{}
+}
+
+// ColumnRanges returns the parts of the lines covered by the column selection,
+// in line order. The range of the head line comes first.
+func (e *TextView) ColumnRanges() []ColumnRange {
+	if !e.column.active {
+		return nil
+	}
+
+	c := e.column
+	first, last := min(c.anchorLine, c.headLine), max(c.anchorLine, c.headLine)
+	ranges := make([]ColumnRange, 0, last-first+1)
+	for line := first; line <= last; line++ {
+		start, startVirtual := e.OffsetAtColumn(line, c.headCol)
+		end, endVirtual := e.OffsetAtColumn(line, c.anchorCol)
+		rng := ColumnRange{Line: line, Start: start, End: end, StartVirtual: startVirtual, EndVirtual: endVirtual}
+		if line == c.headLine {
+			ranges = append([]ColumnRange{rng}, ranges...)
+		} else {
+			ranges = append(ranges, rng)
+		}
+	}
+	return ranges
+}
+
+// paintColumnSelection paints the rectangles of the column selection, including
+// the virtual columns beyond the ends of the lines.
+func (e *TextView) paintColumnSelection(gtx layout.Context, material op.CallOp) {
+	c := e.column
+	first, last := min(c.anchorLine, c.headLine), max(c.anchorLine, c.headLine)
+	last = min(last, len(e.layouter.Paragraphs)-1)
+	minX := e.ColumnX(min(c.anchorCol, c.headCol))
+	maxX := e.ColumnX(max(c.anchorCol, c.headCol))
+	if minX == maxX {
+		// an empty block is shown by the carets.
+		return
+	}
+
+	defer clip.Rect(image.Rectangle{Max: e.viewSize}).Push(gtx.Ops).Pop()
+	for line := first; line <= last; line++ {
+		// the columns of a wrapped line are split across its rows.
+		firstRow, lastRow := e.paragraphRows(line)
+		var rowStart fixed.Int26_6
+		for row := firstRow; row <= lastRow; row++ {
+			li := e.layouter.Lines[row]
+			rowEnd := rowStart + li.Width
+			if row == lastRow {
+				rowEnd = max(rowEnd, maxX)
+			}
+			lo, hi := max(minX, rowStart), min(maxX, rowEnd)
+			xOff := e.rowXOff(row) - rowStart
+			rowStart += li.Width
+			if lo >= hi {
+				continue
+			}
+
+			rect := image.Rect(
+				(xOff+lo).Round()-e.scrollOff.X, li.YOff-li.Ascent.Ceil()-e.scrollOff.Y,
+				(xOff+hi).Round()-e.scrollOff.X, li.YOff+li.Descent.Ceil()-e.scrollOff.Y,
+			)
+			rect = e.adjustPadding(rect)
+			if rect.Max.Y < 0 || rect.Min.Y > e.viewSize.Y {
+				continue
+			}
+
+			stack := clip.Rect(rect).Push(gtx.Ops)
+			material.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
+			stack.Pop()
+		}
+	}
+}
+
+// PaintColumnCarets paints a caret at the head column of every line of the column
+// selection, including the lines shorter than the column.
+func (e *TextView) PaintColumnCarets(gtx layout.Context, material op.CallOp) {
+	if !e.column.active {
+		return
+	}
+
+	c := e.column
+	first, last := min(c.anchorLine, c.headLine), max(c.anchorLine, c.headLine)
+	last = min(last, len(e.layouter.Paragraphs)-1)
+	carWidth2 := gtx.Dp(e.CaretWidth)
+	x := e.ColumnX(c.headCol)
+	cl := image.Rectangle{Max: e.viewSize}
+	for line := first; line <= last; line++ {
+		row, rowStart := e.columnRow(line, x)
+		li := e.layouter.Lines[row]
+		pos := image.Pt((e.rowXOff(row) + x - rowStart).Round(), li.YOff).Sub(e.scrollOff)
+		carRect := image.Rectangle{
+			Min: pos.Sub(image.Pt(carWidth2, li.Ascent.Ceil())),
+			Max: pos.Add(image.Pt(carWidth2, li.Descent.Ceil())),
+		}
+		carRect = cl.Intersect(carRect)
+		if carRect.Empty() {
+			continue
+		}
+
+		stack := clip.Rect(e.adjustPadding(carRect)).Push(gtx.Ops)
+		material.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+		stack.Pop()
+	}
+}
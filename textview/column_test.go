@@ -0,0 +1,105 @@
+package textview
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+func TestColumnSelection(t *testing.T) {
+	vw := NewTextView()
+	vw.TabWidth = 4
+	vw.TextSize = unit.Sp(14)
+	vw.SetText("    x\n\tx\n\n  \tx\n")
+
+	gtx := layout.Context{}
+	shaper := text.NewShaper()
+	vw.Layout(gtx, shaper)
+
+	// the hard tabs are expanded to the tab stop at column 4.
+	for _, off := range []int{4, 7, 13} {
+		if line, col := vw.ColumnAt(off); col != 4 {
+			t.Errorf("offset %d: want column 4, got line %d, column %d", off, line, col)
+		}
+	}
+
+	vw.StartColumnSelection(13)
+	vw.MoveColumnSelection(-3, 0)
+	want := []ColumnRange{
+		{Line: 0, Start: 4, End: 4},
+		{Line: 1, Start: 7, End: 7},
+		{Line: 2, Start: 9, End: 9, StartVirtual: 4, EndVirtual: 4},
+		{Line: 3, Start: 13, End: 13},
+	}
+	got := vw.ColumnRanges()
+	if len(got) != len(want) {
+		t.Fatalf("want %d ranges, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("range %d: want %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	// the head line comes first.
+	vw.StartColumnSelection(4)
+	vw.MoveColumnSelection(3, -4)
+	got = vw.ColumnRanges()
+	if got[0] != (ColumnRange{Line: 3, Start: 10, End: 13}) {
+		t.Errorf("unexpected head range: %+v", got[0])
+	}
+	if got[3] != (ColumnRange{Line: 2, Start: 9, End: 9, EndVirtual: 4}) {
+		t.Errorf("unexpected range of the empty line: %+v", got[3])
+	}
+
+	vw.ClearColumnSelection()
+	if vw.ColumnSelectionActive() || len(vw.ColumnRanges()) != 0 {
+		t.Error("column selection not cleared")
+	}
+}
+
+func TestColumnSelectionWrappedLines(t *testing.T) {
+	vw := NewTextView()
+	vw.TabWidth = 4
+	vw.TextSize = unit.Sp(14)
+	vw.SetWrapLine(true)
+	vw.SetText("x\n" + strings.Repeat("word ", 40) + "\nx\n")
+
+	gtx := layout.Context{
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(200, 400)),
+	}
+	vw.Layout(gtx, text.NewShaper())
+
+	first, last := vw.paragraphRows(1)
+	if first == last {
+		t.Fatalf("expected the line to be soft wrapped")
+	}
+
+	// a rune on the continuation row is past the columns of the first row.
+	runeOff := vw.layouter.Lines[first+1].RuneOff + 1
+	line, col := vw.ColumnAt(runeOff)
+	if line != 1 || col <= vw.xToColumn(vw.layouter.Lines[first].Width) {
+		t.Fatalf("want a column of line 1 past the first row, got line %d, column %d", line, col)
+	}
+	if got, virtual := vw.OffsetAtColumn(line, col); got != runeOff || virtual != 0 {
+		t.Errorf("want the column at %d, got %d with %d virtual columns", runeOff, got, virtual)
+	}
+
+	// the point is on the continuation row.
+	pos := vw.closestToRune(runeOff)
+	vw.StartColumnSelectionAt(image.Pt(pos.X.Round(), pos.Y))
+	if got := vw.ColumnRanges(); len(got) != 1 || got[0] != (ColumnRange{Line: 1, Start: runeOff, End: runeOff}) {
+		t.Errorf("want the column selection at %d, got %+v", runeOff, got)
+	}
+
+	// the column is past the end of the next line.
+	vw.MoveColumnSelection(1, 0)
+	if got := vw.ColumnRanges(); len(got) != 2 || got[0].Line != 2 || got[0].Start != vw.Len()-1 || got[0].StartVirtual == 0 {
+		t.Errorf("want the head at the end of line 2 with virtual columns, got %+v", got)
+	}
+}
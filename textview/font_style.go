@@ -0,0 +1,150 @@
+package textview
+
+import (
+	"math"
+	"slices"
+
+	"gioui.org/font"
+	lt "github.com/oligo/gvcode/internal/layout"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+// fontStyles resolves the bold and italic styles of the syntax tokens and
+// decorations to font runs, which are used by the layout to shape the styled
+// text with the matching font variants.
+type fontStyles struct {
+	view       *TextView
+	boundaries []int
+	spans      []fontSpan
+	runs       []lt.FontRun
+	// font spans of the syntax tokens before they are replaced.
+	prevSpans []fontSpan
+	// the rune range whose font runs changed since the last layout.
+	dirty                bool
+	dirtyStart, dirtyEnd int
+}
+
+type fontSpan struct {
+	start, end   int
+	bold, italic bool
+}
+
+// FontRuns implements layout.FontRunProvider.
+func (s *fontStyles) FontRuns(start, end int) []lt.FontRun {
+	s.spans = s.spans[:0]
+	if tokens := s.view.syntaxStyles; tokens != nil {
+		s.spans = syntaxFontSpans(tokens, start, end, s.spans)
+	}
+	if decorations := s.view.decorations; decorations != nil {
+		for _, deco := range decorations.QueryRange(start, end) {
+			if deco.Bold || deco.Italic {
+				s.spans = append(s.spans, fontSpan{start: deco.Start, end: deco.End, bold: deco.Bold, italic: deco.Italic})
+			}
+		}
+	}
+
+	s.runs = s.runs[:0]
+	if len(s.spans) == 0 {
+		return nil
+	}
+
+	// Split the overlapping spans at their boundaries, and merge the styles
+	// of the spans covering each piece.
+	s.boundaries = s.boundaries[:0]
+	for _, span := range s.spans {
+		s.boundaries = append(s.boundaries, max(span.start, start), min(span.end, end))
+	}
+	slices.Sort(s.boundaries)
+	s.boundaries = slices.Compact(s.boundaries)
+
+	for i := 0; i+1 < len(s.boundaries); i++ {
+		pieceStart, pieceEnd := s.boundaries[i], s.boundaries[i+1]
+		bold, italic := false, false
+		for _, span := range s.spans {
+			if span.start <= pieceStart && span.end >= pieceEnd {
+				bold = bold || span.bold
+				italic = italic || span.italic
+			}
+		}
+		if !bold && !italic {
+			continue
+		}
+
+		run := lt.FontRun{Start: pieceStart, End: pieceEnd}
+		if bold {
+			run.Weight = font.Bold
+		}
+		if italic {
+			run.Style = font.Italic
+		}
+
+		if n := len(s.runs); n > 0 && s.runs[n-1].End == run.Start &&
+			s.runs[n-1].Weight == run.Weight && s.runs[n-1].Style == run.Style {
+			s.runs[n-1].End = run.End
+			continue
+		}
+		s.runs = append(s.runs, run)
+	}
+
+	return s.runs
+}
+
+// markDirty records that the font runs of the rune range [start, end) changed,
+// so the paragraphs covering it have to be reshaped.
+func (s *fontStyles) markDirty(start, end int) {
+	if !s.dirty {
+		s.dirty = true
+		s.dirtyStart, s.dirtyEnd = start, end
+		return
+	}
+	s.dirtyStart = min(s.dirtyStart, start)
+	s.dirtyEnd = max(s.dirtyEnd, end)
+}
+
+// takeDirty returns and clears the changed rune range.
+func (s *fontStyles) takeDirty() (start, end int, ok bool) {
+	start, end, ok = s.dirtyStart, s.dirtyEnd, s.dirty
+	s.dirty = false
+	s.dirtyStart, s.dirtyEnd = 0, 0
+	return
+}
+
+// syntaxFontSpans appends the bold and italic spans of the syntax tokens
+// overlapping the rune range [start, end) to spans.
+func syntaxFontSpans(tokens *syntax.TextTokens, start, end int, spans []fontSpan) []fontSpan {
+	for _, token := range tokens.QueryRange(start, end) {
+		style := token.Style.TextStyle()
+		if style.HasStyle(syntax.Bold) || style.HasStyle(syntax.Italic) {
+			spans = append(spans, fontSpan{
+				start:  token.Start,
+				end:    token.End,
+				bold:   style.HasStyle(syntax.Bold),
+				italic: style.HasStyle(syntax.Italic),
+			})
+		}
+	}
+	return spans
+}
+
+// changedSpans returns the rune range covering the spans that differ between
+// the sorted spans a and b.
+func changedSpans(a, b []fontSpan) (start, end int, ok bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	if len(a) == 0 && len(b) == 0 {
+		return 0, 0, false
+	}
+
+	start, end = math.MaxInt, 0
+	for _, span := range slices.Concat(a, b) {
+		start = min(start, span.start)
+		end = max(end, span.end)
+	}
+	return start, end, true
+}
@@ -0,0 +1,79 @@
+package textview
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/font/gofont"
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"github.com/oligo/gvcode/color"
+	"github.com/oligo/gvcode/textstyle/decoration"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+func TestFontStyleRelayout(t *testing.T) {
+	vw := NewTextView()
+	vw.TextSize = unit.Sp(14)
+	scheme := &syntax.ColorScheme{}
+	scheme.AddStyle("keyword", syntax.Bold, color.Color{}, color.Color{})
+	scheme.AddStyle("string", 0, color.Color{}, color.Color{})
+	vw.SetColorScheme(scheme)
+	vw.SetText("mmmm\nmmmm\nmmmm")
+
+	gtx := layout.Context{
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(300, 200)),
+	}
+	vw.Layout(gtx, text.NewShaper(text.NoSystemFonts(), text.WithCollection(gofont.Collection())))
+	regular := vw.layouter.Lines[0].Width
+
+	assertDirty := func(want bool, start, end int) {
+		t.Helper()
+		if !vw.valid {
+			t.Fatal("expected the layout to stay valid")
+		}
+		if vw.fontStyles.dirty != want {
+			t.Fatalf("expected dirty %v, got %v", want, vw.fontStyles.dirty)
+		}
+		if want && (vw.fontStyles.dirtyStart != start || vw.fontStyles.dirtyEnd != end) {
+			t.Errorf("expected dirty range [%d %d], got [%d %d]", start, end,
+				vw.fontStyles.dirtyStart, vw.fontStyles.dirtyEnd)
+		}
+		vw.makeValid()
+	}
+
+	// tokens without a font style don't reshape anything.
+	vw.SetSyntaxTokens(syntax.Token{Start: 0, End: 4, Scope: "string"})
+	assertDirty(false, 0, 0)
+
+	vw.SetSyntaxTokens(
+		syntax.Token{Start: 0, End: 4, Scope: "string"},
+		syntax.Token{Start: 5, End: 9, Scope: "keyword"},
+	)
+	assertDirty(true, 5, 9)
+	if vw.layouter.Lines[0].Width != regular || vw.layouter.Lines[1].Width <= regular {
+		t.Errorf("expected only the second line to be bold: %s, %s", vw.layouter.Lines[0], vw.layouter.Lines[1])
+	}
+
+	// the same bold tokens again.
+	vw.SetSyntaxTokens(
+		syntax.Token{Start: 0, End: 4, Scope: "string"},
+		syntax.Token{Start: 5, End: 9, Scope: "keyword"},
+	)
+	assertDirty(false, 0, 0)
+
+	vw.AddDecorations(decoration.Decoration{Source: "test", Start: 10, End: 14, Italic: true})
+	assertDirty(true, 10, 14)
+	vw.ClearDecorations("other")
+	assertDirty(false, 0, 0)
+	vw.ClearDecorations("test")
+	assertDirty(true, 10, 14)
+
+	vw.SetSyntaxTokens()
+	assertDirty(true, 5, 9)
+	if width := vw.layouter.Lines[1].Width; width != regular {
+		t.Errorf("expected the second line to be regular again, got width %v", width)
+	}
+}
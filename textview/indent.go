@@ -3,6 +3,7 @@ package textview
 import (
 	"bufio"
 	"io"
+	"regexp"
 	"slices"
 	"strings"
 	"unicode/utf8"
@@ -160,6 +161,11 @@ func checkIndentLevel(line []byte, tabWidth int) int {
 // of the previous line, it indent the new inserted line with the same size. Furthermore, if the newline
 // if between a pair of brackets, it also insert indented lines between them.
 //
+// If IndentRules is set, the text before the caret decides whether the new line
+// is indented by one more level, and a line after a single indented line goes
+// back to the previous level. If the text after the caret matches the decrease
+// indent pattern of the rules, it is moved to its own line like a right bracket.
+//
 // This is mainly used as the line break handler when Enter or Return is pressed.
 func (e *TextView) IndentOnBreak(s string) int {
 	var lineStart, lineEnd int
@@ -170,25 +176,41 @@ func (e *TextView) IndentOnBreak(s string) int {
 	buf := &strings.Builder{}
 	adjust := 0
 
-	// 1. normal case:
-	buf.WriteString(s)
-	buf.WriteString(strings.Repeat(e.Indentation(), indents))
-
-	// 2. check if we are after inside a brackets pair.
+	// 1. check if we are after inside a brackets pair.
 	leftBracket, rightBracket := e.NearestMatchingBrackets()
 	inBrackets := leftBracket >= 0 && rightBracket > leftBracket &&
 		lineStart <= leftBracket && leftBracket < lineEnd && end <= rightBracket
+
+	// 2. apply the indentation rules.
+	increased, splitAfter := inBrackets, false
+	if rules := e.IndentRules; rules != nil && !inBrackets {
+		lineIdx, p := e.FindParagraph(min(start, end))
+		before := e.runeText(p.RuneOff, min(start, end))
+		after := e.lineTextAfter(max(start, end))
+		indents, increased = e.indentAfterLine(lineIdx, before, indents)
+		splitAfter = increased && matchRule(rules.DecreaseIndentPattern, after)
+	}
+
+	// 3. normal case:
+	buf.WriteString(s)
+	buf.WriteString(strings.Repeat(e.Indentation(), indents))
+
 	if inBrackets {
 		// Inside of a pair of brackets, add one more level of indents.
 		buf.WriteString(e.Indentation())
 
-		// 3 check if the right rune happens to be a right bracket
+		// 4 check if the right rune happens to be a right bracket
 		if rightBracket <= lineEnd && end == rightBracket {
-			s2 := s + strings.Repeat(e.Indentation(), indents)
-			buf.WriteString(s2)
-			adjust += utf8.RuneCountInString(s2)
+			splitAfter = true
 		}
+		indents++
+	}
 
+	if splitAfter {
+		// move the closing part to the next line with the outer indentation.
+		s2 := s + strings.Repeat(e.Indentation(), indents-1)
+		buf.WriteString(s2)
+		adjust += utf8.RuneCountInString(s2)
 	}
 
 	moves := e.Replace(start, end, buf.String())
@@ -205,8 +227,134 @@ func (e *TextView) IndentOnBreak(s string) int {
 	return moves
 }
 
-// func (e *autoIndentHandler) dedentRightBrackets(ke key.EditEvent) bool {
-// 	opening, ok := rtlBracketPairs[ke.Text]
-// 	if !ok {
-// 		return false
-// 	}
+// indentAfterLine returns the indentation level of the line after the line at
+// lineIdx, whose text before the break is before and whose own level is level,
+// following the indentation rules. It also reports whether the level is
+// increased.
+func (e *TextView) indentAfterLine(lineIdx int, before string, level int) (int, bool) {
+	rules := e.IndentRules
+	if matchRule(rules.UnIndentedLinePattern, before) {
+		// take the level of the nearest line that is indented normally.
+		for i := lineIdx - 1; i >= 0; i-- {
+			line := e.paragraphText(i)
+			if !matchRule(rules.UnIndentedLinePattern, line) {
+				level = checkIndentLevel([]byte(line), e.TabWidth)
+				break
+			}
+		}
+	}
+
+	switch {
+	case matchRule(rules.IncreaseIndentPattern, before), matchRule(rules.IndentNextLinePattern, before):
+		return level + 1, true
+	case lineIdx > 0 && strings.TrimSpace(before) != "":
+		// the line is the single line indented by the previous line.
+		prev := e.paragraphText(lineIdx - 1)
+		if matchRule(rules.IndentNextLinePattern, prev) && !matchRule(rules.IncreaseIndentPattern, prev) {
+			return max(0, level-1), false
+		}
+	}
+	return level, false
+}
+
+// IndentOnType decides the indentation of the caret line after s is typed
+// before the caret. A right bracket typed on a line with only whitespace before
+// it is aligned with the line of its left bracket. Otherwise a line which
+// starts to match the decrease indent pattern of IndentRules after s is typed
+// is indented by one less level than the line before it.
+//
+// It returns the rune range of the current indentation of the line and the new
+// indentation to replace it with. ok is false if the indentation is unchanged.
+func (e *TextView) IndentOnType(s string) (start, end int, indent string, ok bool) {
+	e.makeValid()
+	caret, caretEnd := e.Selection()
+	typed := utf8.RuneCountInString(s)
+	if caret != caretEnd || typed == 0 {
+		return
+	}
+
+	lineIdx, p := e.FindParagraph(caret)
+	col := caret - p.RuneOff
+	if col < typed {
+		return
+	}
+	line := []rune(e.paragraphText(lineIdx))
+	current := leadingWhitespace(string(line))
+	start, end = p.RuneOff, p.RuneOff+utf8.RuneCountInString(current)
+
+	r, _ := utf8.DecodeRuneInString(s)
+	if _, isRightBracket := e.BracketsQuotes.GetOpeningBracket(r); isRightBracket && typed == 1 &&
+		strings.TrimSpace(string(line[:col-1])) == "" {
+		// the bracket matcher skips the brackets in strings and comments.
+		left, right := e.NearestMatchingBracketsAt(caret - 1)
+		if left < 0 || right != caret-1 {
+			return
+		}
+		leftIdx, _ := e.FindParagraph(left)
+		indent = leadingWhitespace(e.paragraphText(leftIdx))
+		return start, end, indent, indent != current
+	}
+
+	rules := e.IndentRules
+	if rules == nil || rules.DecreaseIndentPattern == nil {
+		return
+	}
+	typedBefore := string(line[:col-typed]) + string(line[col:])
+	if !rules.DecreaseIndentPattern.MatchString(string(line)) || rules.DecreaseIndentPattern.MatchString(typedBefore) {
+		return
+	}
+
+	// find the previous non-blank line.
+	prevIdx := lineIdx - 1
+	for prevIdx >= 0 && strings.TrimSpace(e.paragraphText(prevIdx)) == "" {
+		prevIdx--
+	}
+	if prevIdx < 0 {
+		return
+	}
+	prev := e.paragraphText(prevIdx)
+	level, _ := e.indentAfterLine(prevIdx, prev, checkIndentLevel([]byte(prev), e.TabWidth))
+	level = max(0, level-1)
+	if level >= checkIndentLevel([]byte(current), e.TabWidth) {
+		return
+	}
+	indent = strings.Repeat(e.Indentation(), level)
+	return start, end, indent, true
+}
+
+// paragraphText returns the text of the paragraph at idx, without the line
+// break.
+func (e *TextView) paragraphText(idx int) string {
+	if idx < 0 || idx >= len(e.layouter.Paragraphs) {
+		return ""
+	}
+	p := e.layouter.Paragraphs[idx]
+	return strings.TrimRight(e.runeText(p.RuneOff, p.RuneOff+p.Runes), "\r\n")
+}
+
+// lineTextAfter returns the text from runeOff to the end of its paragraph,
+// without the line break.
+func (e *TextView) lineTextAfter(runeOff int) string {
+	_, p := e.FindParagraph(runeOff)
+	return strings.TrimRight(e.runeText(runeOff, p.RuneOff+p.Runes), "\r\n")
+}
+
+// runeText reads the text of the rune range [start, end).
+func (e *TextView) runeText(start, end int) string {
+	startOff := e.src.RuneOffset(start)
+	endOff := e.src.RuneOffset(end)
+	if endOff <= startOff {
+		return ""
+	}
+	buf := make([]byte, endOff-startOff)
+	n, _ := e.src.ReadAt(buf, int64(startOff))
+	return string(buf[:n])
+}
+
+func leadingWhitespace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+func matchRule(re *regexp.Regexp, s string) bool {
+	return re != nil && re.MatchString(s)
+}
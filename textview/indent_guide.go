@@ -0,0 +1,246 @@
+package textview
+
+import (
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	lt "github.com/oligo/gvcode/internal/layout"
+	"golang.org/x/image/math/fixed"
+)
+
+// indentGuides computes the indentation levels used to draw the indent guides.
+// Levels are cached by paragraph index for a single paint.
+type indentGuides struct {
+	view   *TextView
+	levels map[int]int
+}
+
+func (g *indentGuides) reset(view *TextView) {
+	g.view = view
+	if g.levels == nil {
+		g.levels = make(map[int]int)
+	}
+	clear(g.levels)
+}
+
+// paragraphLevel reads the leading whitespace of the paragraph and returns its
+// indentation level, and whether the paragraph contains only whitespace.
+func (g *indentGuides) paragraphLevel(idx int) (level int, blank bool) {
+	e := g.view
+	p := e.layouter.Paragraphs[idx]
+	e.lineBuf = e.lineBuf[:0]
+	for i := p.RuneOff; i < p.RuneOff+p.Runes; i++ {
+		r, err := e.src.ReadRuneAt(i)
+		if err != nil {
+			break
+		}
+		if r != ' ' && r != '\t' {
+			blank = r == '\n' || r == '\r'
+			return checkIndentLevel(e.lineBuf, e.TabWidth), blank
+		}
+		e.lineBuf = append(e.lineBuf, byte(r))
+	}
+
+	return checkIndentLevel(e.lineBuf, e.TabWidth), true
+}
+
+// level returns the indentation level of the paragraph at idx. Blank paragraphs
+// take their level from the surrounding non-blank paragraphs, so that guides
+// continue through blank lines inside a block.
+func (g *indentGuides) level(idx int) int {
+	if level, ok := g.levels[idx]; ok {
+		return level
+	}
+
+	level, blank := g.paragraphLevel(idx)
+	if blank {
+		above, below := -1, -1
+		for i := idx - 1; i >= 0; i-- {
+			if l, b := g.paragraphLevel(i); !b {
+				above = l
+				break
+			}
+		}
+		for i := idx + 1; i < len(g.view.layouter.Paragraphs); i++ {
+			if l, b := g.paragraphLevel(i); !b {
+				below = l
+				break
+			}
+		}
+
+		switch {
+		case above < 0 || below < 0:
+			level = 0
+		case above < below:
+			// a block starts after the blank lines.
+			level = above + 1
+		default:
+			level = min(above, below+1)
+		}
+	}
+
+	g.levels[idx] = level
+	return level
+}
+
+// activeScope returns the guide level and the paragraph range of the block
+// containing the caret. If the caret line starts a block, the block below it
+// is used. ok is false if the caret is not inside any indented block.
+func (g *indentGuides) activeScope(caretParagraph int) (level, start, end int, ok bool) {
+	total := len(g.view.layouter.Paragraphs)
+	level = g.level(caretParagraph) - 1
+	if caretParagraph+1 < total && g.level(caretParagraph+1) > level+1 {
+		level++
+	}
+	if level < 0 {
+		return 0, 0, 0, false
+	}
+
+	start, end = caretParagraph, caretParagraph
+	for start > 0 && g.level(start-1) > level {
+		start--
+	}
+	for end+1 < total && g.level(end+1) > level {
+		end++
+	}
+
+	return level, start, end, true
+}
+
+// leadingWhitespace returns the width of the leading whitespace of the screen line.
+// ok is false if the line contains only whitespace.
+func (e *TextView) leadingWhitespace(line *lt.Line) (width fixed.Int26_6, ok bool) {
+	runeOff := line.RuneOff
+	for _, gl := range line.Glyphs {
+		r, err := e.src.ReadRuneAt(runeOff)
+		if err != nil || r == '\n' || r == '\r' {
+			return width, false
+		}
+		if r != ' ' && r != '\t' {
+			return width, true
+		}
+		width += gl.Advance
+		runeOff += int(gl.Runes)
+	}
+
+	return width, false
+}
+
+// paragraphWhitespace returns the width of the leading whitespace of the
+// paragraph starting at runeOff, measured on its first screen line, which is
+// looked up backward from the screen line at lineIdx. The soft wrapped lines of
+// a paragraph share the guides of its first line.
+func (e *TextView) paragraphWhitespace(lineIdx, runeOff int) (width fixed.Int26_6, ok bool) {
+	for lineIdx > 0 && e.layouter.Lines[lineIdx].RuneOff > runeOff {
+		lineIdx--
+	}
+	return e.leadingWhitespace(&e.layouter.Lines[lineIdx])
+}
+
+// PaintIndentGuides paints vertical guides at every indentation level of the
+// visible lines. The guide of the block containing the caret is painted with
+// activeMaterial. Guides are only painted in the leading whitespace of lines and
+// are skipped where the text is selected. The soft wrapped lines of a paragraph
+// show the guides of its first line.
+func (e *TextView) PaintIndentGuides(gtx layout.Context, material, activeMaterial op.CallOp) {
+	e.PaintIndentGuidesSkipping(gtx, material, activeMaterial, nil)
+}
+
+// PaintIndentGuidesSkipping paints the indent guides like PaintIndentGuides, and
+// also skips them where the rune ranges of selections are, e.g., the selections
+// of the secondary carets.
+func (e *TextView) PaintIndentGuidesSkipping(gtx layout.Context, material, activeMaterial op.CallOp, selections [][2]int) {
+	if len(e.layouter.Paragraphs) == 0 || e.TabWidth <= 0 {
+		return
+	}
+
+	localViewport := image.Rectangle{Max: e.viewSize}
+	docViewport := image.Rectangle{Max: e.viewSize}.Add(e.scrollOff)
+	defer clip.Rect(localViewport).Push(gtx.Ops).Pop()
+
+	e.guides.reset(e)
+	caretParagraph, _ := e.FindParagraph(e.caret.start)
+	activeLevel, activeStart, activeEnd, hasActive := e.guides.activeScope(caretParagraph)
+
+	selected := e.selectedRegions(docViewport, selections)
+
+	indentWidth := e.layouter.SpaceAdvance().Mul(fixed.I(e.TabWidth))
+	width := max(1, gtx.Dp(unit.Dp(1)))
+
+	// the leading whitespace of the paragraph of the last line painted.
+	lastParagraph := -1
+	var limit fixed.Int26_6
+	var limited bool
+	for i := range e.layouter.Lines {
+		line := &e.layouter.Lines[i]
+		if line.Descent.Ceil()+line.YOff < docViewport.Min.Y {
+			continue
+		}
+		if line.YOff-line.Ascent.Ceil() > docViewport.Max.Y {
+			break
+		}
+
+		pIdx, p := e.FindParagraph(line.RuneOff)
+		level := e.guides.level(pIdx)
+		if level <= 0 {
+			continue
+		}
+		if pIdx != lastParagraph {
+			lastParagraph = pIdx
+			limit, limited = e.paragraphWhitespace(i, p.RuneOff)
+		}
+
+		top := line.YOff - line.Ascent.Ceil() - e.scrollOff.Y
+		bottom := line.YOff + line.Descent.Ceil() - e.scrollOff.Y
+		for k := range level {
+			guideX := indentWidth.Mul(fixed.I(k))
+			if limited && guideX >= limit {
+				break
+			}
+
+			x := (line.XOff + guideX).Round() - e.scrollOff.X
+			rect := image.Rect(x, top, x+width, bottom)
+			if inRegions(image.Pt(x, line.YOff-e.scrollOff.Y-1), selected) {
+				continue
+			}
+
+			guideMaterial := material
+			if hasActive && k == activeLevel && pIdx >= activeStart && pIdx <= activeEnd {
+				guideMaterial = activeMaterial
+			}
+
+			stack := clip.Rect(rect).Push(gtx.Ops)
+			guideMaterial.Add(gtx.Ops)
+			paint.PaintOp{}.Add(gtx.Ops)
+			stack.Pop()
+		}
+	}
+}
+
+// selectedRegions returns the regions of the selection of the caret and of the
+// rune ranges of selections within viewport.
+func (e *TextView) selectedRegions(viewport image.Rectangle, selections [][2]int) []Region {
+	var regions []Region
+	if e.caret.start != e.caret.end {
+		regions = e.layouter.Locate(viewport, e.caret.start, e.caret.end, nil)
+	}
+	for _, sel := range selections {
+		if sel[0] != sel[1] {
+			regions = append(regions, e.layouter.Locate(viewport, sel[0], sel[1], nil)...)
+		}
+	}
+	return regions
+}
+
+func inRegions(pt image.Point, regions []Region) bool {
+	for _, r := range regions {
+		if pt.In(r.Bounds) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package textview
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+func TestIndentGuideLevels(t *testing.T) {
+	vw := NewTextView()
+	vw.TabWidth = 4
+	vw.TextSize = unit.Sp(14)
+	vw.SetText("func a() {\n    if b {\n\t\tc()\n\n\t\td()\n    }\n\n}\n")
+
+	gtx := layout.Context{}
+	shaper := text.NewShaper()
+	vw.Layout(gtx, shaper)
+
+	vw.guides.reset(vw)
+	want := []int{0, 1, 2, 2, 2, 1, 1, 0}
+	for i, level := range want {
+		if got := vw.guides.level(i); got != level {
+			t.Errorf("paragraph %d: want level %d, got %d", i, level, got)
+		}
+	}
+
+	// caret inside the if block.
+	level, start, end, ok := vw.guides.activeScope(2)
+	if !ok || level != 1 || start != 2 || end != 4 {
+		t.Errorf("unexpected active scope: level %d, range [%d, %d], ok: %v", level, start, end, ok)
+	}
+
+	// caret on the line starting the if block.
+	level, start, end, ok = vw.guides.activeScope(1)
+	if !ok || level != 1 || start != 1 || end != 4 {
+		t.Errorf("unexpected active scope: level %d, range [%d, %d], ok: %v", level, start, end, ok)
+	}
+}
+
+func TestIndentGuidesWrappedLines(t *testing.T) {
+	vw := NewTextView()
+	vw.TabWidth = 4
+	vw.TextSize = unit.Sp(14)
+	vw.SetWrapLine(true)
+	vw.SetText("func a() {\n        " + strings.Repeat("word ", 40) + "\n}\n")
+
+	gtx := layout.Context{
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(200, 400)),
+	}
+	vw.Layout(gtx, text.NewShaper())
+
+	_, p := vw.FindParagraph(12)
+	first := -1
+	for i, line := range vw.layouter.Lines {
+		if line.RuneOff == p.RuneOff {
+			first = i
+		}
+	}
+	if first < 0 || first+1 >= len(vw.layouter.Lines) || vw.layouter.Lines[first+1].RuneOff >= p.RuneOff+p.Runes {
+		t.Fatalf("expected the paragraph to be soft wrapped")
+	}
+
+	want, ok := vw.leadingWhitespace(&vw.layouter.Lines[first])
+	if !ok || want <= 0 {
+		t.Fatalf("expected leading whitespace on the first line, got %v, %v", want, ok)
+	}
+	for i := first; i < len(vw.layouter.Lines) && vw.layouter.Lines[i].RuneOff < p.RuneOff+p.Runes; i++ {
+		if got, ok := vw.paragraphWhitespace(i, p.RuneOff); !ok || got != want {
+			t.Errorf("line %d: want leading whitespace %v, got %v, %v", i, want, got, ok)
+		}
+	}
+}
+
+func TestIndentGuidesSkipSelections(t *testing.T) {
+	vw := NewTextView()
+	vw.TabWidth = 4
+	vw.TextSize = unit.Sp(14)
+	vw.SetText("a {\n    b\n    c\n}\n")
+	gtx := layout.Context{
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(200, 400)),
+	}
+	vw.Layout(gtx, text.NewShaper())
+
+	// the caret selects line 1, and a secondary caret selects line 2.
+	vw.SetCaret(4, 9)
+	viewport := image.Rectangle{Max: vw.viewSize}
+	regions := vw.selectedRegions(viewport, [][2]int{{10, 15}, {3, 3}})
+	for _, line := range []int{1, 2} {
+		y := vw.layouter.Lines[line].YOff - 1
+		if !inRegions(image.Pt(vw.layouter.Lines[line].XOff.Round()+1, y), regions) {
+			t.Errorf("line %d: expected the guide to be under a selection", line)
+		}
+	}
+	if y := vw.layouter.Lines[3].YOff - 1; inRegions(image.Pt(1, y), regions) {
+		t.Error("line 3: expected no selection")
+	}
+}
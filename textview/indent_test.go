@@ -2,12 +2,16 @@ package textview
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"gioui.org/layout"
 	"gioui.org/text"
 	"gioui.org/unit"
+	"github.com/oligo/gvcode/color"
 	"github.com/oligo/gvcode/internal/buffer"
+	"github.com/oligo/gvcode/language"
+	"github.com/oligo/gvcode/textstyle/syntax"
 )
 
 func TestIndentLines(t *testing.T) {
@@ -229,3 +233,173 @@ func TestIndentOnBreak(t *testing.T) {
 	}
 
 }
+
+var pythonRules = &language.IndentationRules{
+	IncreaseIndentPattern: regexp.MustCompile(`^\s*(def|class|if|elif|else|for|while|try|except|finally|with)\b.*:\s*$`),
+	DecreaseIndentPattern: regexp.MustCompile(`^\s*(elif\b.*|else|except\b.*|finally):$`),
+}
+
+var rubyRules = &language.IndentationRules{
+	IncreaseIndentPattern: regexp.MustCompile(`^\s*(def|class|if|do)\b.*$`),
+	DecreaseIndentPattern: regexp.MustCompile(`^\s*end\b.*$`),
+	IndentNextLinePattern: regexp.MustCompile(`^\s*(if|for|while)\s*\(.*\)\s*$`),
+}
+
+func TestIndentOnBreakWithRules(t *testing.T) {
+	cases := []struct {
+		rules     *language.IndentationRules
+		input     string
+		selection int
+		want      string
+		wantCaret int
+	}{
+		{
+			rules:     pythonRules,
+			input:     "def foo():",
+			selection: 10,
+			want:      "def foo():\n\t",
+			wantCaret: 12,
+		},
+		{
+			rules:     pythonRules,
+			input:     "\tx = 1",
+			selection: 6,
+			want:      "\tx = 1\n\t",
+			wantCaret: 8,
+		},
+		{
+			rules:     rubyRules,
+			input:     "def foo end",
+			selection: 8,
+			want:      "def foo \n\t\nend",
+			wantCaret: 10,
+		},
+		{
+			// a single line is indented after a while without braces.
+			rules:     rubyRules,
+			input:     "while (x)",
+			selection: 9,
+			want:      "while (x)\n\t",
+			wantCaret: 11,
+		},
+		{
+			rules:     rubyRules,
+			input:     "while (x)\n\tfoo()",
+			selection: 16,
+			want:      "while (x)\n\tfoo()\n",
+			wantCaret: 17,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d: %q", i, tc.input), func(t *testing.T) {
+			vw := setupTextView(tc.input)
+			vw.IndentRules = tc.rules
+			vw.SetCaret(tc.selection, tc.selection)
+			vw.IndentOnBreak("\n")
+
+			got := string(buffer.NewReader(vw.src).ReadAll(nil))
+			caret, _ := vw.Selection()
+			if got != tc.want || caret != tc.wantCaret {
+				t.Errorf("want %q with caret %d, got %q with caret %d", tc.want, tc.wantCaret, got, caret)
+			}
+		})
+	}
+}
+
+func TestIndentOnType(t *testing.T) {
+	cases := []struct {
+		rules  *language.IndentationRules
+		input  string
+		tokens []syntax.Token
+		caret  int
+		typed  string
+		want   string
+		wantOk bool
+	}{
+		{
+			rules:  pythonRules,
+			input:  "if x:\n\ty = 1\n\telse",
+			caret:  18,
+			typed:  ":",
+			want:   "if x:\n\ty = 1\nelse:",
+			wantOk: true,
+		},
+		{
+			// the line already matched before typing.
+			rules:  pythonRules,
+			input:  "if x:\n\ty = 1\n\telse:",
+			caret:  19,
+			typed:  " ",
+			wantOk: false,
+		},
+		{
+			rules:  rubyRules,
+			input:  "def foo\n\tbar\n\ten",
+			caret:  16,
+			typed:  "d",
+			want:   "def foo\n\tbar\nend",
+			wantOk: true,
+		},
+		{
+			// a right bracket on a blank line is aligned with its left bracket.
+			input:  "\tfoo {\n\t\tbar\n\t\t",
+			caret:  15,
+			typed:  "}",
+			want:   "\tfoo {\n\t\tbar\n\t}",
+			wantOk: true,
+		},
+		{
+			// the brackets in strings are skipped.
+			input:  "\tfoo {\n\t\tx := \"{\"\n\t\t",
+			tokens: []syntax.Token{{Start: 14, End: 17, Scope: "string"}},
+			caret:  20,
+			typed:  "}",
+			want:   "\tfoo {\n\t\tx := \"{\"\n\t}",
+			wantOk: true,
+		},
+		{
+			input:  "foo {\n\tbar",
+			caret:  10,
+			typed:  "}",
+			wantOk: false,
+		},
+	}
+
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("%d: %q", i, tc.input), func(t *testing.T) {
+			vw := setupTextView(tc.input)
+			vw.IndentRules = tc.rules
+			if tc.tokens != nil {
+				scheme := &syntax.ColorScheme{}
+				scheme.AddStyle("string", 0, color.Color{}, color.Color{})
+				vw.SetColorScheme(scheme)
+				vw.SetSyntaxTokens(tc.tokens...)
+			}
+			vw.SetCaret(tc.caret, tc.caret)
+			vw.Replace(tc.caret, tc.caret, tc.typed)
+
+			start, end, indent, ok := vw.IndentOnType(tc.typed)
+			if ok != tc.wantOk {
+				t.Fatalf("want ok %v, got %v", tc.wantOk, ok)
+			}
+			if !ok {
+				return
+			}
+			vw.Replace(start, end, indent)
+			if got := string(buffer.NewReader(vw.src).ReadAll(nil)); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func setupTextView(input string) *TextView {
+	vw := NewTextView()
+	vw.TabWidth = 4
+	vw.SoftTab = false
+	vw.TextSize = unit.Sp(14)
+	vw.SetText(input)
+	vw.Layout(layout.Context{}, text.NewShaper())
+	return vw
+}
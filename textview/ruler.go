@@ -0,0 +1,66 @@
+package textview
+
+import (
+	"image"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"github.com/oligo/gvcode/color"
+	"golang.org/x/image/math/fixed"
+)
+
+// Ruler is a thin vertical line drawn behind the text at a column, which is
+// usually used to indicate a line length limit.
+type Ruler struct {
+	// Column is measured in advances of the space glyph.
+	Column int
+	// Color of the ruler. If not set, the default ruler color is used.
+	Color color.Color
+}
+
+// ColumnX returns the x coordinate of the column in the document coordinates,
+// computed from the advance of the space glyph.
+func (e *TextView) ColumnX(column int) fixed.Int26_6 {
+	e.makeValid()
+	return e.layouter.SpaceAdvance().Mul(fixed.I(column))
+}
+
+// PaintRulers paints the vertical rulers across the viewport. Rulers scroll
+// horizontally with the text. defaultColor is used for rulers with no color
+// set.
+func (e *TextView) PaintRulers(gtx layout.Context, rulers []Ruler, defaultColor color.Color) {
+	if len(rulers) == 0 {
+		return
+	}
+
+	localViewport := image.Rectangle{Max: e.viewSize}
+	defer clip.Rect(localViewport).Push(gtx.Ops).Pop()
+
+	width := max(1, gtx.Dp(unit.Dp(1)))
+	for _, ruler := range rulers {
+		if ruler.Column <= 0 {
+			continue
+		}
+
+		x := e.ColumnX(ruler.Column).Round() - e.scrollOff.X
+		if x+width < 0 || x > e.viewSize.X {
+			continue
+		}
+
+		material := ruler.Color
+		if !material.IsSet() {
+			material = defaultColor
+		}
+		if !material.IsSet() {
+			continue
+		}
+
+		rect := image.Rect(x, 0, x+width, e.viewSize.Y)
+		stack := clip.Rect(rect).Push(gtx.Ops)
+		material.Op(gtx.Ops).Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+		stack.Pop()
+	}
+}
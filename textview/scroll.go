@@ -0,0 +1,205 @@
+package textview
+
+import (
+	"time"
+
+	"gioui.org/layout"
+	"gioui.org/op"
+	"github.com/oligo/gvcode/internal/gesture/fling"
+	"golang.org/x/image/math/fixed"
+)
+
+// smoothScroller animates vertical scrolling to a target offset.
+type smoothScroller struct {
+	anim fling.Animation
+	// target is the vertical scroll offset to scroll to.
+	target int
+	// pending marks that the animation has to be started in the next tick.
+	pending bool
+}
+
+func (s *smoothScroller) active() bool {
+	return s.pending || s.anim.Active()
+}
+
+func (s *smoothScroller) stop() {
+	s.pending = false
+	s.anim = fling.Animation{}
+}
+
+// scrollTargetY returns the vertical scroll offset when the running scroll
+// animation finishes.
+func (e *TextView) scrollTargetY() int {
+	if e.smoothScroll.active() {
+		return e.smoothScroll.target
+	}
+	return e.scrollOff.Y
+}
+
+// scrollYTo scrolls vertically to the absolute offset y. The scroll is animated if
+// SmoothScroll is enabled.
+func (e *TextView) scrollYTo(x, y int) {
+	if !e.SmoothScroll {
+		e.scrollAbs(x, y)
+		return
+	}
+
+	e.scrollAbs(x, e.scrollOff.Y)
+	e.smoothScroll.target = y
+	e.smoothScroll.pending = true
+}
+
+// TickScroll advances the running scroll animation, and schedules a redraw if the
+// animation is not finished. It should be called once every frame.
+func (e *TextView) TickScroll(gtx layout.Context) {
+	s := &e.smoothScroll
+	if !s.active() {
+		return
+	}
+
+	if s.pending {
+		s.pending = false
+		b := e.ScrollBounds()
+		s.target = max(min(s.target, b.Max.Y), b.Min.Y)
+		distance := float32(s.target - e.scrollOff.Y)
+		// Jump over the distance the fling can not travel, so the animation
+		// always ends at the target.
+		if maxDist := fling.MaxDistance(gtx.Metric) * 0.9; distance > maxDist {
+			e.scrollAbs(e.scrollOff.X, s.target-int(maxDist))
+			distance = maxDist
+		} else if distance < -maxDist {
+			e.scrollAbs(e.scrollOff.X, s.target+int(maxDist))
+			distance = -maxDist
+		}
+
+		if !s.anim.StartDistance(gtx.Metric, gtx.Now, distance) {
+			e.scrollAbs(e.scrollOff.X, s.target)
+			return
+		}
+	}
+
+	e.scrollAbs(e.scrollOff.X, e.scrollOff.Y+s.anim.Tick(gtx.Now))
+	if !s.anim.Active() {
+		// snap to the target to correct the rounding errors.
+		e.scrollAbs(e.scrollOff.X, s.target)
+		return
+	}
+	gtx.Execute(op.InvalidateCmd{At: gtx.Now.Add(time.Second / 120)})
+}
+
+// caretMargin returns the space in pixels kept between the caret line and the top
+// or bottom edge of the viewport.
+func (e *TextView) caretMargin() int {
+	if e.CaretMargin <= 0 {
+		return 0
+	}
+
+	lineHeight := e.lineHeight.Ceil()
+	if lineHeight <= 0 {
+		return 0
+	}
+	// The margin can not take more than the space of the viewport.
+	visibleLines := e.viewSize.Y / lineHeight
+	return min(e.CaretMargin, max(0, (visibleLines-1)/2)) * lineHeight
+}
+
+// scrollPastEnd returns the vertical space that can be scrolled past the end of the
+// document.
+func (e *TextView) scrollPastEnd() int {
+	switch {
+	case e.ScrollPastEnd:
+		return max(0, e.viewSize.Y-e.lineHeight.Ceil())
+	case e.Typewriter:
+		return e.viewSize.Y / 2
+	}
+	return 0
+}
+
+// scrollXDist returns the horizontal distance to scroll to make the x position
+// visible.
+func (e *TextView) scrollXDist(x fixed.Int26_6) int {
+	// The configures the distance in pixel the caret is from the horizontal border
+	// of the editor, at which point we should scroll to adjust the viewport.
+	// This ensures the caret can easily be seen when scrolled hotizontally.
+	minScrollGap := (e.params.PxPerEm * 1).Ceil()
+
+	// calculate x delta, accounting the padding
+	if d := x.Floor() - minScrollGap - e.scrollOff.X; d < 0 {
+		return d
+	} else if d := x.Ceil() + minScrollGap - (e.scrollOff.X + e.viewSize.X); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// ScrollAlign specifies where ScrollToRange places the range in the viewport.
+type ScrollAlign uint8
+
+const (
+	// ScrollIfOffscreen scrolls only if the range is not fully visible, and
+	// centers it in that case.
+	ScrollIfOffscreen ScrollAlign = iota
+	// ScrollCenter centers the range vertically.
+	ScrollCenter
+	// ScrollTop places the range at the top of the viewport, keeping the caret
+	// margin above it.
+	ScrollTop
+
+	// scrollNearest scrolls by the minimum distance to show the range with the
+	// caret margin around it, as the caret is followed.
+	scrollNearest
+)
+
+// ScrollToRange scrolls the viewport to show the rune range [start, end), which
+// is placed as specified by align. If the range is taller than the viewport, its
+// start is shown. The start of the range is also scrolled into view horizontally.
+// It shares the scrolling of ScrollToCaret, including the running animation.
+func (e *TextView) ScrollToRange(start, end int, align ScrollAlign) {
+	e.scrollToRange(min(start, end), max(start, end), align)
+}
+
+func (e *TextView) scrollToRange(start, end int, align ScrollAlign) {
+	startPos := e.closestToRune(start)
+	endPos := startPos
+	if end != start {
+		endPos = e.closestToRune(end)
+	}
+
+	miny := startPos.Y - startPos.Ascent.Ceil()
+	maxy := endPos.Y + endPos.Descent.Ceil()
+	xdist := e.scrollXDist(startPos.X)
+
+	// calculate y delta against the offset the running animation scrolls to.
+	scrollY := e.scrollTargetY()
+	center := func() int {
+		if maxy-miny > e.viewSize.Y {
+			return miny - scrollY
+		}
+		return (miny+maxy)/2 - (scrollY + e.viewSize.Y/2)
+	}
+
+	var ydist int
+	switch align {
+	case ScrollTop:
+		ydist = miny - e.caretMargin() - scrollY
+	case ScrollCenter:
+		ydist = center()
+	case scrollNearest:
+		margin := e.caretMargin()
+		if d := miny - margin - scrollY; d < 0 {
+			ydist = d
+		} else if d := maxy + margin - (scrollY + e.viewSize.Y); d > 0 {
+			ydist = d
+		}
+	default:
+		if miny < scrollY || maxy > scrollY+e.viewSize.Y {
+			ydist = center()
+		}
+	}
+
+	if ydist == 0 {
+		e.scrollAbs(e.scrollOff.X+xdist, e.scrollOff.Y)
+		return
+	}
+	e.scrollYTo(e.scrollOff.X+xdist, scrollY+ydist)
+}
@@ -0,0 +1,95 @@
+package textview
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+)
+
+func setupScrollView(t *testing.T) (*TextView, layout.Context, int) {
+	t.Helper()
+	vw := NewTextView()
+	vw.TextSize = unit.Sp(14)
+	vw.SetText(strings.Repeat("line\n", 100))
+
+	gtx := layout.Context{
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(300, 200)),
+	}
+	vw.Layout(gtx, text.NewShaper())
+	return vw, gtx, vw.GetLineHeight().Ceil()
+}
+
+func TestScrollPastEnd(t *testing.T) {
+	vw, gtx, lineHeight := setupScrollView(t)
+	maxY := vw.ScrollBounds().Max.Y
+
+	vw.ScrollPastEnd = true
+	vw.Layout(gtx, text.NewShaper())
+	if got, want := vw.ScrollBounds().Max.Y, maxY+200-lineHeight; got != want {
+		t.Errorf("expected to scroll past end up to %d, got %d", want, got)
+	}
+}
+
+func TestScrollToCaretMargin(t *testing.T) {
+	vw, _, lineHeight := setupScrollView(t)
+	vw.CaretMargin = 3
+
+	// move the caret to line 20.
+	vw.SetCaret(20*5, 20*5)
+	vw.ScrollToCaret()
+	caretBottom := vw.LineTop(20) + lineHeight
+	if got := 200 - caretBottom; got < 3*lineHeight {
+		t.Errorf("expected at least %d pixels below the caret, got %d", 3*lineHeight, got)
+	}
+
+	vw.SetCaret(10*5, 10*5)
+	vw.ScrollToCaret()
+	if got := vw.LineTop(10); got < 3*lineHeight {
+		t.Errorf("expected at least %d pixels above the caret, got %d", 3*lineHeight, got)
+	}
+}
+
+func TestScrollToCaretTypewriter(t *testing.T) {
+	vw, _, lineHeight := setupScrollView(t)
+	vw.Typewriter = true
+
+	vw.SetCaret(50*5, 50*5)
+	vw.ScrollToCaret()
+	center := vw.LineTop(50) + lineHeight/2
+	if d := center - 100; d > 1 || d < -1 {
+		t.Errorf("expected the caret line to be centered, got center at %d", center)
+	}
+}
+
+func TestScrollToRange(t *testing.T) {
+	vw, _, lineHeight := setupScrollView(t)
+
+	// line 5 is visible, so the viewport is not scrolled.
+	vw.ScrollToRange(5*5, 5*5+4, ScrollIfOffscreen)
+	if got := vw.ScrollOff().Y; got != 0 {
+		t.Errorf("expected no scroll for a visible range, got offset %d", got)
+	}
+
+	vw.ScrollToRange(50*5, 50*5+4, ScrollIfOffscreen)
+	center := vw.LineTop(50) + lineHeight/2
+	if d := center - 100; d > 1 || d < -1 {
+		t.Errorf("expected the offscreen range to be centered, got center at %d", center)
+	}
+
+	vw.ScrollToRange(30*5, 31*5+4, ScrollCenter)
+	center = (vw.LineTop(30) + vw.LineTop(31) + lineHeight) / 2
+	if d := center - 100; d > 1 || d < -1 {
+		t.Errorf("expected the range to be centered, got center at %d", center)
+	}
+
+	vw.CaretMargin = 2
+	vw.ScrollToRange(40*5, 40*5+4, ScrollTop)
+	if got, want := vw.LineTop(40), 2*lineHeight; got != want {
+		t.Errorf("expected the range at %d from the top, got %d", want, got)
+	}
+}
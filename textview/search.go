@@ -0,0 +1,493 @@
+package textview
+
+import (
+	"io"
+	"iter"
+	"regexp"
+	"slices"
+	"sort"
+
+	"github.com/oligo/gvcode/internal/buffer"
+)
+
+// DefaultMaxMatches is the maximum number of matches found by a Searcher, if
+// SearchOptions.MaxMatches is not set.
+const DefaultMaxMatches = 10000
+
+// SearchOptions configures how a Searcher matches the query.
+type SearchOptions struct {
+	// Regex treats the query as a regular expression in the RE2 syntax. ^ and $
+	// match at line boundaries.
+	Regex bool
+	// IgnoreCase matches the query case-insensitively.
+	IgnoreCase bool
+	// WholeWord only matches the query surrounded by word seperators, or the
+	// boundaries of the text.
+	WholeWord bool
+	// MaxMatches caps the number of matches. Zero means DefaultMaxMatches.
+	MaxMatches int
+}
+
+// SearchMatch is a match of a search. Offsets are in runes.
+type SearchMatch struct {
+	Start, End int
+	// Groups holds the offsets of the submatches in pairs, the first being the
+	// whole match, like the result of regexp.Regexp.FindSubmatchIndex. A pair
+	// of -1 means the group is not matched.
+	Groups []int
+}
+
+// Searcher searches the text of a TextView. Matches are found lazily in document
+// order, and never overlap. The searcher is updated after the text is edited
+// through the TextView, searching again only the affected region, until it is
+// closed.
+type Searcher struct {
+	view *TextView
+	opts SearchOptions
+	// re matches the query. after matches any rune followed by the query. It is
+	// used to search from the middle of the text, so that the assertions of the
+	// query see the rune before the search position.
+	re, after *regexp.Regexp
+
+	scoped               bool
+	scopeStart, scopeEnd int
+
+	matches []SearchMatch
+	// next is the offset where the lazy search continues.
+	next int
+	// done is set when there are no more matches to find, or the number of
+	// matches reaches the cap.
+	done    bool
+	current int
+	// changed is set when the matches are reset or updated.
+	changed bool
+}
+
+// NewSearcher returns a Searcher of the query. An error is returned if the query
+// is not a valid regular expression in the regex mode.
+func (e *TextView) NewSearcher(query string, opts SearchOptions) (*Searcher, error) {
+	if !opts.Regex {
+		query = regexp.QuoteMeta(query)
+	}
+	flags := "(?m)"
+	if opts.IgnoreCase {
+		flags = "(?mi)"
+	}
+
+	re, err := regexp.Compile(flags + query)
+	if err != nil {
+		return nil, err
+	}
+	after := regexp.MustCompile(flags + "(?s:.)(" + query + ")")
+
+	if opts.MaxMatches <= 0 {
+		opts.MaxMatches = DefaultMaxMatches
+	}
+	s := &Searcher{view: e, opts: opts, re: re, after: after}
+	s.Reset()
+	e.searchers = append(e.searchers, s)
+	return s, nil
+}
+
+// Close stops updating the searcher after edits of the text. The searcher
+// should not be used after it is closed.
+func (s *Searcher) Close() {
+	s.view.searchers = slices.DeleteFunc(s.view.searchers, func(e *Searcher) bool { return e == s })
+}
+
+func (e *TextView) resetSearchers() {
+	for _, s := range e.searchers {
+		s.Reset()
+	}
+}
+
+// updateSearchers updates the searchers after the edits of an undo or redo, like
+// the edits made by Replace.
+func (e *TextView) updateSearchers(edits []buffer.TextEdit) {
+	for _, s := range e.searchers {
+		for _, edit := range edits {
+			s.Update(edit.Start, edit.OldEnd, edit.NewEnd)
+		}
+	}
+}
+
+// Options returns the options of the searcher.
+func (s *Searcher) Options() SearchOptions {
+	return s.opts
+}
+
+// SetScope restricts the search to the rune range [start, end), e.g., the
+// selection, and discards the matches found.
+func (s *Searcher) SetScope(start, end int) {
+	s.scoped = true
+	s.scopeStart, s.scopeEnd = min(start, end), max(start, end)
+	s.Reset()
+}
+
+// ClearScope searches the whole text again.
+func (s *Searcher) ClearScope() {
+	s.scoped = false
+	s.Reset()
+}
+
+// Scope returns the rune range of the search.
+func (s *Searcher) Scope() (start, end int) {
+	if !s.scoped {
+		return 0, s.view.src.Len()
+	}
+	return s.scopeStart, min(s.scopeEnd, s.view.src.Len())
+}
+
+// Reset discards the matches found, to search the text again lazily.
+func (s *Searcher) Reset() {
+	s.matches = s.matches[:0]
+	s.next, _ = s.Scope()
+	s.done = false
+	s.current = -1
+	s.changed = true
+}
+
+// Changed reports whether the matches have been reset or updated after an edit
+// since the last call to Changed.
+func (s *Searcher) Changed() bool {
+	changed := s.changed
+	s.changed = false
+	return changed
+}
+
+// find returns the first match starting at or after the rune offset from.
+func (s *Searcher) find(from int) (SearchMatch, bool) {
+	_, end := s.Scope()
+	for from < end {
+		re, readFrom, group := s.re, from, 0
+		if from > 0 {
+			re, readFrom, group = s.after, from-1, 1
+		}
+
+		loc := re.FindReaderSubmatchIndex(runeCounter{buffer.NewRuneReader(s.view.src, readFrom, end)})
+		if loc == nil {
+			return SearchMatch{}, false
+		}
+		groups := loc[2*group:]
+		for i := range groups {
+			if groups[i] >= 0 {
+				groups[i] += readFrom
+			}
+		}
+
+		m := SearchMatch{Start: groups[0], End: groups[1], Groups: groups}
+		if m.Start == m.End || (s.opts.WholeWord && !s.isWholeWord(m)) {
+			from = m.Start + 1
+			continue
+		}
+		return m, true
+	}
+
+	return SearchMatch{}, false
+}
+
+func (s *Searcher) isWholeWord(m SearchMatch) bool {
+	if m.Start > 0 {
+		if r, err := s.view.src.ReadRuneAt(m.Start - 1); err == nil && !s.view.IsWordSeperator(r) {
+			return false
+		}
+	}
+	if m.End < s.view.src.Len() {
+		if r, err := s.view.src.ReadRuneAt(m.End); err == nil && !s.view.IsWordSeperator(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// findNext finds one more match. It reports whether a match is found.
+func (s *Searcher) findNext() bool {
+	if s.done {
+		return false
+	}
+
+	m, ok := s.find(s.next)
+	if !ok {
+		s.done = true
+		return false
+	}
+	s.matches = append(s.matches, m)
+	s.next = m.End
+	if len(s.matches) >= s.opts.MaxMatches {
+		s.done = true
+	}
+	return true
+}
+
+// Matches returns an iterator over the matches in document order. Matches are
+// searched lazily as the iteration goes.
+func (s *Searcher) Matches() iter.Seq[SearchMatch] {
+	return func(yield func(SearchMatch) bool) {
+		for i := 0; i < len(s.matches) || s.findNext(); i++ {
+			if !yield(s.matches[i]) {
+				return
+			}
+		}
+	}
+}
+
+// MatchesIn returns the matches overlapping the rune range [start, end), e.g.,
+// the visible part of the text.
+func (s *Searcher) MatchesIn(start, end int) []SearchMatch {
+	for (len(s.matches) == 0 || s.matches[len(s.matches)-1].End < end) && s.findNext() {
+	}
+
+	i := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].End > start })
+	j := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].Start >= end })
+	if i >= j {
+		return nil
+	}
+	return s.matches[i:j]
+}
+
+// Count searches all the matches and returns their number. capped is set if the
+// number reaches the cap of the matches.
+func (s *Searcher) Count() (n int, capped bool) {
+	for s.findNext() {
+	}
+	return len(s.matches), len(s.matches) >= s.opts.MaxMatches
+}
+
+// Current returns the zero based index of the current match, and the number
+// of matches. The index is -1 if there is no current match.
+func (s *Searcher) Current() (idx int, total int) {
+	total, _ = s.Count()
+	return s.current, total
+}
+
+// CurrentMatch returns the current match, if any.
+func (s *Searcher) CurrentMatch() (SearchMatch, bool) {
+	if s.current < 0 || s.current >= len(s.matches) {
+		return SearchMatch{}, false
+	}
+	return s.matches[s.current], true
+}
+
+// SelectAt makes the first match starting at or after the rune offset current,
+// or the last match ending at or before it if backward is set. The search wraps
+// around at the boundaries of the scope.
+func (s *Searcher) SelectAt(runeOff int, backward bool) (SearchMatch, bool) {
+	n, _ := s.Count()
+	if n == 0 {
+		s.current = -1
+		return SearchMatch{}, false
+	}
+
+	if backward {
+		idx := sort.Search(n, func(i int) bool { return s.matches[i].End > runeOff }) - 1
+		s.current = (idx + n) % n
+	} else {
+		idx := sort.Search(n, func(i int) bool { return s.matches[i].Start >= runeOff })
+		s.current = idx % n
+	}
+	return s.matches[s.current], true
+}
+
+// NextMatch makes the match after the current one current, wrapping around.
+func (s *Searcher) NextMatch() (SearchMatch, bool) {
+	return s.step(1)
+}
+
+// PrevMatch makes the match before the current one current, wrapping around.
+func (s *Searcher) PrevMatch() (SearchMatch, bool) {
+	return s.step(-1)
+}
+
+func (s *Searcher) step(direction int) (SearchMatch, bool) {
+	n, _ := s.Count()
+	if n == 0 {
+		s.current = -1
+		return SearchMatch{}, false
+	}
+	if s.current < 0 {
+		s.current = 0
+		if direction < 0 {
+			s.current = n - 1
+		}
+	} else {
+		s.current = (s.current + direction + n) % n
+	}
+	return s.matches[s.current], true
+}
+
+// Update updates the matches after the text in the rune range [start, oldEnd) is
+// replaced with the text in [start, newEnd). Only the lines of the edit are
+// searched again, until the matches found are in sync with the previous ones.
+// It is called by the TextView for its edits, and is only needed for the edits
+// made to the text source directly.
+func (s *Searcher) Update(start, oldEnd, newEnd int) {
+	s.changed = true
+	delta := newEnd - oldEnd
+	shift := func(off int, atEnd bool) int {
+		switch {
+		case off >= oldEnd:
+			return off + delta
+		case off > start && atEnd:
+			return newEnd
+		case off > start:
+			return start
+		}
+		return off
+	}
+	if s.scoped {
+		s.scopeStart, s.scopeEnd = shift(s.scopeStart, false), shift(s.scopeEnd, true)
+	}
+
+	currentStart := -1
+	if m, ok := s.CurrentMatch(); ok {
+		currentStart = shift(m.Start, false)
+	}
+	defer func() {
+		s.current = -1
+		if currentStart >= 0 {
+			idx := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].Start >= currentStart })
+			if idx < len(s.matches) {
+				s.current = idx
+			}
+		}
+	}()
+
+	if !s.done && start > s.next {
+		// the edit is after the matches found so far.
+		return
+	}
+
+	// search again from the start of the line of the edit, or from the first match
+	// overlapping the line.
+	regionStart := s.lineStart(start)
+	i := sort.Search(len(s.matches), func(i int) bool { return s.matches[i].End > regionStart })
+	if i < len(s.matches) {
+		regionStart = min(regionStart, s.matches[i].Start)
+	}
+	scopeStart, _ := s.Scope()
+	regionStart = max(regionStart, scopeStart)
+	regionEnd := s.lineEnd(newEnd)
+
+	if !s.done {
+		s.matches = s.matches[:i]
+		s.next = regionStart
+		return
+	}
+
+	var tail []SearchMatch
+	for _, m := range s.matches[i:] {
+		if m.Start < oldEnd || m.Start+delta < regionEnd {
+			continue
+		}
+		m.Start += delta
+		m.End += delta
+		groups := make([]int, len(m.Groups))
+		for k, g := range m.Groups {
+			groups[k] = g
+			if g >= 0 {
+				groups[k] += delta
+			}
+		}
+		m.Groups = groups
+		tail = append(tail, m)
+	}
+	capped := len(s.matches) >= s.opts.MaxMatches
+
+	s.matches = s.matches[:i]
+	s.next = regionStart
+	s.done = false
+	for {
+		m, ok := s.find(s.next)
+		if !ok {
+			s.done = true
+			return
+		}
+
+		if m.Start >= regionEnd {
+			for len(tail) > 0 && tail[0].Start < m.Start {
+				tail = tail[1:]
+			}
+			if len(tail) > 0 && tail[0].Start == m.Start && tail[0].End == m.End {
+				// in sync with the previous matches.
+				s.matches = append(s.matches, tail...)
+				s.next = tail[len(tail)-1].End
+				s.done = capped
+				if len(s.matches) >= s.opts.MaxMatches {
+					s.matches = s.matches[:s.opts.MaxMatches]
+					s.next = s.matches[len(s.matches)-1].End
+					s.done = true
+				}
+				return
+			}
+		}
+
+		s.matches = append(s.matches, m)
+		s.next = m.End
+		if len(s.matches) >= s.opts.MaxMatches {
+			s.done = true
+			return
+		}
+	}
+}
+
+// lineStart returns the offset of the start of the line containing runeOff.
+func (s *Searcher) lineStart(runeOff int) int {
+	for runeOff > 0 {
+		if r, err := s.view.src.ReadRuneAt(runeOff - 1); err != nil || r == '\n' {
+			break
+		}
+		runeOff--
+	}
+	return runeOff
+}
+
+// lineEnd returns the offset after the line break of the line containing runeOff.
+func (s *Searcher) lineEnd(runeOff int) int {
+	length := s.view.src.Len()
+	for runeOff < length {
+		r, err := s.view.src.ReadRuneAt(runeOff)
+		runeOff++
+		if err != nil || r == '\n' {
+			break
+		}
+	}
+	return runeOff
+}
+
+// Expand returns the replacement of the match, with the references to the groups
+// in template, like $1 or ${name}, replaced with the text of the groups. The
+// template is returned as is if the query is not a regular expression.
+func (s *Searcher) Expand(template string, m SearchMatch) string {
+	if !s.opts.Regex {
+		return template
+	}
+
+	startOff := s.view.src.RuneOffset(m.Start)
+	endOff := s.view.src.RuneOffset(m.End)
+	text := make([]byte, endOff-startOff)
+	n, _ := s.view.src.ReadAt(text, int64(startOff))
+	text = text[:n]
+
+	groups := make([]int, len(m.Groups))
+	for i, g := range m.Groups {
+		groups[i] = -1
+		if g >= 0 {
+			groups[i] = s.view.src.RuneOffset(g) - startOff
+		}
+	}
+	return string(s.re.Expand(nil, []byte(template), text, groups))
+}
+
+// runeCounter reports the runes read as one byte wide, so the indices matched by
+// a regular expression reading from it are rune offsets.
+type runeCounter struct {
+	r io.RuneReader
+}
+
+func (c runeCounter) ReadRune() (rune, int, error) {
+	r, _, err := c.r.ReadRune()
+	if err != nil {
+		return r, 0, err
+	}
+	return r, 1, nil
+}
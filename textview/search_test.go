@@ -0,0 +1,219 @@
+package textview
+
+import (
+	"slices"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+)
+
+func collectMatches(s *Searcher) [][2]int {
+	var got [][2]int
+	for m := range s.Matches() {
+		got = append(got, [2]int{m.Start, m.End})
+	}
+	return got
+}
+
+func TestSearcher(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		query string
+		opts  SearchOptions
+		want  [][2]int
+	}{
+		{
+			name:  "literal",
+			text:  "foo.bar foo*bar",
+			query: "foo.",
+			want:  [][2]int{{0, 4}},
+		},
+		{
+			name:  "ignore case",
+			text:  "Foo foo FOO",
+			query: "foo",
+			opts:  SearchOptions{IgnoreCase: true},
+			want:  [][2]int{{0, 3}, {4, 7}, {8, 11}},
+		},
+		{
+			name:  "whole word",
+			text:  "foo foobar barfoo foo",
+			query: "foo",
+			opts:  SearchOptions{WholeWord: true},
+			want:  [][2]int{{0, 3}, {18, 21}},
+		},
+		{
+			name:  "regex with unicode",
+			text:  "你好 a1 世界 b22",
+			query: `[a-z]\d+`,
+			opts:  SearchOptions{Regex: true},
+			want:  [][2]int{{3, 5}, {9, 12}},
+		},
+		{
+			name:  "regex assertions in the middle of the text",
+			text:  "abab ab\nab",
+			query: `\bab|^ab`,
+			opts:  SearchOptions{Regex: true},
+			want:  [][2]int{{0, 2}, {5, 7}, {8, 10}},
+		},
+		{
+			name:  "empty matches are skipped",
+			text:  "aa b aaa",
+			query: `a*`,
+			opts:  SearchOptions{Regex: true},
+			want:  [][2]int{{0, 2}, {5, 8}},
+		},
+		{
+			name:  "cap",
+			text:  "a a a a a",
+			query: "a",
+			opts:  SearchOptions{MaxMatches: 3},
+			want:  [][2]int{{0, 1}, {2, 3}, {4, 5}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vw := NewTextView()
+			vw.SetText(tc.text)
+			s, err := vw.NewSearcher(tc.query, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := collectMatches(s); !slices.Equal(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSearcherScope(t *testing.T) {
+	vw := NewTextView()
+	vw.SetText("foo foo foo foo")
+	s, _ := vw.NewSearcher("foo", SearchOptions{})
+
+	s.SetScope(4, 12)
+	want := [][2]int{{4, 7}, {8, 11}}
+	if got := collectMatches(s); !slices.Equal(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	s.ClearScope()
+	if n, capped := s.Count(); n != 4 || capped {
+		t.Errorf("want 4 matches, got %d, capped: %v", n, capped)
+	}
+}
+
+func TestSearcherCurrent(t *testing.T) {
+	vw := NewTextView()
+	vw.SetText("foo foo foo")
+	s, _ := vw.NewSearcher("foo", SearchOptions{})
+
+	if m, ok := s.SelectAt(5, false); !ok || m.Start != 8 {
+		t.Errorf("unexpected match: %v", m)
+	}
+	if idx, total := s.Current(); idx != 2 || total != 3 {
+		t.Errorf("want match 2 of 3, got %d of %d", idx, total)
+	}
+
+	// wrap around.
+	if m, _ := s.NextMatch(); m.Start != 0 {
+		t.Errorf("unexpected match: %v", m)
+	}
+	if m, _ := s.PrevMatch(); m.Start != 8 {
+		t.Errorf("unexpected match: %v", m)
+	}
+	if m, _ := s.SelectAt(8, true); m.Start != 4 {
+		t.Errorf("unexpected match: %v", m)
+	}
+}
+
+func TestSearcherUpdate(t *testing.T) {
+	vw := NewTextView()
+	vw.SetText("foo bar\nbaz foo\nfoo\nqux foo\n")
+	s, _ := vw.NewSearcher("foo", SearchOptions{})
+	s.Count()
+	s.SelectAt(16, false)
+
+	edits := []struct {
+		start, end int
+		text       string
+	}{
+		{8, 11, "foo"},   // replace baz with a match.
+		{4, 7, "xx"},     // shrink the first line.
+		{0, 3, ""},       // delete the first match.
+		{5, 7, "fo\nfo"}, // break the second match.
+	}
+
+	for _, ed := range edits {
+		vw.src.Replace(ed.start, ed.end, ed.text)
+		s.Update(ed.start, ed.end, ed.start+len([]rune(ed.text)))
+
+		want := collectMatches(mustSearcher(t, vw, "foo"))
+		if got := collectMatches(s); !slices.Equal(got, want) {
+			t.Errorf("after %v: want %v, got %v", ed, want, got)
+		}
+	}
+
+	// the current match follows the edits.
+	if m, ok := s.CurrentMatch(); !ok || m.Start != 15 {
+		t.Errorf("unexpected current match: %v, %v", m, ok)
+	}
+
+	// edits made through the view update the searcher.
+	vw.Layout(layout.Context{}, text.NewShaper())
+	vw.Replace(0, 0, "foo")
+	want := collectMatches(mustSearcher(t, vw, "foo"))
+	if got := collectMatches(s); !slices.Equal(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	s.Close()
+	vw.Replace(0, 0, "foo")
+	if got := collectMatches(s); slices.Equal(got, collectMatches(mustSearcher(t, vw, "foo"))) {
+		t.Error("closed searcher is updated")
+	}
+}
+
+func mustSearcher(t *testing.T, vw *TextView, query string) *Searcher {
+	s, err := vw.NewSearcher(query, SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSearcherUndoRedo(t *testing.T) {
+	vw := NewTextView()
+	vw.SetText("foo bar foo\nbaz foo\n")
+	vw.Layout(layout.Context{}, text.NewShaper())
+	s, _ := vw.NewSearcher("foo", SearchOptions{})
+	s.SetScope(8, 19)
+	s.SelectAt(16, false)
+
+	check := func(step string, scopeStart int) {
+		t.Helper()
+		if start, end := s.Scope(); start != scopeStart || end != scopeStart+11 {
+			t.Errorf("%s: want the scope at [%d, %d), got [%d, %d)", step, scopeStart, scopeStart+11, start, end)
+		}
+		want := mustSearcher(t, vw, "foo")
+		want.SetScope(s.Scope())
+		if got := collectMatches(s); !slices.Equal(got, collectMatches(want)) {
+			t.Errorf("%s: want %v, got %v", step, collectMatches(want), got)
+		}
+		// the current match follows the edits instead of being reset.
+		if m, ok := s.CurrentMatch(); !ok || m.Start != scopeStart+8 {
+			t.Errorf("%s: unexpected current match: %v, %v", step, m, ok)
+		}
+	}
+
+	vw.Replace(0, 0, "foo ")
+	check("replace", 12)
+	vw.Undo()
+	check("undo", 8)
+	vw.Redo()
+	check("redo", 12)
+}
@@ -0,0 +1,182 @@
+package textview
+
+import (
+	"image"
+	"slices"
+
+	"gioui.org/f32"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	lt "github.com/oligo/gvcode/internal/layout"
+)
+
+// ScopeKind determines how the scopes enclosing a line are detected.
+type ScopeKind uint8
+
+const (
+	// ScopeByIndentation treats a line followed by more indented lines as the
+	// header of a scope.
+	ScopeByIndentation ScopeKind = iota
+	// ScopeByBrackets treats the lines containing unclosed opening brackets as
+	// the headers of scopes. The brackets in strings and comments are skipped if
+	// the syntax tokens mark them.
+	ScopeByBrackets
+)
+
+// ScopeProvider returns the logical lines of the headers of the scopes enclosing
+// line, ordered from the outermost to the innermost scope. It can be used by
+// applications to supply scopes from a syntax tree or a language server.
+type ScopeProvider func(line int) []int
+
+// maxBracketScanRunes limits how far the bracket scope detection scans backward,
+// to keep it cheap on large documents.
+const maxBracketScanRunes = 1 << 16
+
+// EnclosingScopes returns the logical lines of the headers of the scopes enclosing
+// line, ordered from the outermost to the innermost scope.
+func (e *TextView) EnclosingScopes(line int, kind ScopeKind) []int {
+	e.makeValid()
+	if line <= 0 || line >= len(e.layouter.Paragraphs) {
+		return nil
+	}
+
+	switch kind {
+	case ScopeByBrackets:
+		return e.bracketScopes(line)
+	default:
+		return e.indentScopes(line)
+	}
+}
+
+func (e *TextView) indentScopes(line int) []int {
+	e.guides.reset(e)
+	level := e.guides.level(line)
+
+	var headers []int
+	for i := line - 1; i >= 0 && level > 0; i-- {
+		l, blank := e.guides.paragraphLevel(i)
+		if blank || l >= level {
+			continue
+		}
+		headers = append(headers, i)
+		level = l
+	}
+
+	slices.Reverse(headers)
+	return headers
+}
+
+func (e *TextView) bracketScopes(line int) []int {
+	stack := &bracketStack{}
+	stack.reset()
+
+	var headers []int
+	start := e.layouter.Paragraphs[line].RuneOff
+	for offset := start - 1; offset >= max(0, start-maxBracketScanRunes); offset-- {
+		r, err := e.src.ReadRuneAt(offset)
+		if err != nil {
+			break
+		}
+
+		exists, _ := e.BracketsQuotes.ContainsBracket(r)
+		if !exists || e.bracketScope(offset) != "" {
+			// the brackets in strings and comments are skipped.
+			continue
+		}
+		if _, ok := e.BracketsQuotes.GetOpeningBracket(r); ok {
+			stack.push(r, offset)
+			continue
+		}
+
+		closing, ok := e.BracketsQuotes.GetClosingBracket(r)
+		if !ok {
+			continue
+		}
+		if top, _ := stack.peek(); stack.depth() > 0 && top == closing {
+			stack.pop()
+			continue
+		}
+
+		// an unclosed opening bracket starts a scope.
+		header, _ := e.FindParagraph(offset)
+		if header < line && (len(headers) == 0 || headers[len(headers)-1] != header) {
+			headers = append(headers, header)
+		}
+	}
+
+	slices.Reverse(headers)
+	return headers
+}
+
+// LineTop returns the top y coordinate of the first screen line of the logical line
+// relative to the viewport.
+func (e *TextView) LineTop(line int) int {
+	if line < 0 || line >= len(e.layouter.Paragraphs) {
+		return 0
+	}
+	screenLine := e.screenLineOf(line)
+	return e.layouter.Lines[screenLine].YOff - e.layouter.Lines[screenLine].Ascent.Ceil() - e.scrollOff.Y
+}
+
+// LineAt returns the logical line at the y coordinate relative to the viewport.
+func (e *TextView) LineAt(y int) int {
+	pos := e.layouter.ClosestToXY(0, y+e.scrollOff.Y)
+	line, _ := e.FindParagraph(pos.Runes)
+	return line
+}
+
+// screenLineOf returns the index of the first screen line of the logical line.
+func (e *TextView) screenLineOf(line int) int {
+	pos, _ := e.layouter.ClosestToRune(e.layouter.Paragraphs[line].RuneOff)
+	return pos.LineCol.Line
+}
+
+// PaintStickyLines paints the first screen line of each of the logical lines
+// pinned at the top of the viewport, over a background painted with bgMaterial.
+// The lines are painted with the syntax styles and decorations, like the normal
+// text. A separator is painted below the last pinned line using borderMaterial.
+// It returns the height of the pinned area.
+func (e *TextView) PaintStickyLines(gtx layout.Context, lines []int, material, bgMaterial, borderMaterial op.CallOp) int {
+	if len(lines) == 0 || len(e.layouter.Lines) == 0 {
+		return 0
+	}
+
+	lineHeight := e.lineHeight.Ceil()
+	height := lineHeight * len(lines)
+	defer clip.Rect(image.Rectangle{Max: e.viewSize}).Push(gtx.Ops).Pop()
+
+	bg := clip.Rect(image.Rect(0, 0, e.viewSize.X, height)).Push(gtx.Ops)
+	bgMaterial.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	bg.Pop()
+
+	for i, line := range lines {
+		if line < 0 || line >= len(e.layouter.Paragraphs) {
+			continue
+		}
+
+		screenLine := e.screenLineOf(line)
+		l := e.layouter.Lines[screenLine]
+		top := l.YOff - l.Ascent.Ceil()
+
+		// move the document viewport to the line and paint it at its slot.
+		viewport := image.Rect(e.scrollOff.X, top, e.scrollOff.X+e.viewSize.X, top+lineHeight)
+		slot := op.Affine(f32.Affine2D{}.Offset(f32.Pt(0, float32(i*lineHeight)))).Push(gtx.Ops)
+		e.textPainter.SetViewport(viewport, viewport.Min)
+		e.textPainter.Paint(gtx, e.shaper, []lt.Line{l}, material, e.syntaxStyles, e.decorations, nil)
+		slot.Pop()
+	}
+
+	// restore the viewport of the normal text.
+	e.textPainter.SetViewport(image.Rectangle{Min: e.scrollOff, Max: e.viewSize.Add(e.scrollOff)}, e.scrollOff)
+
+	border := clip.Rect(image.Rect(0, height, e.viewSize.X, height+max(1, gtx.Dp(unit.Dp(1))))).Push(gtx.Ops)
+	borderMaterial.Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	border.Pop()
+
+	return height
+}
@@ -0,0 +1,58 @@
+package textview
+
+import (
+	"slices"
+	"testing"
+
+	"gioui.org/layout"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+func TestEnclosingScopes(t *testing.T) {
+	vw := NewTextView()
+	vw.TabWidth = 4
+	vw.TextSize = unit.Sp(14)
+	vw.SetText("func a() {\n\tif b {\n\t\tc(\n\t\t\td,\n\t\t)\n\n\t}\n}\n")
+
+	gtx := layout.Context{}
+	shaper := text.NewShaper()
+	vw.Layout(gtx, shaper)
+
+	cases := []struct {
+		line int
+		kind ScopeKind
+		want []int
+	}{
+		{line: 0, kind: ScopeByIndentation, want: nil},
+		{line: 2, kind: ScopeByIndentation, want: []int{0, 1}},
+		{line: 3, kind: ScopeByIndentation, want: []int{0, 1, 2}},
+		// blank line inside the if block.
+		{line: 5, kind: ScopeByIndentation, want: []int{0, 1}},
+		{line: 3, kind: ScopeByBrackets, want: []int{0, 1, 2}},
+		{line: 6, kind: ScopeByBrackets, want: []int{0, 1}},
+		{line: 7, kind: ScopeByBrackets, want: []int{0}},
+	}
+
+	for _, tc := range cases {
+		got := vw.EnclosingScopes(tc.line, tc.kind)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("line %d, kind %d: want %v, got %v", tc.line, tc.kind, tc.want, got)
+		}
+	}
+}
+
+func TestBracketScopesSkipStrings(t *testing.T) {
+	// f(
+	// ")", // (
+	// x
+	view := setupBracketView("f(\n\")\", // (\nx\n)",
+		syntax.Token{Start: 3, End: 6, Scope: "string"},
+		syntax.Token{Start: 8, End: 12, Scope: "comment"},
+	)
+
+	if got := view.EnclosingScopes(2, ScopeByBrackets); !slices.Equal(got, []int{0}) {
+		t.Errorf("want [0], got %v", got)
+	}
+}
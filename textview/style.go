@@ -1,6 +1,8 @@
 package textview
 
 import (
+	"math"
+
 	"github.com/oligo/gvcode/textstyle/decoration"
 	"github.com/oligo/gvcode/textstyle/syntax"
 )
@@ -10,6 +12,12 @@ func (e *TextView) AddDecorations(styles ...decoration.Decoration) error {
 		panic("TextView is not properly initialized.")
 	}
 
+	for _, deco := range styles {
+		if deco.Bold || deco.Italic {
+			// bold or italic text has to be reshaped.
+			e.fontStyles.markDirty(deco.Start, deco.End)
+		}
+	}
 	return e.decorations.Insert(styles...)
 }
 
@@ -18,6 +26,12 @@ func (e *TextView) ClearDecorations(source string) error {
 		panic("TextView is not properly initialized.")
 	}
 
+	for _, deco := range e.decorations.QueryRange(0, math.MaxInt) {
+		if (source == "" || deco.Source == source) && (deco.Bold || deco.Italic) {
+			e.fontStyles.markDirty(deco.Start, deco.End)
+		}
+	}
+
 	if source == "" {
 		return e.decorations.RemoveAll()
 	} else {
@@ -27,13 +41,39 @@ func (e *TextView) ClearDecorations(source string) error {
 
 func (e *TextView) SetColorScheme(scheme *syntax.ColorScheme) {
 	e.syntaxStyles = syntax.NewTextTokens(scheme)
+	e.invalidate()
 }
 
 func (e *TextView) SetSyntaxTokens(tokens ...syntax.Token) {
 	if e.syntaxStyles == nil {
 		panic("TextView is not properly initialized.")
 	}
+	styles := &e.fontStyles
+	styles.prevSpans = syntaxFontSpans(e.syntaxStyles, 0, math.MaxInt, styles.prevSpans[:0])
 	e.syntaxStyles.Set(tokens...)
+	styles.spans = syntaxFontSpans(e.syntaxStyles, 0, math.MaxInt, styles.spans[:0])
+	if start, end, ok := changedSpans(styles.prevSpans, styles.spans); ok {
+		// only the bold or italic text that changed has to be reshaped.
+		styles.markDirty(start, end)
+	}
+}
+
+// SyntaxScopeAt returns the scope of the syntax token containing the rune at
+// runeOff.
+func (e *TextView) SyntaxScopeAt(runeOff int) (syntax.StyleScope, bool) {
+	if e.syntaxStyles == nil {
+		return "", false
+	}
+	return e.syntaxStyles.ScopeAt(runeOff)
+}
+
+// QuerySyntaxTokens returns the syntax token styles overlapping the rune range
+// [start, end).
+func (e *TextView) QuerySyntaxTokens(start, end int) []syntax.TokenStyle {
+	if e.syntaxStyles == nil {
+		return nil
+	}
+	return e.syntaxStyles.QueryRange(start, end)
 }
 
 // UpdateSyntaxTokensOffset adjusts existing syntax token offsets after a text edit.
@@ -3,6 +3,7 @@ package textview
 import (
 	"image"
 	"math"
+	"regexp"
 	"unicode/utf8"
 
 	"gioui.org/f32"
@@ -13,6 +14,7 @@ import (
 	"github.com/oligo/gvcode/internal/buffer"
 	lt "github.com/oligo/gvcode/internal/layout"
 	"github.com/oligo/gvcode/internal/painter"
+	"github.com/oligo/gvcode/language"
 	"github.com/oligo/gvcode/textstyle/decoration"
 	"github.com/oligo/gvcode/textstyle/syntax"
 	"golang.org/x/exp/slices"
@@ -23,6 +25,37 @@ import (
 // shaped text.
 type Region = lt.Region
 
+// WrapPolicy configures the width at which lines are wrapped when WrapLine
+// is enabled.
+type WrapPolicy = lt.WrapPolicy
+
+const (
+	// WrapViewport wraps lines at the width of the viewport.
+	WrapViewport = lt.WrapViewport
+	// WrapColumn wraps lines at WrapColumn columns, regardless of the
+	// viewport width.
+	WrapColumn = lt.WrapColumn
+	// WrapBounded wraps lines at WrapColumn columns or the viewport width,
+	// whichever is smaller.
+	WrapBounded = lt.WrapBounded
+)
+
+// WhitespaceMode controls which whitespace characters are rendered visibly.
+type WhitespaceMode = painter.WhitespaceMode
+
+const (
+	// WhitespaceNone renders no whitespace.
+	WhitespaceNone = painter.WhitespaceNone
+	// WhitespaceBoundary renders all whitespace except single spaces between words.
+	WhitespaceBoundary = painter.WhitespaceBoundary
+	// WhitespaceSelection renders whitespace inside the selection only.
+	WhitespaceSelection = painter.WhitespaceSelection
+	// WhitespaceTrailing renders whitespace at the end of lines only.
+	WhitespaceTrailing = painter.WhitespaceTrailing
+	// WhitespaceAll renders all whitespace, including line endings.
+	WhitespaceAll = painter.WhitespaceAll
+)
+
 type caretPos struct {
 	// xoff is the offset to the current position when moving between lines.
 	xoff fixed.Int26_6
@@ -71,10 +104,38 @@ type TextView struct {
 
 	// WrapLine configures whether the displayed text will be broken into lines or not.
 	WrapLine bool
+	// WrapPolicy configures where lines are broken when WrapLine is enabled.
+	WrapPolicy WrapPolicy
+	// WrapColumn sets the column, measured in advances of the space glyph, at
+	// which lines are broken under the WrapColumn or WrapBounded policy.
+	WrapColumn int
+
+	// WhitespaceMode configures which whitespace characters are rendered
+	// visibly. Control characters are rendered as code point labels unless
+	// the mode is WhitespaceNone.
+	WhitespaceMode WhitespaceMode
+
+	// SmoothScroll enables animating the scrolls to the caret, e.g., when the caret
+	// is moved by keyboard or by pages.
+	SmoothScroll bool
+	// ScrollPastEnd allows scrolling past the last line by up to one viewport.
+	ScrollPastEnd bool
+	// CaretMargin is the minimum number of lines kept visible above and below the
+	// caret when scrolling to the caret.
+	CaretMargin int
+	// Typewriter keeps the caret line vertically centered in the viewport.
+	Typewriter bool
 
 	// WordSeperators configures a set of characters that will be used as word separators
 	// when doing word related operations, like navigating or deleting by word.
 	WordSeperators string
+	// WordPattern matches the words of the text. If it is set, it is used to find
+	// the word at a position instead of WordSeperators.
+	WordPattern *regexp.Regexp
+	// IndentRules decides the indentation of the lines when a line break is
+	// inserted or a line is typed. If it is nil, the indentation of the
+	// previous line is kept.
+	IndentRules *language.IndentationRules
 	// Brackets and quote pairs that can be auto-completed when the left half is entered.
 	BracketsQuotes *bracketsQuotes
 
@@ -102,9 +163,18 @@ type TextView struct {
 	valid bool
 	// caret position in the view.
 	caret   caretPos
+	column  columnSelection
 	regions []Region
+	// searchers are the live searchers updated after edits.
+	searchers []*Searcher
 	// line buffer for line related operations.
 	lineBuf []byte
+	// indentation levels used to paint the indent guides.
+	guides indentGuides
+	// fontStyles resolves bold and italic styles for the layout.
+	fontStyles fontStyles
+	// smoothScroll animates the scrolls to the caret.
+	smoothScroll smoothScroller
 }
 
 func NewTextView() *TextView {
@@ -118,6 +188,8 @@ func NewTextView() *TextView {
 func (e *TextView) setSource(source buffer.TextSource) {
 	e.src = source
 	e.layouter = lt.NewTextLayout(e.src)
+	e.fontStyles.view = e
+	e.layouter.FontRuns = &e.fontStyles
 	e.BracketsQuotes = &bracketsQuotes{}
 	e.decorations = decoration.NewDecorationTree(e.src)
 	e.invalidate()
@@ -139,6 +211,17 @@ func (e *TextView) SetWrapLine(enabled bool) {
 	}
 }
 
+// SetWrapPolicy configures where lines are broken when line wrapping is
+// enabled. column is ignored by the WrapViewport policy.
+func (e *TextView) SetWrapPolicy(policy WrapPolicy, column int) {
+	changed := e.WrapPolicy != policy || e.WrapColumn != column
+	e.WrapPolicy = policy
+	e.WrapColumn = column
+	if changed && e.WrapLine {
+		e.invalidate()
+	}
+}
+
 // Dimensions returns the dimensions of the visible text.
 func (e *TextView) Dimensions() layout.Dimensions {
 	basePos := e.dims.Size.Y - e.dims.Baseline
@@ -153,9 +236,13 @@ func (e *TextView) FullDimensions() layout.Dimensions {
 
 func (e *TextView) makeValid() {
 	if e.valid {
+		if start, end, ok := e.fontStyles.takeDirty(); ok {
+			e.relayoutText(e.shaper, start, end)
+		}
 		return
 	}
 	e.layoutText(e.shaper)
+	e.fontStyles.takeDirty()
 	e.valid = true
 }
 
@@ -244,6 +331,12 @@ func (e *TextView) Layout(gtx layout.Context, lt *text.Shaper) {
 		e.shaper = lt
 		e.invalidate()
 	}
+	if e.WrapPolicy != e.layouter.WrapPolicy || e.WrapColumn != e.layouter.WrapColumn {
+		e.invalidate()
+	}
+	if (e.WhitespaceMode != WhitespaceNone) != e.layouter.ExpandControlChars {
+		e.invalidate()
+	}
 	if e.Alignment != e.params.Alignment {
 		e.params.Alignment = e.Alignment
 		e.invalidate()
@@ -307,10 +400,15 @@ func (e *TextView) Len() int {
 }
 
 func (e *TextView) ScrollBounds() image.Rectangle {
-	return image.Rectangle{Max: image.Point{X: e.dims.Size.X - e.viewSize.X, Y: e.dims.Size.Y - e.viewSize.Y}}
+	return image.Rectangle{Max: image.Point{X: e.dims.Size.X - e.viewSize.X, Y: e.dims.Size.Y - e.viewSize.Y + e.scrollPastEnd()}}
 }
 
+// ScrollRel scrolls the viewport by dx and dy pixels, stopping any running
+// scroll animation.
 func (e *TextView) ScrollRel(dx, dy int) {
+	if dx != 0 || dy != 0 {
+		e.smoothScroll.stop()
+	}
 	e.scrollAbs(e.scrollOff.X+dx, e.scrollOff.Y+dy)
 }
 
@@ -418,6 +516,8 @@ func (e *TextView) SetText(s string) int {
 	sc := e.src.Len()
 
 	// e.SetCaret(0, 0)
+	e.column = columnSelection{}
+	e.resetSearchers()
 	e.invalidate()
 	return sc
 }
@@ -436,6 +536,9 @@ func (e *TextView) Replace(start, end int, s string) int {
 	newEnd := startPos.Runes + sc
 
 	e.src.Replace(startOff, endPos.Runes, s)
+	for _, searcher := range e.searchers {
+		searcher.Update(startOff, endPos.Runes, newEnd)
+	}
 	adjust := func(pos int) int {
 		switch {
 		case newEnd < pos && pos < endPos.Runes:
@@ -479,6 +582,13 @@ func (e *TextView) moveByGraphemes(startRuneIdx, graphemes int) int {
 	return s
 }
 
+// NextGrapheme returns the rune offset of the grapheme cluster boundary after
+// runeOff, or the length of the text if runeOff is in the last cluster.
+func (e *TextView) NextGrapheme(runeOff int) int {
+	e.makeValid()
+	return e.moveByGraphemes(runeOff, 1)
+}
+
 // clampCursorToGraphemes ensures that the final start/end positions of
 // the cursor are on grapheme cluster boundaries.
 func (e *TextView) clampCursorToGraphemes() {
@@ -541,33 +651,14 @@ func (e *TextView) MoveLineEnd(selAct SelectionAction) {
 	e.clampCursorToGraphemes()
 }
 
+// ScrollToCaret scrolls the viewport by the minimum distance to show the caret
+// with the caret margin around it, or centers the caret in the typewriter mode.
 func (e *TextView) ScrollToCaret() {
-	caret := e.closestToRune(e.caret.start)
-
-	miny := caret.Y - caret.Ascent.Ceil()
-	maxy := caret.Y + caret.Descent.Ceil()
-
-	// The configures the distance in pixel the caret is from the horizontal border
-	// of the editor, at which point we should scroll to adjust the viewport.
-	// This ensures the caret can easily be seen when scrolled hotizontally.
-	minScrollGap := (e.params.PxPerEm * 1).Ceil()
-
-	var xdist, ydist int
-	// calculate x delta, accounting the padding
-	if d := caret.X.Floor() - minScrollGap - e.scrollOff.X; d < 0 {
-		xdist = d
-	} else if d := caret.X.Ceil() + minScrollGap - (e.scrollOff.X + e.viewSize.X); d > 0 {
-		xdist = d
+	align := scrollNearest
+	if e.Typewriter {
+		align = ScrollCenter
 	}
-
-	// calcualte y delta
-	if d := miny - e.scrollOff.Y; d < 0 {
-		ydist = d
-	} else if d := maxy - (e.scrollOff.Y + e.viewSize.Y); d > 0 {
-		ydist = d
-	}
-
-	e.ScrollRel(xdist, ydist)
+	e.scrollToRange(e.caret.start, e.caret.start, align)
 }
 
 // SelectionLen returns the length of the selection, in runes; it is
@@ -629,6 +720,7 @@ func (e *TextView) ClearSelection() {
 func (e *TextView) Undo() ([]buffer.CursorPos, bool) {
 	cursors, ok := e.src.Undo()
 	if ok {
+		e.updateSearchers(e.src.RestoredEdits())
 		e.invalidate()
 	}
 
@@ -639,6 +731,7 @@ func (e *TextView) Undo() ([]buffer.CursorPos, bool) {
 func (e *TextView) Redo() ([]buffer.CursorPos, bool) {
 	cursors, ok := e.src.Redo()
 	if ok {
+		e.updateSearchers(e.src.RestoredEdits())
 		e.invalidate()
 	}
 
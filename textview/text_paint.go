@@ -28,21 +28,55 @@ func (e *TextView) calculateViewSize(gtx layout.Context) image.Point {
 
 func (e *TextView) layoutText(shaper *text.Shaper) {
 	//e.layoutByParagraph(shaper, &it)
+	e.layouter.WrapPolicy = e.WrapPolicy
+	e.layouter.WrapColumn = e.WrapColumn
+	e.layouter.ExpandControlChars = e.WhitespaceMode != WhitespaceNone
+	// decoration ranges are needed to resolve the font runs.
+	e.decorations.Refresh()
 	e.dims = e.layouter.Layout(shaper, &e.params, e.TabWidth, e.WrapLine)
 }
 
+// relayoutText reshapes the paragraphs covering the rune range [start, end),
+// whose font styles changed while the rest of the layout is still valid.
+func (e *TextView) relayoutText(shaper *text.Shaper, start, end int) {
+	e.decorations.Refresh()
+	e.dims = e.layouter.Relayout(shaper, start, end, e.TabWidth, e.WrapLine)
+}
+
 // PaintText clips and paints the visible text glyph outlines using the provided
 // material to fill the glyphs.
 func (e *TextView) PaintText(gtx layout.Context, material op.CallOp) {
+	e.PaintTextWithWhitespaces(gtx, material, op.CallOp{})
+}
+
+// PaintTextWithWhitespaces paints the text like PaintText, marking the whitespace
+// and control characters according to the WhitespaceMode with the whitespace
+// material in the same pass over the glyphs. Nothing is marked if the whitespace
+// material is empty.
+func (e *TextView) PaintTextWithWhitespaces(gtx layout.Context, material, whitespace op.CallOp) {
 	viewport := image.Rectangle{
 		Min: e.scrollOff,
 		Max: e.viewSize.Add(e.scrollOff),
 	}
 
+	var style *painter.WhitespaceStyle
+	if e.WhitespaceMode != WhitespaceNone && whitespace != (op.CallOp{}) {
+		style = &painter.WhitespaceStyle{
+			Mode:         e.WhitespaceMode,
+			Src:          e.src,
+			SelStart:     e.caret.start,
+			SelEnd:       e.caret.end,
+			Params:       e.params,
+			SpaceAdvance: e.layouter.SpaceAdvance(),
+			SpaceGlyph:   e.layouter.SpaceGlyphID(),
+			Color:        whitespace,
+		}
+	}
+
 	e.textPainter.SetViewport(viewport, e.scrollOff)
 	e.textPainter.SetLineHeight(e.lineHeight)
 	e.decorations.Refresh()
-	e.textPainter.Paint(gtx, e.shaper, e.layouter.Lines, material, e.syntaxStyles, e.decorations)
+	e.textPainter.Paint(gtx, e.shaper, e.layouter.Lines, material, e.syntaxStyles, e.decorations, style)
 }
 
 // selectionPolygons creates clip.PathSpecs for the given selection regions,
@@ -76,12 +110,23 @@ func (e *TextView) selectionPolygons(gtx layout.Context, regions []lt.Region) []
 }
 
 // PaintSelection clips and paints the visible text selection rectangles using
-// the provided material to fill the rectangles.
+// the provided material to fill the rectangles. If there is a column selection,
+// its rectangles are painted instead.
 func (e *TextView) PaintSelection(gtx layout.Context, material op.CallOp) {
+	if e.column.active {
+		e.paintColumnSelection(gtx, material)
+		return
+	}
+	e.PaintSelectionRange(gtx, e.caret.start, e.caret.end, material)
+}
+
+// PaintSelectionRange paints the selection rectangles of the rune range [start, end),
+// e.g., the selections of the secondary carets.
+func (e *TextView) PaintSelectionRange(gtx layout.Context, start, end int, material op.CallOp) {
 	localViewport := image.Rectangle{Max: e.viewSize}
 	docViewport := image.Rectangle{Max: e.viewSize}.Add(e.scrollOff)
 	defer clip.Rect(localViewport).Push(gtx.Ops).Pop()
-	e.regions = e.layouter.Locate(docViewport, e.caret.start, e.caret.end, e.regions)
+	e.regions = e.layouter.Locate(docViewport, start, end, e.regions)
 	//log.Println("regions count: ", len(e.regions), e.regions)
 	if len(e.regions) == 0 {
 		return
@@ -201,8 +246,13 @@ func (e *TextView) caretCurrentLine() (start lt.CombinedPos, end lt.CombinedPos,
 // PaintCaret clips and paints the caret rectangle, adding material immediately
 // before painting to set the appropriate paint material.
 func (e *TextView) PaintCaret(gtx layout.Context, material op.CallOp) {
+	e.PaintCaretAt(gtx, e.caret.start, material)
+}
+
+// PaintCaretAt paints a caret at the rune offset, e.g., for the secondary carets.
+func (e *TextView) PaintCaretAt(gtx layout.Context, runeOff int, material op.CallOp) {
 	carWidth2 := gtx.Dp(e.CaretWidth)
-	caretPos, carAsc, carDesc := e.CaretInfo()
+	caretPos, carAsc, carDesc := e.caretInfoAt(runeOff)
 
 	carRect := image.Rectangle{
 		Min: caretPos.Sub(image.Pt(carWidth2, carAsc)),
@@ -217,8 +267,38 @@ func (e *TextView) PaintCaret(gtx layout.Context, material op.CallOp) {
 	}
 }
 
+// PaintBlockCaretAt paints a caret covering the grapheme cluster at the rune
+// offset, like the caret of the overwrite mode. At the end of a line, it is as
+// wide as a space.
+func (e *TextView) PaintBlockCaretAt(gtx layout.Context, runeOff int, material op.CallOp) {
+	caretPos, carAsc, carDesc := e.caretInfoAt(runeOff)
+	width := e.layouter.SpaceAdvance().Ceil()
+	if r, err := e.src.ReadRuneAt(runeOff); err == nil && r != '\n' {
+		start := e.closestToRune(runeOff)
+		if next := e.closestToRune(e.NextGrapheme(runeOff)); next.Y == start.Y && next.X > start.X {
+			width = (next.X - start.X).Ceil()
+		}
+	}
+
+	carRect := image.Rectangle{
+		Min: caretPos.Sub(image.Pt(0, carAsc)),
+		Max: caretPos.Add(image.Pt(width, carDesc)),
+	}
+	cl := image.Rectangle{Max: e.viewSize}
+	carRect = cl.Intersect(carRect)
+	if !carRect.Empty() {
+		defer clip.Rect(e.adjustPadding(carRect)).Push(gtx.Ops).Pop()
+		material.Add(gtx.Ops)
+		paint.PaintOp{}.Add(gtx.Ops)
+	}
+}
+
 func (e *TextView) CaretInfo() (pos image.Point, ascent, descent int) {
-	caretStart := e.closestToRune(e.caret.start)
+	return e.caretInfoAt(e.caret.start)
+}
+
+func (e *TextView) caretInfoAt(runeOff int) (pos image.Point, ascent, descent int) {
+	caretStart := e.closestToRune(runeOff)
 
 	ascent = caretStart.Ascent.Ceil()
 	descent = caretStart.Descent.Ceil()
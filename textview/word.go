@@ -4,6 +4,7 @@ import (
 	"slices"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -120,6 +121,10 @@ func (e *TextView) ReadWord(bySpace bool) (string, int) {
 // The bySpace parameter controls whether only spaces are considered separators (true) or
 // custom word separators are used (false).
 func (e *TextView) WordBoundariesAt(caret int, bySpace bool) (start, end int) {
+	if !bySpace && e.WordPattern != nil {
+		return e.wordPatternAt(caret)
+	}
+
 	separator := func(r rune) bool {
 		if bySpace {
 			return unicode.IsSpace(r)
@@ -137,6 +142,30 @@ func (e *TextView) WordBoundariesAt(caret int, bySpace bool) (start, end int) {
 	return start, end
 }
 
+// wordPatternAt returns the start and end rune offsets of the match of WordPattern
+// in the line of caret that contains caret.
+func (e *TextView) wordPatternAt(caret int) (start, end int) {
+	_, p := e.FindParagraph(caret)
+	startOff := e.src.RuneOffset(p.RuneOff)
+	endOff := e.src.RuneOffset(p.RuneOff + p.Runes)
+	line := make([]byte, endOff-startOff)
+	n, _ := e.src.ReadAt(line, int64(startOff))
+	line = line[:n]
+
+	col := caret - p.RuneOff
+	for _, loc := range e.WordPattern.FindAllIndex(line, -1) {
+		matchStart := utf8.RuneCount(line[:loc[0]])
+		if matchStart > col {
+			break
+		}
+		matchEnd := matchStart + utf8.RuneCount(line[loc[0]:loc[1]])
+		if col <= matchEnd {
+			return p.RuneOff + matchStart, p.RuneOff + matchEnd
+		}
+	}
+	return caret, caret
+}
+
 // FindAllWordOccurrences returns the start and end rune offsets of all occurrences of the word
 // spanning from start to end (exclusive). The bySpace parameter controls whether only spaces
 // are considered separators (true) or custom word separators are used (false).
@@ -2,6 +2,7 @@ package textview
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"gioui.org/layout"
@@ -231,3 +232,29 @@ func TestFindAllWordOccurrences(t *testing.T) {
 		})
 	}
 }
+
+func TestWordBoundariesAtWithPattern(t *testing.T) {
+	view := NewTextView()
+	view.SetText("let a-b = 1\nfoo-bar.baz")
+	view.Layout(layout.Context{}, text.NewShaper())
+	view.WordPattern = regexp.MustCompile(`[\w-]+`)
+
+	testcases := []struct {
+		caret      int
+		start, end int
+	}{
+		{caret: 0, start: 0, end: 3},
+		{caret: 5, start: 4, end: 7},
+		{caret: 7, start: 4, end: 7},
+		{caret: 8, start: 8, end: 8},
+		{caret: 14, start: 12, end: 19},
+		{caret: 21, start: 20, end: 23},
+	}
+
+	for _, tc := range testcases {
+		start, end := view.WordBoundariesAt(tc.caret, false)
+		if start != tc.start || end != tc.end {
+			t.Errorf("WordBoundariesAt(%d) = [%d, %d), want [%d, %d)", tc.caret, start, end, tc.start, tc.end)
+		}
+	}
+}
@@ -21,6 +21,10 @@ func NewEditor(th *material.Theme) *gvcode.Editor {
 	colorScheme.SelectColor = gvcolor.MakeColor(th.ContrastBg).MulAlpha(0x60)
 	colorScheme.LineColor = gvcolor.MakeColor(th.ContrastBg).MulAlpha(0x30)
 	colorScheme.LineNumberColor = gvcolor.MakeColor(th.Fg).MulAlpha(0xb6)
+	colorScheme.RulerColor = gvcolor.MakeColor(th.Fg).MulAlpha(0x30)
+	colorScheme.WhitespaceColor = gvcolor.MakeColor(th.Fg).MulAlpha(0x50)
+	colorScheme.IndentGuideColor = gvcolor.MakeColor(th.Fg).MulAlpha(0x30)
+	colorScheme.ActiveIndentGuideColor = gvcolor.MakeColor(th.Fg).MulAlpha(0x90)
 
 	editor.WithOptions(
 		gvcode.WrapLine(false),
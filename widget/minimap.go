@@ -0,0 +1,226 @@
+package widget
+
+import (
+	"image"
+	"math"
+	"unicode/utf8"
+
+	"gioui.org/gesture"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"github.com/oligo/gvcode"
+	"github.com/oligo/gvcode/color"
+)
+
+// Minimap is a companion widget of the editor that renders a scaled-down overview
+// of the whole document. Each run of non-whitespace characters is drawn as a block
+// colored with the foreground of its syntax token, so no text is shaped. The visible
+// part of the editor is marked with a viewport rectangle, and clicking or dragging
+// in the minimap scrolls the editor.
+//
+// Minimap should be laid out after the editor, to use the up-to-date scroll position.
+type Minimap struct {
+	// Editor is the editor to render.
+	Editor *gvcode.Editor
+	// LineHeight is the height of a line in the minimap. Defaults to 2dp.
+	LineHeight unit.Dp
+	// CharWidth is the width of a column in the minimap. Defaults to 1dp.
+	CharWidth unit.Dp
+	// MaxColumn limits the columns rendered for each line. Defaults to 120.
+	MaxColumn int
+	// ViewportColor is used to fill the viewport rectangle. If not set, a faded
+	// foreground color of the editor is used.
+	ViewportColor color.Color
+
+	dragger gesture.Drag
+	// offset is the vertical offset of the minimap content in pixels, when the
+	// document does not fit in the minimap.
+	offset int
+	// buf is reused to read the text of the lines.
+	buf []byte
+}
+
+func (m *Minimap) lineHeight(gtx layout.Context) int {
+	if m.LineHeight <= 0 {
+		return max(1, gtx.Dp(unit.Dp(2)))
+	}
+	return max(1, gtx.Dp(m.LineHeight))
+}
+
+func (m *Minimap) charWidth(gtx layout.Context) float32 {
+	if m.CharWidth <= 0 {
+		return max(1, float32(gtx.Dp(unit.Dp(1))))
+	}
+	return max(1, float32(gtx.Dp(m.CharWidth)))
+}
+
+// Update handles the pointer events, scrolling the editor to put the viewport
+// center at the pointer position.
+func (m *Minimap) Update(gtx layout.Context) {
+	if m.Editor == nil {
+		return
+	}
+
+	for {
+		evt, ok := m.dragger.Update(gtx.Metric, gtx.Source, gesture.Vertical)
+		if !ok {
+			break
+		}
+
+		switch evt.Kind {
+		case pointer.Press, pointer.Drag:
+			contentHeight := m.Editor.Lines() * m.lineHeight(gtx)
+			if contentHeight <= 0 {
+				continue
+			}
+
+			// the rows of the minimap are logical lines, which are converted to
+			// scroll ratios of the editor as it may wrap them.
+			_, _, minY, maxY := m.Editor.ScrollRatio()
+			if math.IsNaN(float64(minY)) || math.IsInf(float64(minY), 0) ||
+				math.IsNaN(float64(maxY)) || math.IsInf(float64(maxY), 0) {
+				continue
+			}
+			line := (evt.Position.Y + float32(m.offset)) / float32(m.lineHeight(gtx))
+			target := m.Editor.LineRatio(line) - (maxY-minY)/2
+			target = max(0, min(target, 1-(maxY-minY)))
+			m.Editor.Scroll(gtx, 0, target-minY)
+			gtx.Execute(pointer.GrabCmd{Tag: &m.dragger, ID: evt.PointerID})
+			gtx.Execute(op.InvalidateCmd{})
+		}
+	}
+}
+
+// Layout draws the minimap and the viewport rectangle, filling the maximum
+// constraints.
+func (m *Minimap) Layout(gtx layout.Context) layout.Dimensions {
+	size := gtx.Constraints.Max
+	if m.Editor == nil {
+		return layout.Dimensions{Size: size}
+	}
+	m.Update(gtx)
+
+	defer clip.Rect(image.Rectangle{Max: size}).Push(gtx.Ops).Pop()
+	pointer.CursorDefault.Add(gtx.Ops)
+	m.dragger.Add(gtx.Ops)
+
+	lineHeight := m.lineHeight(gtx)
+	lines := m.Editor.Lines()
+	contentHeight := lines * lineHeight
+
+	// Scroll the minimap along with the editor if the document does not fit. The
+	// viewport is measured in logical lines, which are the rows of the minimap
+	// even if the editor wraps them.
+	top, bottom := m.Editor.VisibleLines()
+	m.offset = 0
+	if contentHeight > size.Y {
+		if scrollable := float32(lines) - (bottom - top); scrollable > 0 {
+			m.offset = int(float32(contentHeight-size.Y) * min(1, top/scrollable))
+		}
+	}
+
+	firstLine := m.offset / lineHeight
+	lastLine := min(lines-1, (m.offset+size.Y)/lineHeight)
+	for line := firstLine; line <= lastLine; line++ {
+		m.paintLine(gtx, line, line*lineHeight-m.offset, lineHeight)
+	}
+
+	// paint the viewport rectangle.
+	viewportColor := m.ViewportColor
+	if !viewportColor.IsSet() {
+		viewportColor = m.Editor.ColorPalette().Foreground.MulAlpha(0x20)
+	}
+	viewport := image.Rect(0, int(top*float32(lineHeight))-m.offset, size.X, int(bottom*float32(lineHeight))-m.offset)
+	viewport.Max.Y = max(viewport.Max.Y, viewport.Min.Y+lineHeight)
+	paintRect(gtx, viewport, viewportColor)
+
+	return layout.Dimensions{Size: size}
+}
+
+// paintLine paints a logical line as blocks of non-whitespace runes, using the
+// foreground colors of the syntax tokens.
+func (m *Minimap) paintLine(gtx layout.Context, line int, y int, height int) {
+	start, end := m.Editor.LineRange(line)
+	if start >= end {
+		return
+	}
+
+	palette := m.Editor.ColorPalette()
+	tokens := m.Editor.SyntaxTokens(start, end)
+	_, tabWidth := m.Editor.TabStyle()
+	tabWidth = max(1, tabWidth)
+	maxColumn := m.MaxColumn
+	if maxColumn <= 0 {
+		maxColumn = 120
+	}
+	charWidth := m.charWidth(gtx)
+
+	// a block is a run of non-whitespace runes of the same color.
+	blockStart := -1
+	var blockColor color.Color
+	commit := func(col int) {
+		if blockStart >= 0 && blockColor.IsSet() {
+			rect := image.Rect(int(float32(blockStart)*charWidth), y, int(float32(col)*charWidth), y+height)
+			paintRect(gtx, rect, blockColor.MulAlpha(0xb0))
+		}
+		blockStart = -1
+	}
+
+	// the line is read at once, limited to the runes that can be rendered.
+	m.buf = m.Editor.ReadRange(m.buf, start, min(end, start+maxColumn))
+	text := m.buf
+
+	col := 0
+	tokenIdx := 0
+	for runeOff := start; len(text) > 0 && col < maxColumn; runeOff++ {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+		if r == '\n' || r == '\r' {
+			break
+		}
+
+		if r == ' ' || r == '\t' {
+			commit(col)
+			if r == '\t' {
+				col = (col/tabWidth + 1) * tabWidth
+			} else {
+				col++
+			}
+			continue
+		}
+
+		runeColor := palette.Foreground
+		for tokenIdx < len(tokens) && tokens[tokenIdx].End <= runeOff {
+			tokenIdx++
+		}
+		if tokenIdx < len(tokens) && tokens[tokenIdx].Start <= runeOff {
+			if fg := palette.GetColor(tokens[tokenIdx].Style.Foreground()); fg.IsSet() {
+				runeColor = fg
+			}
+		}
+
+		if blockStart >= 0 && runeColor.NRGBA() != blockColor.NRGBA() {
+			commit(col)
+		}
+		if blockStart < 0 {
+			blockStart = col
+			blockColor = runeColor
+		}
+		col++
+	}
+	commit(min(col, maxColumn))
+}
+
+func paintRect(gtx layout.Context, rect image.Rectangle, material color.Color) {
+	if rect.Empty() || !material.IsSet() {
+		return
+	}
+	stack := clip.Rect(rect).Push(gtx.Ops)
+	material.Op(gtx.Ops).Add(gtx.Ops)
+	paint.PaintOp{}.Add(gtx.Ops)
+	stack.Pop()
+}
@@ -0,0 +1,117 @@
+package widget
+
+import (
+	"image"
+	"math"
+	"strings"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/io/input"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"github.com/oligo/gvcode"
+	"github.com/oligo/gvcode/textstyle/syntax"
+)
+
+// minimapWindow lays out an editor in a 500x500 viewport, with a 100x200 minimap
+// over its top left corner.
+type minimapWindow struct {
+	router  input.Router
+	ops     op.Ops
+	shaper  *text.Shaper
+	editor  *gvcode.Editor
+	minimap *Minimap
+}
+
+func newMinimapWindow(input string, opts ...gvcode.EditorOption) *minimapWindow {
+	editor := &gvcode.Editor{}
+	editor.WithOptions(append([]gvcode.EditorOption{gvcode.WithColorScheme(syntax.ColorScheme{}), gvcode.WithTextSize(14), gvcode.WithLineHeight(0, 1.2)}, opts...)...)
+	editor.SetText(input)
+	w := &minimapWindow{shaper: text.NewShaper(), editor: editor, minimap: &Minimap{Editor: editor}}
+	w.frame()
+	return w
+}
+
+func (w *minimapWindow) frame() {
+	w.ops.Reset()
+	gtx := layout.Context{
+		Ops:         &w.ops,
+		Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+		Constraints: layout.Exact(image.Pt(500, 500)),
+		Source:      w.router.Source(),
+	}
+	w.editor.Layout(gtx, w.shaper)
+	gtx.Constraints = layout.Exact(image.Pt(100, 200))
+	w.minimap.Layout(gtx)
+	w.router.Frame(&w.ops)
+}
+
+func (w *minimapWindow) scrollToLine(line float32) {
+	_, _, minY, _ := w.editor.ScrollRatio()
+	w.editor.Scroll(layout.Context{}, 0, w.editor.LineRatio(line)-minY)
+	w.frame()
+}
+
+func TestMinimapViewport(t *testing.T) {
+	// 500 lines of 2px do not fit in the minimap.
+	w := newMinimapWindow(strings.Repeat("line\n", 499) + "line")
+	if w.minimap.offset != 0 {
+		t.Fatalf("want no offset at the top, got %d", w.minimap.offset)
+	}
+
+	w.scrollToLine(500)
+	top, _ := w.editor.VisibleLines()
+	if top <= 0 {
+		t.Fatalf("want the editor scrolled, got the top at %v", top)
+	}
+	if want := 500*2 - 200; w.minimap.offset != want {
+		t.Errorf("want the offset %d at the end, got %d", want, w.minimap.offset)
+	}
+
+	// the viewport stays in the minimap while scrolling.
+	for _, line := range []float32{50, 200, 350} {
+		w.scrollToLine(line)
+		top, bottom := w.editor.VisibleLines()
+		if y := int(top*2) - w.minimap.offset; y < 0 || int(bottom*2)-w.minimap.offset > 200 {
+			t.Errorf("line %v: want the viewport in the minimap, got [%v, %v] with offset %d", line, top, bottom, w.minimap.offset)
+		}
+	}
+}
+
+func TestMinimapClickToScroll(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input string
+		opts  []gvcode.EditorOption
+	}{
+		{name: "unwrapped", input: strings.Repeat("line\n", 200)},
+		// the wrapped first line takes many screen lines of the editor, but a
+		// single row of the minimap.
+		{name: "wrapped", input: strings.Repeat("word ", 300) + strings.Repeat("\nline", 200), opts: []gvcode.EditorOption{gvcode.WrapLine(true)}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newMinimapWindow(tc.input, tc.opts...)
+
+			// clicking at line 60 centers the viewport on it.
+			w.router.Queue(pointer.Event{
+				Kind:     pointer.Press,
+				Source:   pointer.Mouse,
+				Buttons:  pointer.ButtonPrimary,
+				Position: f32.Pt(10, 60*2),
+			})
+			w.frame()
+			w.frame()
+
+			top, bottom := w.editor.VisibleLines()
+			if center := (top + bottom) / 2; math.Abs(float64(center-60)) > 1 {
+				t.Errorf("want the viewport centered at line 60, got [%v, %v]", top, bottom)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package gvcode
+
+import (
+	"math"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/unit"
+)
+
+const (
+	// MinZoom is the minimum zoom level of the editor.
+	MinZoom float32 = 0.5
+	// MaxZoom is the maximum zoom level of the editor.
+	MaxZoom float32 = 3
+	// zoomStep is the amount by which a single zoom in or out changes the level.
+	zoomStep float32 = 0.1
+)
+
+// A ZoomChangedEvent is generated when the zoom level of the editor changes,
+// e.g., using Ctrl+wheel, the zoom shortcuts or SetZoom. Applications can persist
+// the level and restore it using [WithZoom].
+type ZoomChangedEvent struct {
+	Zoom float32
+}
+
+func (s ZoomChangedEvent) isEditorEvent() {}
+
+// zoomState scales the editor by scaling the metric used to lay it out, so the
+// text size, line height, gutter widths and decoration strokes scale together.
+type zoomState struct {
+	// level is the zoom level. A zero level means no zooming.
+	level float32
+	// anchorLine is the logical line kept at anchorY, relative to the viewport,
+	// when the layout is updated for the new zoom level.
+	anchorLine int
+	anchorY    int
+	anchored   bool
+	// modifierHeld is set while the shortcut modifier of Ctrl+wheel zooming is
+	// held.
+	modifierHeld bool
+}
+
+func (z *zoomState) factor() float32 {
+	if z.level <= 0 {
+		return 1
+	}
+	return z.level
+}
+
+// scale returns the metric scaled by the zoom level.
+func (z *zoomState) scale(metric unit.Metric) unit.Metric {
+	f := z.factor()
+	metric.PxPerDp *= f
+	metric.PxPerSp *= f
+	return metric
+}
+
+func clampZoom(level float32) float32 {
+	// round to the step to avoid accumulating float errors.
+	level = float32(math.Round(float64(level/zoomStep))) * zoomStep
+	return max(MinZoom, min(level, MaxZoom))
+}
+
+// Zoom returns the zoom level of the editor. The default level is 1.
+func (e *Editor) Zoom() float32 {
+	return e.zoom.factor()
+}
+
+// SetZoom sets the zoom level of the editor, keeping the caret line in place.
+// The level is clamped to [MinZoom, MaxZoom]. A ZoomChangedEvent is queued if
+// the level is changed.
+func (e *Editor) SetZoom(level float32) {
+	e.initBuffer()
+	line, _ := e.text.CaretPos()
+	if evt := e.zoomAt(level, line); evt != nil {
+		e.pending = append(e.pending, evt)
+	}
+}
+
+// ZoomIn increases the zoom level by one step.
+func (e *Editor) ZoomIn() {
+	e.SetZoom(e.Zoom() + zoomStep)
+}
+
+// ZoomOut decreases the zoom level by one step.
+func (e *Editor) ZoomOut() {
+	e.SetZoom(e.Zoom() - zoomStep)
+}
+
+// ResetZoom restores the default zoom level.
+func (e *Editor) ResetZoom() {
+	e.SetZoom(1)
+}
+
+// zoomAt sets the zoom level, anchoring the logical line at its current position
+// in the viewport. It returns a ZoomChangedEvent if the level is changed.
+func (e *Editor) zoomAt(level float32, line int) EditorEvent {
+	level = clampZoom(level)
+	if level == e.zoom.factor() {
+		return nil
+	}
+
+	e.zoom.level = level
+	e.zoom.anchorLine = line
+	e.zoom.anchorY = e.text.LineTop(line)
+	e.zoom.anchored = true
+	return ZoomChangedEvent{Zoom: level}
+}
+
+// updateZoomModifier tracks the key events of the shortcut modifier, and
+// reports whether it is held. The modifier of the wheel scrolling corrects the
+// state if a release is missed, e.g., when the window loses the focus.
+func (e *Editor) updateZoomModifier(gtx layout.Context) bool {
+	name := key.NameCtrl
+	if key.ModShortcut == key.ModCommand {
+		name = key.NameCommand
+	}
+	for {
+		evt, ok := gtx.Event(key.Filter{Name: name, Optional: key.ModCtrl | key.ModCommand | key.ModShift | key.ModAlt | key.ModSuper})
+		if !ok {
+			break
+		}
+		if ke, ok := evt.(key.Event); ok {
+			e.zoom.modifierHeld = ke.State == key.Press
+		}
+	}
+	return e.zoom.modifierHeld
+}
+
+// processZoom applies the zoom steps of Ctrl+wheel scrolling, anchoring the line
+// under the pointer.
+func (e *Editor) processZoom() (EditorEvent, bool) {
+	steps, pos := e.scroller.Zoom()
+	if steps == 0 {
+		return nil, false
+	}
+
+	line := e.text.LineAt(int(pos.Y))
+	if evt := e.zoomAt(e.Zoom()+float32(steps)*zoomStep, line); evt != nil {
+		return evt, true
+	}
+	return nil, false
+}
+
+// restoreZoomAnchor scrolls the text to keep the anchor line at its position
+// before zooming. It must be called after the text is laid out.
+func (e *Editor) restoreZoomAnchor() {
+	if !e.zoom.anchored {
+		return
+	}
+	e.zoom.anchored = false
+	if e.zoom.anchorLine < 0 || e.zoom.anchorLine >= e.text.Paragraphs() {
+		return
+	}
+	e.text.ScrollRel(0, e.text.LineTop(e.zoom.anchorLine)-e.zoom.anchorY)
+}
+
+// zoomCommand handles the zoom shortcuts: Ctrl+= (or Ctrl++) zooms in, Ctrl+-
+// zooms out and Ctrl+0 resets the zoom level.
+func (e *Editor) zoomCommand(gtx layout.Context, evt key.Event) EditorEvent {
+	line, _ := e.text.CaretPos()
+	switch evt.Name {
+	case "=", "+":
+		return e.zoomAt(e.Zoom()+zoomStep, line)
+	case "-":
+		return e.zoomAt(e.Zoom()-zoomStep, line)
+	case "0":
+		return e.zoomAt(1, line)
+	}
+	return nil
+}
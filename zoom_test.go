@@ -0,0 +1,120 @@
+package gvcode
+
+import (
+	"image"
+	"testing"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+)
+
+// zoomEvents returns the zoom levels of the ZoomChangedEvents collected by the
+// window, and resets the events.
+func (w *testWindow) zoomEvents() []float32 {
+	var levels []float32
+	for _, evt := range w.events {
+		if z, ok := evt.(ZoomChangedEvent); ok {
+			levels = append(levels, z.Zoom)
+		}
+	}
+	w.events = w.events[:0]
+	return levels
+}
+
+func TestSetZoomEvent(t *testing.T) {
+	w := newTestWindow(t, "abc")
+	w.events = w.events[:0]
+
+	w.editor.SetZoom(1.5)
+	w.frame()
+	if got := w.zoomEvents(); len(got) != 1 || got[0] != 1.5 {
+		t.Errorf("SetZoom should emit a ZoomChangedEvent: %v", got)
+	}
+
+	// the level is not changed.
+	w.editor.SetZoom(1.5)
+	w.frame()
+	if got := w.zoomEvents(); len(got) != 0 {
+		t.Errorf("unexpected ZoomChangedEvent: %v", got)
+	}
+
+	w.editor.ResetZoom()
+	w.frame()
+	if got := w.zoomEvents(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("ResetZoom should emit a ZoomChangedEvent: %v", got)
+	}
+}
+
+func TestWheelZoom(t *testing.T) {
+	// the text fits in the viewport, so the editor can not scroll.
+	w := newTestWindow(t, "abc")
+	w.events = w.events[:0]
+
+	// a scrollable parent of the editor.
+	parent := new(int)
+	parentScroll := float32(0)
+	frame := func() {
+		w.ops.Reset()
+		gtx := layout.Context{
+			Ops:         &w.ops,
+			Metric:      unit.Metric{PxPerDp: 1, PxPerSp: 1},
+			Constraints: layout.Exact(image.Pt(500, 500)),
+			Source:      w.router.Source(),
+		}
+		for {
+			evt, ok := gtx.Event(pointer.Filter{Target: parent, Kinds: pointer.Scroll, ScrollY: pointer.ScrollRange{Min: -1000, Max: 1000}})
+			if !ok {
+				break
+			}
+			parentScroll += evt.(pointer.Event).Scroll.Y
+		}
+		defer clip.Rect(image.Rect(0, 0, 500, 500)).Push(gtx.Ops).Pop()
+		event.Op(gtx.Ops, parent)
+		for {
+			evt, ok := w.editor.Update(gtx)
+			if !ok {
+				break
+			}
+			w.events = append(w.events, evt)
+		}
+		w.editor.Layout(gtx, w.shaper)
+		w.router.Frame(&w.ops)
+	}
+	wheel := func(mods key.Modifiers) {
+		w.router.Queue(pointer.Event{
+			Kind:      pointer.Scroll,
+			Source:    pointer.Mouse,
+			Position:  f32.Pt(100, 10),
+			Scroll:    f32.Pt(0, -20),
+			Modifiers: mods,
+		})
+		frame()
+	}
+	frame()
+
+	wheel(0)
+	if parentScroll != -20 || len(w.zoomEvents()) != 0 {
+		t.Errorf("the wheel scrolling should be left to the parent: %v", parentScroll)
+	}
+
+	w.router.Queue(key.Event{Name: key.NameCtrl, State: key.Press})
+	frame()
+	parentScroll = 0
+	wheel(key.ModShortcut)
+	if got := w.zoomEvents(); len(got) != 1 || got[0] != 1.1 {
+		t.Errorf("Ctrl+wheel should zoom in: %v", got)
+	}
+
+	w.router.Queue(key.Event{Name: key.NameCtrl, State: key.Release})
+	frame()
+	parentScroll = 0
+	wheel(0)
+	if parentScroll != -20 || len(w.zoomEvents()) != 0 {
+		t.Errorf("the wheel scrolling should be left to the parent after Ctrl is released: %v", parentScroll)
+	}
+}